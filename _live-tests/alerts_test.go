@@ -41,7 +41,7 @@ func TestAlerts(t *testing.T) {
 	})
 
 	t.Run("List", func(t *testing.T) {
-		_, err := client.AlertsService().List()
+		_, err := client.AlertsService().List(nil)
 		assert.Nil(t, err)
 	})
 
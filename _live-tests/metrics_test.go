@@ -31,7 +31,7 @@ func TestMetrics(t *testing.T) {
 	})
 
 	t.Run("List", func(t *testing.T) {
-		_, err := client.MetricsService().List()
+		_, err := client.MetricsService().List(nil)
 		require.Nil(t, err)
 	})
 
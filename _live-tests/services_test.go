@@ -27,7 +27,7 @@ func TestServices(t *testing.T) {
 	})
 
 	t.Run("List", func(t *testing.T) {
-		_, err := client.ServicesService().List()
+		_, err := client.ServicesService().List(nil)
 		require.Nil(t, err)
 	})
 
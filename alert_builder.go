@@ -0,0 +1,305 @@
+package appoptics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AlertConditionType is the kind of test an AlertCondition applies
+type AlertConditionType string
+
+const (
+	// ConditionAbove triggers when the metric rises above the threshold
+	ConditionAbove AlertConditionType = "above"
+	// ConditionBelow triggers when the metric falls below the threshold
+	ConditionBelow AlertConditionType = "below"
+	// ConditionAbsent triggers when the metric has not reported for the duration
+	ConditionAbsent AlertConditionType = "absent"
+)
+
+// AlertSummaryFunction selects the statistic of aggregated measurements an AlertCondition tests
+type AlertSummaryFunction string
+
+const (
+	SummaryAverage    AlertSummaryFunction = "average"
+	SummarySum        AlertSummaryFunction = "sum"
+	SummaryCount      AlertSummaryFunction = "count"
+	SummaryMin        AlertSummaryFunction = "min"
+	SummaryMax        AlertSummaryFunction = "max"
+	SummaryLast       AlertSummaryFunction = "last"
+	SummaryDerivative AlertSummaryFunction = "derivative"
+)
+
+var alertSummaryFunctions = map[AlertSummaryFunction]bool{
+	SummaryAverage: true, SummarySum: true, SummaryCount: true, SummaryMin: true, SummaryMax: true,
+	SummaryLast: true, SummaryDerivative: true,
+}
+
+const (
+	// MinAlertConditionDuration is the shortest duration accepted for an AlertCondition
+	MinAlertConditionDuration = time.Minute
+	// MaxAlertConditionDuration is the longest duration accepted for an AlertCondition
+	MaxAlertConditionDuration = time.Hour
+)
+
+// ConditionBuilder builds an AlertCondition fluently, e.g.
+//
+//	Above("cpu.percent.used", 90).Summary(SummaryMax).For(5 * time.Minute).WithTag("env", "prod")
+type ConditionBuilder struct {
+	condition AlertCondition
+}
+
+// Above starts a condition triggering when metric exceeds threshold
+func Above(metric string, threshold float64) *ConditionBuilder {
+	return &ConditionBuilder{AlertCondition{Type: string(ConditionAbove), MetricName: metric, Threshold: threshold}}
+}
+
+// Below starts a condition triggering when metric falls below threshold
+func Below(metric string, threshold float64) *ConditionBuilder {
+	return &ConditionBuilder{AlertCondition{Type: string(ConditionBelow), MetricName: metric, Threshold: threshold}}
+}
+
+// Absent starts a condition triggering when metric has not reported for the given duration
+func Absent(metric string, duration time.Duration) *ConditionBuilder {
+	return &ConditionBuilder{AlertCondition{Type: string(ConditionAbsent), MetricName: metric, Duration: int(duration / time.Second)}}
+}
+
+// Summary sets the statistic tested by an above or below condition
+func (b *ConditionBuilder) Summary(f AlertSummaryFunction) *ConditionBuilder {
+	b.condition.SummaryFunction = string(f)
+	return b
+}
+
+// For requires an above or below condition to hold for the given duration before triggering
+func (b *ConditionBuilder) For(d time.Duration) *ConditionBuilder {
+	b.condition.Duration = int(d / time.Second)
+	return b
+}
+
+// DetectReset treats decreases of a derivative condition's metric as counter resets rather than negative rates
+func (b *ConditionBuilder) DetectReset() *ConditionBuilder {
+	b.condition.DetectReset = true
+	return b
+}
+
+// WithTag restricts the condition to series whose tag has one of the given values. Wildcards such as "prod-*"
+// are allowed.
+func (b *ConditionBuilder) WithTag(name string, values ...string) *ConditionBuilder {
+	b.condition.Tags = append(b.condition.Tags, TagFilter(name, values...))
+	return b
+}
+
+// GroupedBy tests each value of the tag separately, so that the Alert triggers per value. Without values,
+// every value of the tag is included.
+func (b *ConditionBuilder) GroupedBy(name string, values ...string) *ConditionBuilder {
+	b.condition.Tags = append(b.condition.Tags, GroupedTagFilter(name, values...))
+	return b
+}
+
+// Build validates and returns the AlertCondition
+func (b *ConditionBuilder) Build() (*AlertCondition, error) {
+	condition := b.condition
+	condition.Tags = append([]*Tag(nil), b.condition.Tags...)
+	if err := condition.Validate(); err != nil {
+		return nil, err
+	}
+	return &condition, nil
+}
+
+// TagFilter returns a Tag matching any of the given values
+func TagFilter(name string, values ...string) *Tag {
+	return &Tag{Name: name, Values: values}
+}
+
+// GroupedTagFilter returns a grouped Tag matching any of the given values, or all values if none are given
+func GroupedTagFilter(name string, values ...string) *Tag {
+	if len(values) == 0 {
+		values = []string{"*"}
+	}
+	return &Tag{Name: name, Values: values, Grouped: true}
+}
+
+// Validate checks the combination of fields in the AlertCondition against the rules of its type
+func (c *AlertCondition) Validate() error {
+	if c.MetricName == "" {
+		return errors.New("alert condition needs a metric name")
+	}
+
+	duration := time.Duration(c.Duration) * time.Second
+	if c.Duration != 0 && (duration < MinAlertConditionDuration || duration > MaxAlertConditionDuration) {
+		return fmt.Errorf("alert condition on %s: duration must be between %s and %s",
+			c.MetricName, MinAlertConditionDuration, MaxAlertConditionDuration)
+	}
+
+	switch AlertConditionType(c.Type) {
+	case ConditionAbove, ConditionBelow:
+		if c.SummaryFunction != "" && !alertSummaryFunctions[AlertSummaryFunction(c.SummaryFunction)] {
+			return fmt.Errorf("alert condition on %s: unknown summary function %q", c.MetricName, c.SummaryFunction)
+		}
+		if c.DetectReset && AlertSummaryFunction(c.SummaryFunction) != SummaryDerivative {
+			return fmt.Errorf("alert condition on %s: detect_reset only applies to the derivative summary function", c.MetricName)
+		}
+	case ConditionAbsent:
+		if c.Duration == 0 {
+			return fmt.Errorf("absent alert condition on %s needs a duration", c.MetricName)
+		}
+		if c.Threshold != 0 || c.SummaryFunction != "" || c.DetectReset {
+			return fmt.Errorf("absent alert condition on %s takes no threshold, summary function or detect_reset", c.MetricName)
+		}
+	default:
+		return fmt.Errorf("alert condition on %s: unknown type %q", c.MetricName, c.Type)
+	}
+
+	for _, tag := range c.Tags {
+		if tag.Name == "" || len(tag.Values) == 0 {
+			return fmt.Errorf("alert condition on %s: tag filters need a name and at least one value", c.MetricName)
+		}
+	}
+	return nil
+}
+
+// AlertBuilder builds an AlertRequest fluently, e.g.
+//
+//	NewAlertBuilder("api.latency.high").
+//		Condition(Above("api.latency", 500).For(5 * time.Minute)).
+//		NotifyServices(opsServiceID).
+//		Build()
+type AlertBuilder struct {
+	request    AlertRequest
+	conditions []*ConditionBuilder
+}
+
+// NewAlertBuilder starts an AlertRequest for an active Alert with the given name
+func NewAlertBuilder(name string) *AlertBuilder {
+	return &AlertBuilder{request: AlertRequest{Name: name}}
+}
+
+// Description sets the Alert description
+func (b *AlertBuilder) Description(description string) *AlertBuilder {
+	b.request.Description = description
+	return b
+}
+
+// RearmAfter sets the time after triggering before the Alert can trigger again
+func (b *AlertBuilder) RearmAfter(d time.Duration) *AlertBuilder {
+	b.request.RearmSeconds = int(d / time.Second)
+	return b
+}
+
+// Inactive creates the Alert disabled
+func (b *AlertBuilder) Inactive() *AlertBuilder {
+	active := false
+	b.request.Active = &active
+	return b
+}
+
+// Attribute sets an Alert attribute
+func (b *AlertBuilder) Attribute(name string, value interface{}) *AlertBuilder {
+	if b.request.Attributes == nil {
+		b.request.Attributes = map[string]interface{}{}
+	}
+	b.request.Attributes[name] = value
+	return b
+}
+
+// RunbookURL sets the runbook_url attribute, linked from notifications
+func (b *AlertBuilder) RunbookURL(url string) *AlertBuilder {
+	return b.Attribute("runbook_url", url)
+}
+
+// Condition adds conditions to the Alert, all of which must hold for it to trigger
+func (b *AlertBuilder) Condition(conditions ...*ConditionBuilder) *AlertBuilder {
+	b.conditions = append(b.conditions, conditions...)
+	return b
+}
+
+// NotifyServices adds the IDs of Services notified when the Alert triggers
+func (b *AlertBuilder) NotifyServices(ids ...int) *AlertBuilder {
+	b.request.Services = append(b.request.Services, ids...)
+	return b
+}
+
+// Build validates and returns the AlertRequest
+func (b *AlertBuilder) Build() (*AlertRequest, error) {
+	request := b.request
+	request.Conditions = nil
+	for _, cb := range b.conditions {
+		condition, err := cb.Build()
+		if err != nil {
+			return nil, fmt.Errorf("alert %q: %s", request.Name, err)
+		}
+		request.Conditions = append(request.Conditions, condition)
+	}
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Validate checks the AlertRequest has a name and at least one condition, and validates each condition
+func (r *AlertRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("alert needs a name")
+	}
+	if len(r.Conditions) == 0 {
+		return fmt.Errorf("alert %q needs at least one condition", r.Name)
+	}
+	if r.RearmSeconds < 0 {
+		return fmt.Errorf("alert %q: rearm time cannot be negative", r.Name)
+	}
+	for _, condition := range r.Conditions {
+		if err := condition.Validate(); err != nil {
+			return fmt.Errorf("alert %q: %s", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// ToRequest converts the Alert to an AlertRequest, referring to its Services by ID
+func (a *Alert) ToRequest() *AlertRequest {
+	request := &AlertRequest{
+		ID:           a.ID,
+		Name:         a.Name,
+		Description:  a.Description,
+		Active:       a.Active,
+		RearmSeconds: a.RearmSeconds,
+		Conditions:   a.Conditions,
+		Attributes:   a.Attributes,
+		CreatedAt:    a.CreatedAt,
+		UpdatedAt:    a.UpdatedAt,
+	}
+	for _, s := range a.Services {
+		request.Services = append(request.Services, s.ID)
+	}
+	return request
+}
+
+// ToAlert converts the AlertRequest to an Alert, looking up its Service IDs among services, such as those
+// returned by ListAllServices. An error is returned for IDs not among them.
+func (r *AlertRequest) ToAlert(services []*Service) (*Alert, error) {
+	byID := make(map[int]*Service, len(services))
+	for _, s := range services {
+		byID[s.ID] = s
+	}
+
+	alert := &Alert{
+		ID:           r.ID,
+		Name:         r.Name,
+		Description:  r.Description,
+		Active:       r.Active,
+		RearmSeconds: r.RearmSeconds,
+		Conditions:   r.Conditions,
+		Attributes:   r.Attributes,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+	for _, id := range r.Services {
+		s, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("alert %q refers to unknown service %d", r.Name, id)
+		}
+		alert.Services = append(alert.Services, s)
+	}
+	return alert, nil
+}
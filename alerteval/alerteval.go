@@ -0,0 +1,359 @@
+// Package alerteval evaluates Alert definitions against time series locally, producing the timeline of when
+// the Alert would have triggered and cleared. It is intended for testing Alert definitions before they are
+// deployed, against fixtures or data fetched with appoptics.RetrieveAll.
+//
+// Conditions are evaluated at a fixed step, one minute by default, like the AppOptics alerting service. At each
+// step the most recent point of each series is tested:
+//
+//   - above and below conditions hold once the summary statistic of every point of a series has been beyond the
+//     threshold for at least the condition's duration;
+//   - absent conditions hold once a series has not reported for the condition's duration, counting from the start
+//     of the evaluation for series which never reported.
+//
+// The Alert triggers when all its conditions hold, each for at least one series, and clears when they no longer
+// do. It cannot trigger again until its rearm time has passed since it last triggered; while the conditions keep
+// holding it triggers again each time the rearm time passes.
+package alerteval
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+// DefaultStep is the interval between evaluations
+const DefaultStep = time.Minute
+
+// DefaultRearm is the rearm time used by the API for Alerts which do not set one
+const DefaultRearm = 600 * time.Second
+
+// Point is a measurement of a series
+type Point struct {
+	Time  time.Time
+	Value float64
+	// Summary holds the statistics of an aggregated measurement, used by the sum, count, min, max and last summary
+	// functions. Without it, Value stands for every statistic, and the count is one.
+	Summary *Summary
+}
+
+// Summary is the statistics of an aggregated measurement
+type Summary struct {
+	Count int64
+	Sum   float64
+	Min   float64
+	Max   float64
+	Last  float64
+}
+
+// Series is the points reported by a Metric for one set of tags
+type Series struct {
+	Metric string
+	Tags   map[string]string
+	Points []Point
+}
+
+// EventType is a change of Alert state
+type EventType string
+
+const (
+	Trigger EventType = "trigger"
+	Clear   EventType = "clear"
+)
+
+// Event is a change of Alert state at a time. For triggers, Series lists the tags of the series meeting the
+// conditions.
+type Event struct {
+	Type   EventType
+	Time   time.Time
+	Series []map[string]string
+}
+
+// Options bounds the evaluation
+type Options struct {
+	// Start and End bound the evaluation, defaulting to the times of the earliest and latest points
+	Start time.Time
+	End   time.Time
+	// Step is the interval between evaluations, defaulting to DefaultStep
+	Step time.Duration
+}
+
+// FromMeasurements converts a measurements query response to Series of the queried Metric
+func FromMeasurements(resp *appoptics.MeasurementsResponse) []Series {
+	series := make([]Series, 0, len(resp.Series))
+	for _, s := range resp.Series {
+		points := make([]Point, len(s.Measurements))
+		for i, m := range s.Measurements {
+			points[i] = Point{Time: m.Timestamp(), Value: m.Value}
+		}
+		series = append(series, Series{Metric: resp.Name, Tags: s.Tags, Points: points})
+	}
+	return series
+}
+
+// Evaluate validates the Alert and returns the timeline of its triggers and clears over the given series
+func Evaluate(alert *appoptics.AlertRequest, series []Series, opts Options) ([]Event, error) {
+	if err := alert.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.Step <= 0 {
+		opts.Step = DefaultStep
+	}
+	if opts.Start.IsZero() || opts.End.IsZero() {
+		first, last, ok := timeRange(series)
+		if !ok && (opts.Start.IsZero() || opts.End.IsZero()) {
+			return nil, errors.New("an evaluation range is required when there are no points")
+		}
+		if opts.Start.IsZero() {
+			opts.Start = first
+		}
+		if opts.End.IsZero() {
+			opts.End = last
+		}
+	}
+
+	rearm := time.Duration(alert.RearmSeconds) * time.Second
+	if rearm == 0 {
+		rearm = DefaultRearm
+	}
+
+	conditions := make([]*conditionState, len(alert.Conditions))
+	for i, c := range alert.Conditions {
+		conditions[i] = newConditionState(c, series, opts.Start)
+	}
+
+	var events []Event
+	var active bool
+	var lastTrigger time.Time
+	for t := opts.Start; !t.After(opts.End); t = t.Add(opts.Step) {
+		met := true
+		var meeting []map[string]string
+		for _, c := range conditions {
+			tags := c.advance(t)
+			if len(tags) == 0 {
+				met = false
+			}
+			meeting = append(meeting, tags...)
+		}
+
+		switch {
+		case met && (lastTrigger.IsZero() || t.Sub(lastTrigger) >= rearm):
+			events = append(events, Event{Type: Trigger, Time: t, Series: uniqueTags(meeting)})
+			active, lastTrigger = true, t
+		case !met && active:
+			events = append(events, Event{Type: Clear, Time: t})
+			active = false
+		}
+	}
+	return events, nil
+}
+
+// conditionState tracks a condition over the series matching it
+type conditionState struct {
+	condition *appoptics.AlertCondition
+	duration  time.Duration
+	series    []*seriesState
+}
+
+// seriesState is the progress of a condition through one series
+type seriesState struct {
+	series *Series
+	// next is the index of the first point not yet seen
+	next int
+	// lastSeen is the time of the last point seen, or the evaluation start
+	lastSeen time.Time
+	// breachedSince is the time of the first point of the current run of points beyond the threshold
+	breachedSince time.Time
+	previous      *Point
+}
+
+func newConditionState(c *appoptics.AlertCondition, all []Series, start time.Time) *conditionState {
+	state := &conditionState{condition: c, duration: time.Duration(c.Duration) * time.Second}
+
+	for i := range all {
+		s := &all[i]
+		if s.Metric != c.MetricName || !matchesTags(c.Tags, s.Tags) {
+			continue
+		}
+		sorted := *s
+		sorted.Points = append([]Point(nil), s.Points...)
+		sort.SliceStable(sorted.Points, func(i, j int) bool {
+			return sorted.Points[i].Time.Before(sorted.Points[j].Time)
+		})
+		state.series = append(state.series, &seriesState{series: &sorted, lastSeen: start})
+	}
+
+	// an absent condition on a Metric which never reported holds once its duration has passed
+	if len(state.series) == 0 && c.Type == string(appoptics.ConditionAbsent) {
+		state.series = append(state.series, &seriesState{series: &Series{Metric: c.MetricName}, lastSeen: start})
+	}
+	return state
+}
+
+// advance consumes the points up to t and returns the tags of the series meeting the condition at t
+func (c *conditionState) advance(t time.Time) []map[string]string {
+	var meeting []map[string]string
+	for _, s := range c.series {
+		for s.next < len(s.series.Points) && !s.series.Points[s.next].Time.After(t) {
+			c.observe(s, s.series.Points[s.next])
+			s.next++
+		}
+
+		if c.condition.Type == string(appoptics.ConditionAbsent) {
+			if t.Sub(s.lastSeen) >= c.duration {
+				meeting = append(meeting, s.series.Tags)
+			}
+			continue
+		}
+		if !s.breachedSince.IsZero() && t.Sub(s.breachedSince) >= c.duration {
+			meeting = append(meeting, s.series.Tags)
+		}
+	}
+	return meeting
+}
+
+func (c *conditionState) observe(s *seriesState, p Point) {
+	s.lastSeen = p.Time
+	previous := s.previous
+	s.previous = &p
+
+	value, ok := statistic(c.condition, p, previous)
+	if !ok {
+		return
+	}
+
+	breached := value > c.condition.Threshold
+	if c.condition.Type == string(appoptics.ConditionBelow) {
+		breached = value < c.condition.Threshold
+	}
+	switch {
+	case !breached:
+		s.breachedSince = time.Time{}
+	case s.breachedSince.IsZero():
+		s.breachedSince = p.Time
+	}
+}
+
+// statistic returns the value tested for a point, or false if the point cannot be tested
+func statistic(c *appoptics.AlertCondition, p Point, previous *Point) (float64, bool) {
+	summary := p.Summary
+	switch appoptics.AlertSummaryFunction(c.SummaryFunction) {
+	case appoptics.SummarySum:
+		if summary != nil {
+			return summary.Sum, true
+		}
+	case appoptics.SummaryCount:
+		if summary != nil {
+			return float64(summary.Count), true
+		}
+		return 1, true
+	case appoptics.SummaryMin:
+		if summary != nil {
+			return summary.Min, true
+		}
+	case appoptics.SummaryMax:
+		if summary != nil {
+			return summary.Max, true
+		}
+	case appoptics.SummaryLast:
+		if summary != nil {
+			return summary.Last, true
+		}
+	case appoptics.SummaryDerivative:
+		if previous == nil {
+			return 0, false
+		}
+		delta := p.Value - previous.Value
+		if delta < 0 && c.DetectReset {
+			return 0, false
+		}
+		return delta, true
+	default:
+		if summary != nil && summary.Count > 0 {
+			return summary.Sum / float64(summary.Count), true
+		}
+	}
+	return p.Value, true
+}
+
+// matchesTags reports whether the series tags satisfy every tag filter, with values matched as wildcards
+func matchesTags(filters []*appoptics.Tag, tags map[string]string) bool {
+	for _, filter := range filters {
+		value, ok := tags[filter.Name]
+		if !ok {
+			return false
+		}
+		matched := false
+		for _, pattern := range filter.Values {
+			if matchWildcard(pattern, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matchWildcard reports whether value matches pattern, in which each * stands for any run of characters,
+// including none. Unlike path.Match, * also matches "/", as in the API's tag filters.
+func matchWildcard(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(value, part)
+		if i < 0 {
+			return false
+		}
+		value = value[i+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+func timeRange(series []Series) (first, last time.Time, ok bool) {
+	for _, s := range series {
+		for _, p := range s.Points {
+			if !ok || p.Time.Before(first) {
+				first = p.Time
+			}
+			if !ok || p.Time.After(last) {
+				last = p.Time
+			}
+			ok = true
+		}
+	}
+	return first, last, ok
+}
+
+func uniqueTags(tags []map[string]string) []map[string]string {
+	seen := map[string]bool{}
+	var unique []map[string]string
+	for _, t := range tags {
+		key := tagsKey(t)
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+func tagsKey(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
@@ -0,0 +1,225 @@
+package alerteval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func minute(n int) time.Time {
+	return start.Add(time.Duration(n) * time.Minute)
+}
+
+// perMinute returns a series with one point a minute from the start
+func perMinute(metric string, tags map[string]string, values ...float64) Series {
+	s := Series{Metric: metric, Tags: tags}
+	for i, v := range values {
+		s.Points = append(s.Points, Point{Time: minute(i), Value: v})
+	}
+	return s
+}
+
+func timeline(events []Event) []string {
+	var out []string
+	for _, e := range events {
+		out = append(out, string(e.Type)+"@"+e.Time.Sub(start).String())
+	}
+	return out
+}
+
+func TestEvaluateAboveWithDuration(t *testing.T) {
+	alert, err := appoptics.NewAlertBuilder("cpu.high").
+		RearmAfter(time.Hour).
+		Condition(appoptics.Above("cpu", 90).For(2 * time.Minute)).
+		Build()
+	require.NoError(t, err)
+
+	series := []Series{perMinute("cpu", nil, 50, 95, 96, 97, 98, 50, 95, 50)}
+	events, err := Evaluate(alert, series, Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"trigger@3m0s", "clear@5m0s"}, timeline(events))
+}
+
+func TestEvaluateRearm(t *testing.T) {
+	alert, err := appoptics.NewAlertBuilder("cpu.high").
+		RearmAfter(3 * time.Minute).
+		Condition(appoptics.Above("cpu", 90)).
+		Build()
+	require.NoError(t, err)
+
+	series := []Series{perMinute("cpu", nil, 95, 50, 95, 95, 95, 95, 95)}
+	events, err := Evaluate(alert, series, Options{})
+	require.NoError(t, err)
+
+	// the breach at 2m is within the rearm time of the trigger at 0m, and the still breaching alert triggers again
+	// once the rearm time has passed
+	assert.Equal(t, []string{"trigger@0s", "clear@1m0s", "trigger@3m0s", "trigger@6m0s"}, timeline(events))
+}
+
+func TestEvaluateDefaultRearm(t *testing.T) {
+	condition, err := appoptics.Above("cpu", 90).Build()
+	require.NoError(t, err)
+	alert := &appoptics.AlertRequest{Name: "cpu.high", Conditions: []*appoptics.AlertCondition{condition}}
+	values := make([]float64, 12)
+	for i := range values {
+		values[i] = 95
+	}
+
+	events, err := Evaluate(alert, []Series{perMinute("cpu", nil, values...)}, Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"trigger@0s", "trigger@10m0s"}, timeline(events))
+}
+
+func TestEvaluateAbsent(t *testing.T) {
+	alert, err := appoptics.NewAlertBuilder("heartbeat.missing").
+		RearmAfter(5 * time.Minute).
+		Condition(appoptics.Absent("heartbeat", 2*time.Minute)).
+		Build()
+	require.NoError(t, err)
+
+	series := []Series{{Metric: "heartbeat", Points: []Point{{Time: minute(0), Value: 1}, {Time: minute(5), Value: 1}}}}
+	events, err := Evaluate(alert, series, Options{End: minute(8)})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"trigger@2m0s", "clear@5m0s", "trigger@7m0s"}, timeline(events))
+}
+
+func TestEvaluateAbsentWithoutSeries(t *testing.T) {
+	alert, err := appoptics.NewAlertBuilder("heartbeat.missing").
+		Condition(appoptics.Absent("heartbeat", time.Minute)).
+		Build()
+	require.NoError(t, err)
+
+	events, err := Evaluate(alert, nil, Options{Start: start, End: minute(3)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trigger@1m0s"}, timeline(events))
+
+	_, err = Evaluate(alert, nil, Options{})
+	assert.Error(t, err)
+}
+
+func TestEvaluateSummaryFunctions(t *testing.T) {
+	points := []Point{
+		{Time: minute(0), Summary: &Summary{Count: 4, Sum: 40, Min: 1, Max: 25, Last: 5}},
+		{Time: minute(1), Summary: &Summary{Count: 4, Sum: 100, Min: 2, Max: 60, Last: 30}},
+	}
+	series := []Series{{Metric: "latency", Points: points}}
+
+	tests := []struct {
+		summary   appoptics.AlertSummaryFunction
+		threshold float64
+		expected  []string
+	}{
+		{appoptics.SummaryAverage, 20, []string{"trigger@1m0s"}},
+		{appoptics.SummarySum, 50, []string{"trigger@1m0s"}},
+		{appoptics.SummaryCount, 3, []string{"trigger@0s"}},
+		{appoptics.SummaryMin, 1, []string{"trigger@1m0s"}},
+		{appoptics.SummaryMax, 20, []string{"trigger@0s"}},
+		{appoptics.SummaryLast, 10, []string{"trigger@1m0s"}},
+	}
+	for _, test := range tests {
+		t.Run(string(test.summary), func(t *testing.T) {
+			alert, err := appoptics.NewAlertBuilder("latency.high").
+				Condition(appoptics.Above("latency", test.threshold).Summary(test.summary)).
+				Build()
+			require.NoError(t, err)
+
+			events, err := Evaluate(alert, series, Options{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, timeline(events))
+		})
+	}
+}
+
+func TestEvaluateDerivative(t *testing.T) {
+	series := []Series{perMinute("requests", nil, 100, 150, 10, 20)}
+
+	alert, err := appoptics.NewAlertBuilder("requests.dropped").
+		Condition(appoptics.Below("requests", 0).Summary(appoptics.SummaryDerivative)).
+		Build()
+	require.NoError(t, err)
+
+	events, err := Evaluate(alert, series, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trigger@2m0s", "clear@3m0s"}, timeline(events))
+
+	alert, err = appoptics.NewAlertBuilder("requests.dropped").
+		Condition(appoptics.Below("requests", 0).Summary(appoptics.SummaryDerivative).DetectReset()).
+		Build()
+	require.NoError(t, err)
+
+	events, err = Evaluate(alert, series, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestEvaluateTagsAndConditions(t *testing.T) {
+	alert, err := appoptics.NewAlertBuilder("prod.overloaded").
+		Condition(appoptics.Above("cpu", 90).WithTag("env", "prod*")).
+		Condition(appoptics.Above("load", 4)).
+		Build()
+	require.NoError(t, err)
+
+	series := []Series{
+		perMinute("cpu", map[string]string{"env": "staging", "host": "a"}, 99, 99, 99),
+		perMinute("cpu", map[string]string{"env": "production", "host": "b"}, 50, 95, 95),
+		perMinute("load", map[string]string{"host": "b"}, 5, 5, 1),
+	}
+	events, err := Evaluate(alert, series, Options{})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"trigger@1m0s", "clear@2m0s"}, timeline(events))
+	assert.Equal(t, []map[string]string{
+		{"env": "production", "host": "b"},
+		{"host": "b"},
+	}, events[0].Series)
+}
+
+func TestMatchWildcard(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, value string
+		want           bool
+	}{
+		{"*", "api/v1", true},
+		{"api/*", "api/v1/users", true},
+		{"*/v1", "api/v1", true},
+		{"a*b*c", "a/b/b/c", true},
+		{"prod", "prod", true},
+		{"prod", "production", false},
+		{"prod*", "staging", false},
+		{"*ab*ab", "ab", false},
+		{"[a]*", "[a]x", true},
+	} {
+		assert.Equal(t, tc.want, matchWildcard(tc.pattern, tc.value), "%q against %q", tc.value, tc.pattern)
+	}
+}
+
+func TestEvaluateInvalidAlert(t *testing.T) {
+	_, err := Evaluate(&appoptics.AlertRequest{Name: "empty"}, nil, Options{Start: start, End: minute(1)})
+	assert.Error(t, err)
+}
+
+func TestFromMeasurements(t *testing.T) {
+	resp := &appoptics.MeasurementsResponse{
+		Name: "cpu",
+		Series: []appoptics.MeasurementSeries{{
+			Tags:         map[string]string{"host": "a"},
+			Measurements: []appoptics.MeasurementPoint{{Time: start.Unix(), Value: 42}},
+		}},
+	}
+
+	series := FromMeasurements(resp)
+	require.Len(t, series, 1)
+	assert.Equal(t, "cpu", series[0].Metric)
+	assert.Equal(t, map[string]string{"host": "a"}, series[0].Tags)
+	require.Len(t, series[0].Points, 1)
+	assert.True(t, series[0].Points[0].Time.Equal(start))
+	assert.Equal(t, 42.0, series[0].Points[0].Value)
+}
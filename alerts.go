@@ -1,6 +1,7 @@
 package appoptics
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -58,7 +59,8 @@ type AlertsService struct {
 }
 
 type AlertsCommunicator interface {
-	List() (*AlertsListResponse, error)
+	List(*PaginationParameters) (*AlertsListResponse, error)
+	ListContext(context.Context, *PaginationParameters) (*AlertsListResponse, error)
 	Retrieve(int) (*Alert, error)
 	Create(*AlertRequest) (*Alert, error)
 	Update(*AlertRequest) error
@@ -66,22 +68,52 @@ type AlertsCommunicator interface {
 	DisassociateFromService(alertId, serviceId int) error
 	Delete(int) error
 	Status(int) (*AlertStatus, error)
+	StatusContext(context.Context, int) (*AlertStatus, error)
 }
 
 func NewAlertsService(c *Client) *AlertsService {
 	return &AlertsService{c}
 }
 
-// List retrieves all Alerts
-func (as *AlertsService) List() (*AlertsListResponse, error) {
+// List retrieves a page of Alerts. A nil PaginationParameters retrieves the first page.
+func (as *AlertsService) List(rp *PaginationParameters) (*AlertsListResponse, error) {
+	return as.ListContext(context.Background(), rp)
+}
+
+// ListAllAlerts retrieves every Alert, requesting further pages until all have been fetched
+func ListAllAlerts(ac AlertsCommunicator) ([]*Alert, error) {
+	return listAllAlerts(context.Background(), ac)
+}
+
+func listAllAlerts(ctx context.Context, ac AlertsCommunicator) ([]*Alert, error) {
+	var alerts []*Alert
+	rp := &PaginationParameters{}
+	for {
+		alertsResponse, err := ac.ListContext(ctx, rp)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alertsResponse.Alerts...)
+
+		rp.Offset += len(alertsResponse.Alerts)
+		if len(alertsResponse.Alerts) == 0 || rp.Offset >= alertsResponse.Query.Found {
+			return alerts, nil
+		}
+	}
+}
+
+// ListContext retrieves a page of Alerts like List, canceling the request if ctx is done first
+func (as *AlertsService) ListContext(ctx context.Context, rp *PaginationParameters) (*AlertsListResponse, error) {
 	req, err := as.client.NewRequest("GET", "alerts", nil)
 	if err != nil {
 		return nil, err
 	}
 
+	rp.AddToRequest(req)
+
 	alertsResponse := &AlertsListResponse{}
 
-	_, err = as.client.Do(req, &alertsResponse)
+	_, err = as.client.Do(req.WithContext(ctx), &alertsResponse)
 
 	if err != nil {
 		return nil, err
@@ -191,6 +223,11 @@ func (as *AlertsService) Delete(id int) error {
 
 // Status returns the Alert's status
 func (as *AlertsService) Status(id int) (*AlertStatus, error) {
+	return as.StatusContext(context.Background(), id)
+}
+
+// StatusContext returns the status of the Alert like Status, canceling the request if ctx is done first
+func (as *AlertsService) StatusContext(ctx context.Context, id int) (*AlertStatus, error) {
 	path := fmt.Sprintf("alerts/%d/status", id)
 	req, err := as.client.NewRequest("GET", path, nil)
 	if err != nil {
@@ -199,7 +236,7 @@ func (as *AlertsService) Status(id int) (*AlertStatus, error) {
 
 	alertStatus := &AlertStatus{}
 
-	_, err = as.client.Do(req, alertStatus)
+	_, err = as.client.Do(req.WithContext(ctx), alertStatus)
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,253 @@
+package appoptics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAlertWatchInterval   = time.Minute
+	defaultAlertWatchMaxBackoff = 10 * time.Minute
+)
+
+// AlertState is the state of an Alert as observed by WatchAlerts
+type AlertState string
+
+const (
+	AlertStateOK        AlertState = "ok"
+	AlertStateTriggered AlertState = "triggered"
+	AlertStateDisabled  AlertState = "disabled"
+)
+
+// AlertTransition is a change between AlertStates
+type AlertTransition string
+
+const (
+	// AlertTriggered is reported when an Alert enters the triggered state
+	AlertTriggered AlertTransition = "triggered"
+	// AlertCleared is reported when a triggered Alert returns to the ok state
+	AlertCleared AlertTransition = "cleared"
+	// AlertDisabled is reported when an Alert is made inactive
+	AlertDisabled AlertTransition = "disabled"
+	// AlertEnabled is reported when an inactive Alert is made active again and is not triggered
+	AlertEnabled AlertTransition = "enabled"
+)
+
+// AlertTransitionEvent reports an Alert changing state between two polls
+type AlertTransitionEvent struct {
+	Alert      *Alert          `json:"alert"`
+	Transition AlertTransition `json:"transition"`
+	From       AlertState      `json:"from"`
+	To         AlertState      `json:"to"`
+	// Time is when the change was observed
+	Time time.Time `json:"time"`
+}
+
+// WatchAlertsOption configures WatchAlerts and WatchAlertsChan
+type WatchAlertsOption func(*watchAlertsConfig)
+
+type watchAlertsConfig struct {
+	interval   time.Duration
+	maxBackoff time.Duration
+	ids        map[int]bool
+	errors     func(error)
+}
+
+// WatchInterval sets the delay between polls, which must be positive. The default is one minute.
+func WatchInterval(interval time.Duration) WatchAlertsOption {
+	return func(c *watchAlertsConfig) {
+		c.interval = interval
+	}
+}
+
+// WatchMaxBackoff sets the limit the delay between polls backs off to while requests fail, which must be
+// positive. The default is ten minutes.
+func WatchMaxBackoff(max time.Duration) WatchAlertsOption {
+	return func(c *watchAlertsConfig) {
+		c.maxBackoff = max
+	}
+}
+
+// WatchAlertIDs restricts the watch to the Alerts with the given IDs. By default every Alert is watched.
+func WatchAlertIDs(ids ...int) WatchAlertsOption {
+	return func(c *watchAlertsConfig) {
+		for _, id := range ids {
+			c.ids[id] = true
+		}
+	}
+}
+
+// WatchErrors sets a function called with the errors of failed polls. Rate limited polls are retried without
+// being reported.
+func WatchErrors(fn func(error)) WatchAlertsOption {
+	return func(c *watchAlertsConfig) {
+		c.errors = fn
+	}
+}
+
+// WatchAlerts polls the state of the Alerts until ctx is done, calling fn with each transition it observes, and
+// returns the context's error. The first poll records the current states without reporting them. Inactive Alerts
+// are in the disabled state; the status of active ones is retrieved on every poll. Alerts which are deleted are no
+// longer watched.
+//
+// Each poll lists the Alerts a page at a time and then makes one Status request per active watched Alert, so
+// watching many Alerts at a short interval uses up the API rate limit quickly; WatchAlertIDs limits the Status
+// requests to the given Alerts. Requests are made with ListContext and StatusContext, so that they are canceled
+// along with ctx.
+//
+// Failed polls are retried, backing off exponentially; when the API rate limits a poll, the Retry-After delay it
+// returns is honored instead. Polls are never made more often than the interval, and an error is returned at once
+// for a non-positive interval or backoff limit.
+func WatchAlerts(ctx context.Context, ac AlertsCommunicator, fn func(AlertTransitionEvent), opts ...WatchAlertsOption) error {
+	config := &watchAlertsConfig{
+		interval:   defaultAlertWatchInterval,
+		maxBackoff: defaultAlertWatchMaxBackoff,
+		ids:        map[int]bool{},
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.interval <= 0 || config.maxBackoff <= 0 {
+		return errors.New("alert watch interval and backoff must be positive")
+	}
+
+	states := map[int]AlertState{}
+	delay := config.interval
+
+	for {
+		err := pollAlerts(ctx, ac, config, states, fn)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == nil:
+			delay = config.interval
+		default:
+			if wait, limited := retryAfter(err); limited {
+				delay = wait
+			} else {
+				if config.errors != nil {
+					config.errors(err)
+				}
+				if delay *= 2; delay > config.maxBackoff {
+					delay = config.maxBackoff
+				}
+			}
+			if delay < config.interval {
+				delay = config.interval
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// WatchAlertsChan runs WatchAlerts in a goroutine, sending the transitions on the first returned channel, which
+// is closed once WatchAlerts returns. Its error is then sent on the second channel, which is closed in turn.
+// Polling waits for each transition to be received.
+//
+//	events, errs := appoptics.WatchAlertsChan(ctx, client.AlertsService())
+//	for event := range events {
+//		...
+//	}
+//	if err := <-errs; err != context.Canceled {
+//		...
+//	}
+func WatchAlertsChan(ctx context.Context, ac AlertsCommunicator, opts ...WatchAlertsOption) (<-chan AlertTransitionEvent, <-chan error) {
+	events := make(chan AlertTransitionEvent)
+	errs := make(chan error, 1)
+	go func() {
+		err := WatchAlerts(ctx, ac, func(event AlertTransitionEvent) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		}, opts...)
+		close(events)
+		errs <- err
+		close(errs)
+	}()
+	return events, errs
+}
+
+// pollAlerts retrieves the states of the watched Alerts, reporting those which changed since the last poll
+func pollAlerts(ctx context.Context, ac AlertsCommunicator, config *watchAlertsConfig, states map[int]AlertState, fn func(AlertTransitionEvent)) error {
+	alerts, err := listAllAlerts(ctx, ac)
+	if err != nil {
+		return err
+	}
+
+	seen := map[int]bool{}
+	for _, alert := range alerts {
+		if len(config.ids) > 0 && !config.ids[alert.ID] {
+			continue
+		}
+		seen[alert.ID] = true
+
+		state := AlertStateDisabled
+		if alert.Active == nil || *alert.Active {
+			status, err := ac.StatusContext(ctx, alert.ID)
+			if err != nil {
+				return err
+			}
+			state = AlertStateOK
+			if status.Status == string(AlertStateTriggered) {
+				state = AlertStateTriggered
+			}
+		}
+
+		previous, known := states[alert.ID]
+		states[alert.ID] = state
+		if !known || previous == state {
+			continue
+		}
+		fn(AlertTransitionEvent{
+			Alert:      alert,
+			Transition: transitionTo(previous, state),
+			From:       previous,
+			To:         state,
+			Time:       time.Now(),
+		})
+	}
+
+	for id := range states {
+		if !seen[id] {
+			delete(states, id)
+		}
+	}
+	return nil
+}
+
+func transitionTo(from, to AlertState) AlertTransition {
+	switch {
+	case to == AlertStateTriggered:
+		return AlertTriggered
+	case to == AlertStateDisabled:
+		return AlertDisabled
+	case from == AlertStateDisabled:
+		return AlertEnabled
+	default:
+		return AlertCleared
+	}
+}
+
+// retryAfter reports whether err is a rate limited response, and how long the API asked to wait
+func retryAfter(err error) (time.Duration, bool) {
+	errResponse, ok := err.(*ErrorResponse)
+	if !ok || errResponse.Response == nil || errResponse.Response.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(errResponse.Response.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return defaultAlertWatchInterval, true
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,177 @@
+package alertsync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const opsAlerts = `
+version: 1
+alerts:
+  - name: ops.cpu.high
+    description: CPU is high
+    rearm_seconds: 600
+    conditions:
+      - type: above
+        metric_name: cpu.percent.used
+        threshold: 90
+        summary_function: average
+        duration: 300
+        tags:
+          - name: env
+            values: [prod]
+    services: [Ops Email]
+`
+
+func decode(t *testing.T, input string) *Document {
+	doc, err := Decode(strings.NewReader(input))
+	require.NoError(t, err)
+	return doc
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"unknown field":  "version: 1\nalerts: []\ncolour: red\n",
+		"bad version":    "version: 2\n",
+		"unnamed alert":  "version: 1\nalerts:\n  - conditions: [{type: above, metric_name: x}]\n",
+		"no conditions":  "version: 1\nalerts:\n  - name: a\n",
+		"duplicate name": "version: 1\nalerts:\n  - name: a\n    conditions: [{type: absent, metric_name: x}]\n  - name: a\n    conditions: [{type: absent, metric_name: x}]\n",
+		"json unknown":   `{"version": 1, "alerts": [], "colour": "red"}`,
+	} {
+		_, err := Decode(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ops.yaml"), []byte(opsAlerts), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.json"), []byte(
+		`{"version": 1, "alerts": [{"name": "api.absent", "conditions": [{"type": "absent", "metric_name": "api.requests", "duration": 600}]}]}`,
+	), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a document"), 0o644))
+
+	doc, err := LoadFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, doc.Alerts, 2)
+	assert.Equal(t, "ops.cpu.high", doc.Alerts[0].Name)
+	assert.Equal(t, "api.absent", doc.Alerts[1].Name)
+
+	// the same Alert in two files is a conflict
+	_, err = LoadFiles(filepath.Join(dir, "ops.yaml"), filepath.Join(dir, "ops.yaml"))
+	assert.Error(t, err)
+}
+
+func TestPlanAndApply(t *testing.T) {
+	api, client := newFakeAPI(t)
+	emailID := api.addService("Ops Email")
+	api.addService("Ops Slack")
+	api.addService("Unused")
+
+	doc := decode(t, opsAlerts)
+
+	plan, err := MakePlan(client, doc)
+	require.NoError(t, err)
+	require.Len(t, plan.Changes, 1)
+	assert.Equal(t, Create, plan.Changes[0].Action)
+	assert.Contains(t, plan.Changes[0].Diff, "+ name: ops.cpu.high")
+	assert.Contains(t, plan.String(), "1 to create, 0 to update, 0 to delete, 0 unchanged")
+
+	require.NoError(t, Apply(client, plan))
+	assert.Equal(t, []string{"POST /v1/alerts"}, api.takeCalls())
+	require.Len(t, api.alerts, 1)
+	assert.Equal(t, emailID, api.alerts[0].Services[0].ID)
+
+	// applying the same document again is a no-op
+	plan, err = MakePlan(client, doc)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), plan.String())
+
+	// changing only the Services associates and disassociates without updating the Alert
+	doc.Alerts[0].Services = []string{"Ops Slack"}
+	plan, err = MakePlan(client, doc)
+	require.NoError(t, err)
+	require.True(t, plan.HasChanges())
+	assert.Equal(t, []string{"Ops Slack"}, plan.Changes[0].Associate)
+	assert.Equal(t, []string{"Ops Email"}, plan.Changes[0].Disassociate)
+	require.NoError(t, Apply(client, plan))
+	id := api.alerts[0].ID
+	assert.Equal(t, []string{
+		fmt.Sprintf("POST /v1/alerts/%d/services", id),
+		fmt.Sprintf("DELETE /v1/alerts/%d/services/%d", id, emailID),
+	}, api.takeCalls())
+
+	// changing the threshold updates the Alert and shows the change in the diff
+	doc.Alerts[0].Conditions[0].Threshold = 95
+	plan, err = MakePlan(client, doc)
+	require.NoError(t, err)
+	assert.Equal(t, Update, plan.Changes[0].Action)
+	assert.Contains(t, plan.Changes[0].Diff, "-     threshold: 90")
+	assert.Contains(t, plan.Changes[0].Diff, "+     threshold: 95")
+	require.NoError(t, Apply(client, plan))
+	assert.Equal(t, []string{fmt.Sprintf("PUT /v1/alerts/%d", id)}, api.takeCalls())
+	assert.Equal(t, "Ops Slack", api.alerts[0].Services[0].Title, "updates must not drop Services")
+
+	plan, err = MakePlan(client, doc)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges(), plan.String())
+}
+
+func TestPlanPrune(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.addService("Ops Email")
+	condition := []*appoptics.AlertCondition{{Type: "absent", MetricName: "x"}}
+	api.addAlert(&appoptics.Alert{Name: "ops.disk.full", Conditions: condition})
+	api.addAlert(&appoptics.Alert{Name: "ops.memory.low", Conditions: condition})
+	api.addAlert(&appoptics.Alert{Name: "team.other", Conditions: condition})
+
+	doc := decode(t, opsAlerts)
+
+	plan, err := MakePlan(client, doc)
+	require.NoError(t, err)
+	assert.Len(t, plan.Changes, 1, "alerts absent from the document are left alone without Prune")
+
+	plan, err = MakePlan(client, doc, Prune("ops."))
+	require.NoError(t, err)
+	actions := map[string]Action{}
+	for _, change := range plan.Changes {
+		actions[change.Alert] = change.Action
+	}
+	assert.Equal(t, map[string]Action{
+		"ops.cpu.high":   Create,
+		"ops.disk.full":  Delete,
+		"ops.memory.low": Delete,
+	}, actions)
+
+	require.NoError(t, Apply(client, plan))
+	var names []string
+	for _, alert := range api.alerts {
+		names = append(names, alert.Name)
+	}
+	assert.ElementsMatch(t, []string{"team.other", "ops.cpu.high"}, names)
+}
+
+func TestPlanServiceErrors(t *testing.T) {
+	api, client := newFakeAPI(t)
+
+	_, err := MakePlan(client, decode(t, opsAlerts))
+	assert.EqualError(t, err, `alert "ops.cpu.high" refers to unknown service "Ops Email"`)
+
+	api.addService("Ops Email")
+	api.addService("Ops Email")
+	_, err = MakePlan(client, decode(t, opsAlerts))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "several services")
+}
+
+func TestDecodeRejectsInvalidConditions(t *testing.T) {
+	_, err := Decode(strings.NewReader("version: 1\nalerts:\n  - name: a\n    conditions: [{type: absent, metric_name: x}]\n"))
+	assert.Error(t, err, "absent conditions need a duration")
+}
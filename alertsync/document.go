@@ -0,0 +1,242 @@
+// Package alertsync manages AppOptics Alerts as code. Desired Alerts are read from YAML or JSON documents,
+// which refer to Services by title rather than ID, compared against the Alerts in an organization to produce
+// a Plan, and the Plan is then applied.
+package alertsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/appoptics/appoptics-api-go"
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentVersion is the version of the document format understood by this package
+const DocumentVersion = 1
+
+// Document is a set of desired Alerts
+type Document struct {
+	Version int     `json:"version" yaml:"version"`
+	Alerts  []Alert `json:"alerts" yaml:"alerts"`
+}
+
+// Alert is the desired state of an appoptics.Alert. Alerts are identified by Name, and Services by title.
+type Alert struct {
+	Name         string                 `json:"name" yaml:"name"`
+	Description  string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Active       *bool                  `json:"active,omitempty" yaml:"active,omitempty"`
+	RearmSeconds int                    `json:"rearm_seconds,omitempty" yaml:"rearm_seconds,omitempty"`
+	Conditions   []Condition            `json:"conditions" yaml:"conditions"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Services     []string               `json:"services,omitempty" yaml:"services,omitempty"`
+}
+
+// Condition is the desired state of an appoptics.AlertCondition
+type Condition struct {
+	Type            string  `json:"type" yaml:"type"`
+	MetricName      string  `json:"metric_name" yaml:"metric_name"`
+	Threshold       float64 `json:"threshold" yaml:"threshold"`
+	SummaryFunction string  `json:"summary_function,omitempty" yaml:"summary_function,omitempty"`
+	Duration        int     `json:"duration,omitempty" yaml:"duration,omitempty"`
+	DetectReset     bool    `json:"detect_reset,omitempty" yaml:"detect_reset,omitempty"`
+	Tags            []Tag   `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Tag is a tag filter on a Condition
+type Tag struct {
+	Name    string   `json:"name" yaml:"name"`
+	Values  []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Grouped bool     `json:"grouped,omitempty" yaml:"grouped,omitempty"`
+}
+
+// Decode reads a Document as YAML or, if it begins with "{", as JSON
+func Decode(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(doc)
+	} else {
+		dec := yaml.NewDecoder(strings.NewReader(string(data)))
+		dec.KnownFields(true)
+		err = dec.Decode(doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, doc.Validate()
+}
+
+// LoadFiles reads and merges the Documents in the given files. Directories are expanded to the .yaml, .yml
+// and .json files they contain.
+func LoadFiles(paths ...string) (*Document, error) {
+	merged := &Document{Version: DocumentVersion}
+
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		for _, pattern := range []string{"*.yaml", "*.yml", "*.json"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			sort.Strings(matches)
+			files = append(files, matches...)
+		}
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		doc, err := Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		merged.Alerts = append(merged.Alerts, doc.Alerts...)
+	}
+
+	return merged, merged.Validate()
+}
+
+// Validate checks the Document version, that Alert names are present and unique, and that every Alert has at
+// least one Condition and passes appoptics.AlertRequest.Validate
+func (d *Document) Validate() error {
+	if d.Version != DocumentVersion {
+		return fmt.Errorf("unsupported document version %d", d.Version)
+	}
+
+	seen := map[string]bool{}
+	for i, alert := range d.Alerts {
+		if alert.Name == "" {
+			return fmt.Errorf("alert %d has no name", i+1)
+		}
+		if seen[alert.Name] {
+			return fmt.Errorf("alert name %q is not unique", alert.Name)
+		}
+		seen[alert.Name] = true
+
+		if len(alert.Conditions) == 0 {
+			return fmt.Errorf("alert %q has no conditions", alert.Name)
+		}
+		for j, condition := range alert.Conditions {
+			if condition.Type == "" || condition.MetricName == "" {
+				return fmt.Errorf("alert %q condition %d needs a type and metric_name", alert.Name, j+1)
+			}
+		}
+		if err := alert.request(nil).Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromAlert converts an appoptics.Alert to its desired state form, naming its Services by title
+func FromAlert(a *appoptics.Alert) Alert {
+	alert := Alert{
+		Name:         a.Name,
+		Description:  a.Description,
+		Active:       a.Active,
+		RearmSeconds: a.RearmSeconds,
+		Attributes:   a.Attributes,
+	}
+
+	for _, c := range a.Conditions {
+		condition := Condition{
+			Type:            c.Type,
+			MetricName:      c.MetricName,
+			Threshold:       c.Threshold,
+			SummaryFunction: c.SummaryFunction,
+			Duration:        c.Duration,
+			DetectReset:     c.DetectReset,
+		}
+		for _, t := range c.Tags {
+			condition.Tags = append(condition.Tags, Tag{Name: t.Name, Values: t.Values, Grouped: t.Grouped})
+		}
+		alert.Conditions = append(alert.Conditions, condition)
+	}
+
+	for _, s := range a.Services {
+		alert.Services = append(alert.Services, s.Title)
+	}
+
+	return alert.normalized()
+}
+
+// request converts the desired state to an appoptics.AlertRequest with the given Service IDs
+func (a Alert) request(serviceIDs []int) *appoptics.AlertRequest {
+	req := &appoptics.AlertRequest{
+		Name:         a.Name,
+		Description:  a.Description,
+		Active:       a.Active,
+		RearmSeconds: a.RearmSeconds,
+		Attributes:   a.Attributes,
+		Services:     serviceIDs,
+	}
+
+	for _, c := range a.Conditions {
+		condition := &appoptics.AlertCondition{
+			Type:            c.Type,
+			MetricName:      c.MetricName,
+			Threshold:       c.Threshold,
+			SummaryFunction: c.SummaryFunction,
+			Duration:        c.Duration,
+			DetectReset:     c.DetectReset,
+		}
+		for _, t := range c.Tags {
+			condition.Tags = append(condition.Tags, &appoptics.Tag{Name: t.Name, Values: t.Values, Grouped: t.Grouped})
+		}
+		req.Conditions = append(req.Conditions, condition)
+	}
+
+	return req
+}
+
+// normalized returns a copy with defaults made explicit and Services sorted, so that equivalent Alerts
+// compare and render identically
+func (a Alert) normalized() Alert {
+	active := a.Active == nil || *a.Active
+	a.Active = &active
+
+	if len(a.Attributes) == 0 {
+		a.Attributes = nil
+	}
+
+	services := append([]string(nil), a.Services...)
+	sort.Strings(services)
+	a.Services = services
+	if len(a.Services) == 0 {
+		a.Services = nil
+	}
+
+	conditions := make([]Condition, len(a.Conditions))
+	for i, c := range a.Conditions {
+		if len(c.Tags) == 0 {
+			c.Tags = nil
+		}
+		conditions[i] = c
+	}
+	a.Conditions = conditions
+
+	return a
+}
@@ -0,0 +1,234 @@
+package alertsync
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/gorilla/mux"
+)
+
+// pageSize is the page length the fake API uses, kept small so that listing has to page
+const pageSize = 2
+
+// fakeAPI is an in-memory implementation of the Alerts and Services endpoints
+type fakeAPI struct {
+	mu       sync.Mutex
+	nextID   int
+	alerts   []*appoptics.Alert
+	services []*appoptics.Service
+	calls    []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *appoptics.Client) {
+	api := &fakeAPI{nextID: 1}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/services", api.listServices).Methods("GET")
+	router.HandleFunc("/v1/alerts", api.listAlerts).Methods("GET")
+	router.HandleFunc("/v1/alerts", api.createAlert).Methods("POST")
+	router.HandleFunc("/v1/alerts/{id}", api.updateAlert).Methods("PUT")
+	router.HandleFunc("/v1/alerts/{id}", api.deleteAlert).Methods("DELETE")
+	router.HandleFunc("/v1/alerts/{id}/services", api.associate).Methods("POST")
+	router.HandleFunc("/v1/alerts/{id}/services/{serviceId}", api.disassociate).Methods("DELETE")
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return api, appoptics.NewClient("deadbeef", appoptics.BaseURLClientOption(server.URL+"/v1/"))
+}
+
+func (api *fakeAPI) id() int {
+	id := api.nextID
+	api.nextID++
+	return id
+}
+
+func (api *fakeAPI) addService(title string) int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	service := &appoptics.Service{ID: api.id(), Type: "mail", Title: title}
+	api.services = append(api.services, service)
+	return service.ID
+}
+
+func (api *fakeAPI) addAlert(alert *appoptics.Alert) int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	alert.ID = api.id()
+	api.alerts = append(api.alerts, alert)
+	return alert.ID
+}
+
+func (api *fakeAPI) service(id int) *appoptics.Service {
+	for _, service := range api.services {
+		if service.ID == id {
+			return service
+		}
+	}
+	return nil
+}
+
+func (api *fakeAPI) alert(r *http.Request) (int, *appoptics.Alert) {
+	for i, alert := range api.alerts {
+		if strconv.Itoa(alert.ID) == mux.Vars(r)["id"] {
+			return i, alert
+		}
+	}
+	return -1, nil
+}
+
+func (api *fakeAPI) record(r *http.Request) {
+	api.calls = append(api.calls, r.Method+" "+r.URL.Path)
+}
+
+func page(r *http.Request, total int) (int, int) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	offset = min(offset, total)
+	return offset, min(offset+pageSize, total)
+}
+
+func (api *fakeAPI) listServices(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	from, to := page(r, len(api.services))
+	json.NewEncoder(w).Encode(appoptics.ListServicesResponse{
+		Query:    appoptics.QueryInfo{Found: len(api.services), Length: to - from, Offset: from, Total: len(api.services)},
+		Services: api.services[from:to],
+	})
+}
+
+func (api *fakeAPI) listAlerts(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	from, to := page(r, len(api.alerts))
+	json.NewEncoder(w).Encode(appoptics.AlertsListResponse{
+		Query:  appoptics.QueryInfo{Found: len(api.alerts), Length: to - from, Offset: from, Total: len(api.alerts)},
+		Alerts: api.alerts[from:to],
+	})
+}
+
+// fromRequest builds the stored form of an AlertRequest, as the real API would
+func (api *fakeAPI) fromRequest(req *appoptics.AlertRequest) *appoptics.Alert {
+	alert := &appoptics.Alert{
+		ID:           req.ID,
+		Name:         req.Name,
+		Description:  req.Description,
+		Active:       req.Active,
+		RearmSeconds: req.RearmSeconds,
+		Conditions:   req.Conditions,
+		Attributes:   req.Attributes,
+	}
+	for _, id := range req.Services {
+		alert.Services = append(alert.Services, api.service(id))
+	}
+	return alert
+}
+
+func (api *fakeAPI) createAlert(w http.ResponseWriter, r *http.Request) {
+	req := &appoptics.AlertRequest{}
+	decodeBody(r, req)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.record(r)
+	alert := api.fromRequest(req)
+	alert.ID = api.id()
+	api.alerts = append(api.alerts, alert)
+	json.NewEncoder(w).Encode(alert)
+}
+
+func (api *fakeAPI) updateAlert(w http.ResponseWriter, r *http.Request) {
+	req := &appoptics.AlertRequest{}
+	decodeBody(r, req)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.record(r)
+	i, existing := api.alert(r)
+	if existing == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	alert := api.fromRequest(req)
+	alert.Services = existing.Services
+	api.alerts[i] = alert
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *fakeAPI) deleteAlert(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.record(r)
+	i, existing := api.alert(r)
+	if existing == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	api.alerts = append(api.alerts[:i:i], api.alerts[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *fakeAPI) associate(w http.ResponseWriter, r *http.Request) {
+	body := struct {
+		ID int `json:"service"`
+	}{}
+	decodeBody(r, &body)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.record(r)
+	_, alert := api.alert(r)
+	if alert == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	alert.Services = append(alert.Services, api.service(body.ID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *fakeAPI) disassociate(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.record(r)
+	_, alert := api.alert(r)
+	if alert == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var services []*appoptics.Service
+	for _, service := range alert.Services {
+		if strconv.Itoa(service.ID) != mux.Vars(r)["serviceId"] {
+			services = append(services, service)
+		}
+	}
+	alert.Services = services
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (api *fakeAPI) takeCalls() []string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	calls := api.calls
+	api.calls = nil
+	return calls
+}
+
+func decodeBody(r *http.Request, v interface{}) {
+	body, err := gzip.NewReader(r.Body)
+	if err != nil {
+		panic(fmt.Sprintf("request body is not gzipped: %s", err))
+	}
+	json.NewDecoder(body).Decode(v)
+}
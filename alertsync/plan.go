@@ -0,0 +1,275 @@
+package alertsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/appoptics/appoptics-api-go/internal/linediff"
+)
+
+// Action is the change made to an Alert
+type Action string
+
+const (
+	Create    Action = "create"
+	Update    Action = "update"
+	Delete    Action = "delete"
+	Unchanged Action = "unchanged"
+)
+
+// Change is the planned change to a single Alert
+type Change struct {
+	Action Action
+	Alert  string
+	// AlertID is the ID of the existing Alert, or zero when it is to be created
+	AlertID int
+	// Diff is a line diff of the YAML form of the Alert, with removed lines prefixed by "-" and added lines by "+"
+	Diff string
+	// Associate and Disassociate list the titles of Services to attach to or detach from an existing Alert
+	Associate    []string
+	Disassociate []string
+
+	// bodyChanged is set when the Alert itself, rather than just its Services, needs updating
+	bodyChanged bool
+	desired     Alert
+}
+
+// Plan is the ordered list of changes needed to reach the desired Alerts
+type Plan struct {
+	Changes []Change
+	// services maps Service titles to IDs
+	services map[string]int
+}
+
+// HasChanges reports whether applying the Plan modifies anything
+func (p *Plan) HasChanges() bool {
+	for _, change := range p.Changes {
+		if change.Action != Unchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the Plan for review, one line per changed Alert followed by its diff, and a summary
+func (p *Plan) String() string {
+	var b strings.Builder
+	counts := map[Action]int{}
+
+	for _, change := range p.Changes {
+		counts[change.Action]++
+		if change.Action == Unchanged {
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s alert %q\n", change.Action, change.Alert)
+		b.WriteString(linediff.Indent(change.Diff, "    "))
+		for _, title := range change.Associate {
+			fmt.Fprintf(&b, "    associate service %q\n", title)
+		}
+		for _, title := range change.Disassociate {
+			fmt.Fprintf(&b, "    disassociate service %q\n", title)
+		}
+	}
+
+	fmt.Fprintf(&b, "%d to create, %d to update, %d to delete, %d unchanged\n",
+		counts[Create], counts[Update], counts[Delete], counts[Unchanged])
+	return b.String()
+}
+
+// PlanOption configures MakePlan
+type PlanOption func(*planOptions)
+
+type planOptions struct {
+	prune       bool
+	prunePrefix string
+}
+
+// Prune deletes existing Alerts which are absent from the Document and whose names start with prefix. An empty
+// prefix makes the Document authoritative for every Alert in the organization. Without Prune, Alerts absent from
+// the Document are left alone.
+func Prune(prefix string) PlanOption {
+	return func(o *planOptions) {
+		o.prune = true
+		o.prunePrefix = prefix
+	}
+}
+
+// MakePlan compares the Document against the Alerts in the organization and returns the changes needed to make
+// them match. Nothing is modified.
+func MakePlan(c appoptics.ServiceAccessor, doc *Document, opts ...PlanOption) (*Plan, error) {
+	options := &planOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	services, err := appoptics.ListAllServices(c.ServicesService())
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{services: map[string]int{}}
+	ambiguous := map[string]bool{}
+	for _, service := range services {
+		if _, ok := plan.services[service.Title]; ok {
+			ambiguous[service.Title] = true
+		}
+		plan.services[service.Title] = service.ID
+	}
+
+	for _, alert := range doc.Alerts {
+		for _, title := range alert.Services {
+			if _, ok := plan.services[title]; !ok {
+				return nil, fmt.Errorf("alert %q refers to unknown service %q", alert.Name, title)
+			}
+			if ambiguous[title] {
+				return nil, fmt.Errorf("alert %q refers to service %q, but several services have that title", alert.Name, title)
+			}
+		}
+	}
+
+	alerts, err := appoptics.ListAllAlerts(c.AlertsService())
+	if err != nil {
+		return nil, err
+	}
+	existing := map[string]*appoptics.Alert{}
+	for _, alert := range alerts {
+		if _, ok := existing[alert.Name]; ok {
+			return nil, fmt.Errorf("several existing alerts are named %q", alert.Name)
+		}
+		existing[alert.Name] = alert
+	}
+
+	for _, desired := range doc.Alerts {
+		desired = desired.normalized()
+		current, ok := existing[desired.Name]
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{
+				Action:      Create,
+				Alert:       desired.Name,
+				Diff:        linediff.YAML(nil, desired),
+				bodyChanged: true,
+				desired:     desired,
+			})
+			continue
+		}
+
+		currentState := FromAlert(current)
+		change := Change{
+			Action:       Unchanged,
+			Alert:        desired.Name,
+			AlertID:      current.ID,
+			Associate:    difference(desired.Services, currentState.Services),
+			Disassociate: difference(currentState.Services, desired.Services),
+			bodyChanged:  !sameBody(currentState, desired),
+			desired:      desired,
+		}
+		if change.bodyChanged || len(change.Associate) > 0 || len(change.Disassociate) > 0 {
+			change.Action = Update
+			change.Diff = linediff.YAML(currentState, desired)
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	if options.prune {
+		wanted := map[string]bool{}
+		for _, alert := range doc.Alerts {
+			wanted[alert.Name] = true
+		}
+
+		var stale []*appoptics.Alert
+		for _, alert := range alerts {
+			if !wanted[alert.Name] && strings.HasPrefix(alert.Name, options.prunePrefix) {
+				stale = append(stale, alert)
+			}
+		}
+		sort.Slice(stale, func(i, j int) bool {
+			return stale[i].Name < stale[j].Name
+		})
+		for _, alert := range stale {
+			plan.Changes = append(plan.Changes, Change{
+				Action:  Delete,
+				Alert:   alert.Name,
+				AlertID: alert.ID,
+				Diff:    linediff.YAML(FromAlert(alert), nil),
+			})
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply carries out the changes in a Plan made by MakePlan. Services of existing Alerts are changed with
+// AssociateToService and DisassociateFromService. Apply stops at the first error; since the Plan is made
+// idempotently, making and applying a new Plan resumes where it stopped.
+func Apply(c appoptics.ServiceAccessor, plan *Plan) error {
+	alerts := c.AlertsService()
+
+	for _, change := range plan.Changes {
+		var err error
+		switch change.Action {
+		case Create:
+			_, err = alerts.Create(change.desired.request(plan.serviceIDs(change.desired.Services)))
+		case Update:
+			if change.bodyChanged {
+				req := change.desired.request(nil)
+				req.ID = change.AlertID
+				err = alerts.Update(req)
+			}
+			for _, title := range change.Associate {
+				if err == nil {
+					err = alerts.AssociateToService(change.AlertID, plan.services[title])
+				}
+			}
+			for _, title := range change.Disassociate {
+				if err == nil {
+					err = alerts.DisassociateFromService(change.AlertID, plan.services[title])
+				}
+			}
+		case Delete:
+			err = alerts.Delete(change.AlertID)
+		}
+		if err != nil {
+			return fmt.Errorf("%s alert %q: %s", change.Action, change.Alert, err)
+		}
+	}
+	return nil
+}
+
+func (p *Plan) serviceIDs(titles []string) []int {
+	ids := make([]int, 0, len(titles))
+	for _, title := range titles {
+		ids = append(ids, p.services[title])
+	}
+	return ids
+}
+
+// sameBody compares two normalized Alerts, disregarding their Services
+func sameBody(a, b Alert) bool {
+	a.Services, b.Services = nil, nil
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
+
+// difference returns the members of a absent from b
+func difference(a, b []string) []string {
+	in := map[string]bool{}
+	for _, s := range b {
+		in[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !in[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -3,6 +3,7 @@ package appoptics
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 )
@@ -52,6 +53,7 @@ type AnnotationsCommunicator interface {
 	Create(*AnnotationEvent, string) (*AnnotationEvent, error)
 	UpdateStream(string, string) error
 	UpdateEvent(string, int, *AnnotationLink) (*AnnotationLink, error)
+	ModifyEvent(string, int, *AnnotationEvent) error
 	Delete(string) error
 }
 
@@ -65,15 +67,11 @@ func NewAnnotationsService(c *Client) *AnnotationsService {
 
 // List retrieves paginated AnnotationEvents for all streams with name LIKE argument string
 func (as *AnnotationsService) List(streamNameSearch *string) (*ListAnnotationsResponse, error) {
-	var (
-		path        string
-		annotations *ListAnnotationsResponse
-	)
+	var annotations *ListAnnotationsResponse
 
+	path := "annotations"
 	if streamNameSearch != nil {
-		path = fmt.Sprintf("annotations?name=%s", *streamNameSearch)
-	} else {
-		path = "annotations"
+		path += "?" + url.Values{"name": {*streamNameSearch}}.Encode()
 	}
 
 	req, err := as.client.NewRequest("GET", path, nil)
@@ -93,7 +91,7 @@ func (as *AnnotationsService) List(streamNameSearch *string) (*ListAnnotationsRe
 // Retrieve fetches all AnnotationEvents matching the provided sources
 func (as *AnnotationsService) Retrieve(retReq *RetrieveAnnotationsRequest) (*AnnotationStream, error) {
 	stream := &AnnotationStream{}
-	path := fmt.Sprintf("annotations/%s", retReq.Name)
+	path := fmt.Sprintf("annotations/%s", url.PathEscape(retReq.Name))
 	req, err := as.client.NewRequest("GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -131,7 +129,7 @@ func (retReq *RetrieveAnnotationsRequest) queryString(req *http.Request) string
 // RetrieveEvent returns a single event identified by an integer ID from a given stream
 func (as *AnnotationsService) RetrieveEvent(streamName string, id int) (*AnnotationEvent, error) {
 	event := &AnnotationEvent{}
-	path := fmt.Sprintf("annotations/%s/%d", streamName, id)
+	path := fmt.Sprintf("annotations/%s/%d", url.PathEscape(streamName), id)
 	req, err := as.client.NewRequest("GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -146,7 +144,7 @@ func (as *AnnotationsService) RetrieveEvent(streamName string, id int) (*Annotat
 
 // Create makes an AnnotationEvent on the stream with the given name
 func (as *AnnotationsService) Create(event *AnnotationEvent, streamName string) (*AnnotationEvent, error) {
-	path := fmt.Sprintf("annotations/%s", streamName)
+	path := fmt.Sprintf("annotations/%s", url.PathEscape(streamName))
 	req, err := as.client.NewRequest("POST", path, event)
 	if err != nil {
 		return nil, err
@@ -165,9 +163,11 @@ func (as *AnnotationsService) Create(event *AnnotationEvent, streamName string)
 
 // UpdateStream updates the display name of the stream
 func (as *AnnotationsService) UpdateStream(streamName, displayName string) error {
-	path := fmt.Sprintf("annotations/%s", streamName)
-	jsonTemplate := `{"display_name": %s}`
-	req, err := as.client.NewRequest("POST", path, fmt.Sprintf(jsonTemplate, displayName))
+	path := fmt.Sprintf("annotations/%s", url.PathEscape(streamName))
+	body := struct {
+		DisplayName string `json:"display_name"`
+	}{displayName}
+	req, err := as.client.NewRequest("POST", path, body)
 	if err != nil {
 		return err
 	}
@@ -183,7 +183,7 @@ func (as *AnnotationsService) UpdateStream(streamName, displayName string) error
 // UpdateEvent adds a link to an annotation Event
 func (as *AnnotationsService) UpdateEvent(streamName string, id int, link *AnnotationLink) (*AnnotationLink, error) {
 	newLink := &AnnotationLink{}
-	path := fmt.Sprintf("annotations/%s/%d/links", streamName, id)
+	path := fmt.Sprintf("annotations/%s/%d/links", url.PathEscape(streamName), id)
 	req, err := as.client.NewRequest("POST", path, link)
 	if err != nil {
 		return nil, err
@@ -198,9 +198,31 @@ func (as *AnnotationsService) UpdateEvent(streamName string, id int, link *Annot
 	return newLink, nil
 }
 
+// ModifyEvent updates the title, source, description, links, start time or end time of an annotation Event.
+// Fields left at their zero values are not changed.
+func (as *AnnotationsService) ModifyEvent(streamName string, id int, event *AnnotationEvent) error {
+	path := fmt.Sprintf("annotations/%s/%d", url.PathEscape(streamName), id)
+	body := struct {
+		Title       string           `json:"title,omitempty"`
+		Source      string           `json:"source,omitempty"`
+		Description string           `json:"description,omitempty"`
+		Links       []AnnotationLink `json:"links,omitempty"`
+		StartTime   int64            `json:"start_time,omitempty"`
+		EndTime     int64            `json:"end_time,omitempty"`
+	}{event.Title, event.Source, event.Description, event.Links, event.StartTime, event.EndTime}
+
+	req, err := as.client.NewRequest("PUT", path, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = as.client.Do(req, nil)
+	return err
+}
+
 // Delete deletes the annotation stream matching the provided name
 func (as *AnnotationsService) Delete(streamName string) error {
-	path := fmt.Sprintf("annotations/%s", streamName)
+	path := fmt.Sprintf("annotations/%s", url.PathEscape(streamName))
 	req, err := as.client.NewRequest("DELETE", path, nil)
 	if err != nil {
 		return err
@@ -0,0 +1,118 @@
+package appoptics
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeployOutcome is the result of a deploy, recorded in the title of its annotation Event when the DeployMarker
+// is finished
+type DeployOutcome string
+
+const (
+	DeploySucceeded DeployOutcome = "succeeded"
+	DeployFailed    DeployOutcome = "failed"
+	DeployCancelled DeployOutcome = "cancelled"
+)
+
+// ErrDeployMarkerFinished is returned when finishing a DeployMarker a second time
+var ErrDeployMarkerFinished = errors.New("deploy marker is already finished")
+
+// Deploy describes a deploy to be marked on an annotation stream
+type Deploy struct {
+	// Stream is the name of the annotation stream, e.g. "api-deploys". It is created if it does not exist.
+	Stream      string
+	Title       string
+	Source      string
+	Description string
+	// Links point at related resources, such as those made by CommitLink and BuildLink
+	Links []AnnotationLink
+	// StartTime defaults to now
+	StartTime time.Time
+}
+
+// DeployMarker is the open annotation Event of a deploy in progress
+type DeployMarker struct {
+	ac       AnnotationsCommunicator
+	stream   string
+	title    string
+	event    AnnotationEvent
+	finished bool
+}
+
+// CommitLink returns a link to the commit being deployed
+func CommitLink(href string) AnnotationLink {
+	return AnnotationLink{Rel: "commit", Href: href, Label: "Commit"}
+}
+
+// BuildLink returns a link to the CI build or pipeline performing the deploy
+func BuildLink(href string) AnnotationLink {
+	return AnnotationLink{Rel: "build", Href: href, Label: "Build"}
+}
+
+// StartDeploy creates an annotation Event for a deploy without an end time. Call Finish on the returned
+// DeployMarker once the deploy is over.
+func StartDeploy(ac AnnotationsCommunicator, d *Deploy) (*DeployMarker, error) {
+	if d.Stream == "" || d.Title == "" {
+		return nil, errors.New("deploy needs a Stream and a Title")
+	}
+
+	start := d.StartTime
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	created, err := ac.Create(&AnnotationEvent{
+		Title:       d.Title,
+		Source:      d.Source,
+		Description: d.Description,
+		Links:       d.Links,
+		StartTime:   start.Unix(),
+	}, d.Stream)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeployMarker{ac: ac, stream: d.Stream, title: d.Title, event: *created}, nil
+}
+
+// Stream returns the name of the annotation stream holding the Event
+func (m *DeployMarker) Stream() string {
+	return m.stream
+}
+
+// Event returns the annotation Event as last known to the DeployMarker
+func (m *DeployMarker) Event() AnnotationEvent {
+	return m.event
+}
+
+// Finish closes the Event at the current time, appending the outcome to its title
+func (m *DeployMarker) Finish(outcome DeployOutcome) error {
+	return m.FinishAt(time.Now(), outcome)
+}
+
+// FinishAt closes the Event at the given time, appending the outcome, if any, to its title
+func (m *DeployMarker) FinishAt(end time.Time, outcome DeployOutcome) error {
+	if m.finished {
+		return ErrDeployMarkerFinished
+	}
+	if end.Unix() < m.event.StartTime {
+		return fmt.Errorf("deploy cannot finish at %s, before it started", end.Format(time.RFC3339))
+	}
+
+	update := &AnnotationEvent{EndTime: end.Unix()}
+	if outcome != "" {
+		update.Title = fmt.Sprintf("%s (%s)", m.title, outcome)
+	}
+	if err := m.ac.ModifyEvent(m.stream, m.event.ID, update); err != nil {
+		return err
+	}
+
+	m.event.EndTime = update.EndTime
+	if update.Title != "" {
+		m.event.Title = update.Title
+	}
+	m.finished = true
+	return nil
+}
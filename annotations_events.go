@@ -0,0 +1,289 @@
+package appoptics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAnnotationChunkSize is the length of the time windows requested by an AnnotationEventIterator
+	DefaultAnnotationChunkSize = 24 * time.Hour
+	// DefaultAnnotationEventsPerRequest is the number of events at which a response is assumed to be truncated
+	DefaultAnnotationEventsPerRequest = 100
+)
+
+// ErrAnnotationEventsTruncated is returned by an AnnotationEventIterator when a window of one second still yields
+// MaxEventsPerRequest events, so that it cannot be split further and some of its events may be missing. Check
+// for it with errors.Is.
+var ErrAnnotationEventsTruncated = errors.New("annotation events truncated")
+
+// AnnotationEventsRequest selects the events of an annotation stream over a time range
+type AnnotationEventsRequest struct {
+	// Name is the annotation stream
+	Name string
+	// StartTime is the beginning of the range, and is required
+	StartTime time.Time
+	// EndTime is the end of the range, defaulting to now
+	EndTime time.Time
+	// Sources restricts the events to those from the given sources
+	Sources []string
+	// ChunkSize is the length of the windows requested one at a time, defaulting to DefaultAnnotationChunkSize
+	ChunkSize time.Duration
+	// MaxEventsPerRequest is the number of events the API returns at most for one request, defaulting to
+	// DefaultAnnotationEventsPerRequest. A window yielding that many events is split in half and requested again,
+	// down to windows of one second.
+	MaxEventsPerRequest int
+}
+
+// AnnotationEventIterator walks the events of an annotation stream over a time range, in order of start time,
+// requesting the range a window at a time. Each event appears once, with its Source set from the source it was
+// listed under.
+//
+//	it := appoptics.NewAnnotationEventIterator(client.AnnotationsService(), req)
+//	for it.Next() {
+//		event := it.Event()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type AnnotationEventIterator struct {
+	ac      AnnotationsCommunicator
+	req     AnnotationEventsRequest
+	next    time.Time
+	buffer  []AnnotationEvent
+	current AnnotationEvent
+	seen    map[int]bool
+	err     error
+}
+
+// NewAnnotationEventIterator returns an iterator over the events selected by req
+func NewAnnotationEventIterator(ac AnnotationsCommunicator, req *AnnotationEventsRequest) *AnnotationEventIterator {
+	it := &AnnotationEventIterator{ac: ac, req: *req, seen: map[int]bool{}}
+
+	if it.req.EndTime.IsZero() {
+		it.req.EndTime = time.Now()
+	}
+	if it.req.ChunkSize <= 0 {
+		it.req.ChunkSize = DefaultAnnotationChunkSize
+	}
+	if it.req.MaxEventsPerRequest <= 0 {
+		it.req.MaxEventsPerRequest = DefaultAnnotationEventsPerRequest
+	}
+	switch {
+	case it.req.Name == "":
+		it.err = errors.New("an annotation stream name is required")
+	case it.req.StartTime.IsZero():
+		it.err = errors.New("a start time is required")
+	}
+	it.next = it.req.StartTime
+
+	return it
+}
+
+// Next advances to the next event, returning false when there are no more events or an error occurred
+func (it *AnnotationEventIterator) Next() bool {
+	for len(it.buffer) == 0 {
+		if it.err != nil || !it.next.Before(it.req.EndTime) {
+			return false
+		}
+
+		end := it.next.Add(it.req.ChunkSize)
+		if end.After(it.req.EndTime) {
+			end = it.req.EndTime
+		}
+		events, err := it.window(it.next, end)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.next = end
+
+		for _, event := range events {
+			if !it.seen[event.ID] {
+				it.seen[event.ID] = true
+				it.buffer = append(it.buffer, event)
+			}
+		}
+		sort.SliceStable(it.buffer, func(i, j int) bool {
+			if it.buffer[i].StartTime != it.buffer[j].StartTime {
+				return it.buffer[i].StartTime < it.buffer[j].StartTime
+			}
+			return it.buffer[i].ID < it.buffer[j].ID
+		})
+	}
+
+	it.current, it.buffer = it.buffer[0], it.buffer[1:]
+	return true
+}
+
+// Event returns the current event
+func (it *AnnotationEventIterator) Event() AnnotationEvent {
+	return it.current
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *AnnotationEventIterator) Err() error {
+	return it.err
+}
+
+// window retrieves the events between start and end, splitting the window while responses look truncated
+func (it *AnnotationEventIterator) window(start, end time.Time) ([]AnnotationEvent, error) {
+	stream, err := it.ac.Retrieve(&RetrieveAnnotationsRequest{
+		Name:      it.req.Name,
+		StartTime: start,
+		EndTime:   end,
+		Sources:   it.req.Sources,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := stream.Flatten()
+	if len(events) < it.req.MaxEventsPerRequest {
+		return events, nil
+	}
+	if end.Sub(start) <= time.Second {
+		return nil, fmt.Errorf("%w: %d events starting at %s", ErrAnnotationEventsTruncated, len(events),
+			start.UTC().Format(time.RFC3339))
+	}
+
+	mid := start.Add(end.Sub(start) / 2).Truncate(time.Second)
+	if !mid.After(start) {
+		mid = start.Add(time.Second)
+	}
+	first, err := it.window(start, mid)
+	if err != nil {
+		return nil, err
+	}
+	second, err := it.window(mid, end)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// Flatten returns the events of the stream in a single list, with each Source set from the source the event
+// is listed under
+func (s *AnnotationStream) Flatten() []AnnotationEvent {
+	var events []AnnotationEvent
+	for _, bySource := range s.Events {
+		sources := make([]string, 0, len(bySource))
+		for source := range bySource {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+
+		for _, source := range sources {
+			for _, event := range bySource[source] {
+				if event.Source == "" {
+					event.Source = source
+				}
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// AnnotationExportFormat is the file format written by ExportAnnotationEvents
+type AnnotationExportFormat string
+
+const (
+	// AnnotationExportCSV writes a header row then one row per event, with times in RFC 3339 and links as
+	// space separated rel=href pairs
+	AnnotationExportCSV AnnotationExportFormat = "csv"
+	// AnnotationExportJSON writes a JSON array of events
+	AnnotationExportJSON AnnotationExportFormat = "json"
+)
+
+var annotationCSVHeader = []string{"id", "source", "title", "description", "start_time", "end_time", "links"}
+
+// ExportAnnotationEvents writes the remaining events of the iterator to w, returning the number written. When the
+// iterator fails, the events written so far are left as a complete CSV file or JSON array, and its error is
+// returned.
+func ExportAnnotationEvents(w io.Writer, it *AnnotationEventIterator, format AnnotationExportFormat) (int, error) {
+	switch format {
+	case AnnotationExportCSV:
+		return exportAnnotationEventsCSV(w, it)
+	case AnnotationExportJSON:
+		return exportAnnotationEventsJSON(w, it)
+	}
+	return 0, fmt.Errorf("unknown annotation export format %q", format)
+}
+
+func exportAnnotationEventsCSV(w io.Writer, it *AnnotationEventIterator) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(annotationCSVHeader); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for it.Next() {
+		event := it.Event()
+		links := make([]string, len(event.Links))
+		for i, link := range event.Links {
+			links[i] = link.Rel + "=" + link.Href
+		}
+		record := []string{
+			strconv.Itoa(event.ID),
+			event.Source,
+			event.Title,
+			event.Description,
+			formatAnnotationTime(event.StartTime),
+			formatAnnotationTime(event.EndTime),
+			strings.Join(links, " "),
+		}
+		if err := cw.Write(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return n, err
+	}
+	return n, it.Err()
+}
+
+func exportAnnotationEventsJSON(w io.Writer, it *AnnotationEventIterator) (int, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for it.Next() {
+		data, err := json.Marshal(it.Event())
+		if err != nil {
+			return n, err
+		}
+		separator := "\n  "
+		if n > 0 {
+			separator = ",\n  "
+		}
+		if _, err := io.WriteString(w, separator+string(data)); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	if _, err := io.WriteString(w, "\n]\n"); err != nil {
+		return n, err
+	}
+	return n, it.Err()
+}
+
+func formatAnnotationTime(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
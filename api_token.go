@@ -1,6 +1,9 @@
 package appoptics
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+)
 
 type ApiToken struct {
 	ID     *int    `json:"id,omitempty"`
@@ -52,7 +55,7 @@ func (ts *ApiTokensService) List() (*ApiTokensResponse, error) {
 // Retrieve returns the ApiToken identified by the parameter
 func (ts *ApiTokensService) Retrieve(name string) (*ApiTokensResponse, error) {
 	tokenResponse := &ApiTokensResponse{}
-	path := fmt.Sprintf("api_tokens/%s", name)
+	path := fmt.Sprintf("api_tokens/%s", url.PathEscape(name))
 	req, err := ts.client.NewRequest("GET", path, nil)
 
 	if err != nil {
@@ -86,7 +89,11 @@ func (ts *ApiTokensService) Create(at *ApiToken) (*ApiToken, error) {
 
 // Update updates the ApiToken
 func (ts *ApiTokensService) Update(at *ApiToken) (*ApiToken, error) {
-	path := fmt.Sprintf("api_tokens/%d", at.ID)
+	var id int
+	if at.ID != nil {
+		id = *at.ID
+	}
+	path := fmt.Sprintf("api_tokens/%d", id)
 	req, err := ts.client.NewRequest("PUT", path, at)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,102 @@
+package appoptics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertBuilder(t *testing.T) {
+	req, err := appoptics.NewAlertBuilder("api.latency.high").
+		Description("API latency is high").
+		RearmAfter(10*time.Minute).
+		RunbookURL("https://example.com/runbooks/latency").
+		Condition(
+			appoptics.Above("api.latency", 500).Summary(appoptics.SummaryMax).For(5*time.Minute).
+				WithTag("env", "prod").GroupedBy("host"),
+			appoptics.Absent("api.requests", 10*time.Minute),
+		).
+		NotifyServices(145, 156).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "api.latency.high", req.Name)
+	assert.Equal(t, 600, req.RearmSeconds)
+	assert.Nil(t, req.Active, "alerts are active unless Inactive is used")
+	assert.Equal(t, "https://example.com/runbooks/latency", req.Attributes["runbook_url"])
+	assert.Equal(t, []int{145, 156}, req.Services)
+
+	require.Len(t, req.Conditions, 2)
+	assert.Equal(t, &appoptics.AlertCondition{
+		Type:            "above",
+		MetricName:      "api.latency",
+		Threshold:       500,
+		SummaryFunction: "max",
+		Duration:        300,
+		Tags: []*appoptics.Tag{
+			{Name: "env", Values: []string{"prod"}},
+			{Name: "host", Values: []string{"*"}, Grouped: true},
+		},
+	}, req.Conditions[0])
+	assert.Equal(t, &appoptics.AlertCondition{Type: "absent", MetricName: "api.requests", Duration: 600}, req.Conditions[1])
+
+	inactive, err := appoptics.NewAlertBuilder("x").Inactive().Condition(appoptics.Below("free", 1)).Build()
+	require.NoError(t, err)
+	assert.False(t, *inactive.Active)
+}
+
+func TestAlertBuilderValidation(t *testing.T) {
+	for desc, builder := range map[string]*appoptics.AlertBuilder{
+		"no name":             appoptics.NewAlertBuilder("").Condition(appoptics.Above("m", 1)),
+		"no conditions":       appoptics.NewAlertBuilder("a"),
+		"negative rearm":      appoptics.NewAlertBuilder("a").RearmAfter(-time.Minute).Condition(appoptics.Above("m", 1)),
+		"no metric":           appoptics.NewAlertBuilder("a").Condition(appoptics.Above("", 1)),
+		"duration too short":  appoptics.NewAlertBuilder("a").Condition(appoptics.Above("m", 1).For(30 * time.Second)),
+		"duration too long":   appoptics.NewAlertBuilder("a").Condition(appoptics.Above("m", 1).For(2 * time.Hour)),
+		"absent no duration":  appoptics.NewAlertBuilder("a").Condition(appoptics.Absent("m", 0)),
+		"absent with summary": appoptics.NewAlertBuilder("a").Condition(appoptics.Absent("m", time.Hour).Summary(appoptics.SummaryMax)),
+		"unknown summary":     appoptics.NewAlertBuilder("a").Condition(appoptics.Above("m", 1).Summary("median")),
+		"reset not derivative": appoptics.NewAlertBuilder("a").
+			Condition(appoptics.Above("m", 1).Summary(appoptics.SummaryMax).DetectReset()),
+		"tag without values": appoptics.NewAlertBuilder("a").Condition(appoptics.Above("m", 1).WithTag("env")),
+	} {
+		_, err := builder.Build()
+		assert.Error(t, err, desc)
+	}
+
+	_, err := appoptics.NewAlertBuilder("a").
+		Condition(appoptics.Above("m", 1).Summary(appoptics.SummaryDerivative).DetectReset()).
+		Build()
+	assert.NoError(t, err)
+
+	absentWithThreshold := &appoptics.AlertCondition{Type: "absent", MetricName: "m", Duration: 600, Threshold: 5}
+	assert.Error(t, absentWithThreshold.Validate())
+
+	unknownType := &appoptics.AlertCondition{Type: "equals", MetricName: "m"}
+	assert.Error(t, unknownType.Validate())
+}
+
+func TestAlertRequestConversion(t *testing.T) {
+	services := []*appoptics.Service{{ID: 145, Title: "Notify Ops Room"}, {ID: 156, Title: "Email ops team"}}
+	alert := &appoptics.Alert{
+		ID:         7,
+		Name:       "api.latency.high",
+		Conditions: []*appoptics.AlertCondition{{Type: "above", MetricName: "api.latency", Threshold: 500}},
+		Services:   services,
+	}
+
+	req := alert.ToRequest()
+	assert.Equal(t, 7, req.ID)
+	assert.Equal(t, []int{145, 156}, req.Services)
+	assert.Equal(t, alert.Conditions, req.Conditions)
+
+	back, err := req.ToAlert(services)
+	require.NoError(t, err)
+	assert.Equal(t, alert, back)
+
+	_, err = req.ToAlert(services[:1])
+	assert.Error(t, err)
+}
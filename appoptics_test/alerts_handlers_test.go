@@ -1,6 +1,50 @@
 package appoptics_test
 
-import "net/http"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/gorilla/mux"
+)
+
+// alertPoll is the state of the Alerts for one poll: "ok", "triggered" or "disabled" by ID, or an HTTP status
+// code to fail the poll's listing with
+type alertPoll struct {
+	states map[int]string
+	fail   int
+}
+
+// scriptedAlerts holds the polls served by ListScriptedAlertsHandler and StatusScriptedAlertHandler
+var scriptedAlerts struct {
+	sync.Mutex
+	polls []alertPoll
+	poll  int
+}
+
+// serveAlertPolls serves the scripted polls instead of the fixed Alerts for the rest of the test, one Alert per
+// page, moving to the next poll each time the first page is listed. The last poll is repeated once the script is
+// exhausted.
+func serveAlertPolls(t *testing.T, polls []alertPoll) {
+	setAlertPolls := func(polls []alertPoll) {
+		scriptedAlerts.Lock()
+		defer scriptedAlerts.Unlock()
+		scriptedAlerts.polls, scriptedAlerts.poll = polls, -1
+	}
+	setAlertPolls(polls)
+	t.Cleanup(func() { setAlertPolls(nil) })
+}
+
+// alertPollsScripted matches requests made while serveAlertPolls is in effect
+func alertPollsScripted(*http.Request, *mux.RouteMatch) bool {
+	scriptedAlerts.Lock()
+	defer scriptedAlerts.Unlock()
+	return scriptedAlerts.polls != nil
+}
 
 func ListAlertsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -178,3 +222,43 @@ func AssociateAlertHandler() http.HandlerFunc {
 		w.WriteHeader(http.StatusCreated)
 	}
 }
+
+func ListScriptedAlertsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scriptedAlerts.Lock()
+		defer scriptedAlerts.Unlock()
+
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset == 0 && scriptedAlerts.poll < len(scriptedAlerts.polls)-1 {
+			scriptedAlerts.poll++
+		}
+		poll := scriptedAlerts.polls[scriptedAlerts.poll]
+		if poll.fail != 0 {
+			if poll.fail == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(poll.fail)
+			w.Write([]byte(`{"errors":{"request":["failed"]}}`))
+			return
+		}
+
+		resp := appoptics.AlertsListResponse{Query: appoptics.QueryInfo{Found: len(poll.states), Length: 1, Offset: offset}}
+		if offset < len(poll.states) {
+			id := offset + 1
+			active := poll.states[id] != "disabled"
+			resp.Alerts = []*appoptics.Alert{{ID: id, Name: fmt.Sprintf("alert.%d", id), Active: &active}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func StatusScriptedAlertHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scriptedAlerts.Lock()
+		defer scriptedAlerts.Unlock()
+
+		id, _ := strconv.Atoi(mux.Vars(r)["alertId"])
+		status := scriptedAlerts.polls[scriptedAlerts.poll].states[id]
+		json.NewEncoder(w).Encode(appoptics.AlertStatus{Alert: appoptics.Alert{ID: id}, Status: status})
+	}
+}
@@ -1,6 +1,7 @@
 package appoptics_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/appoptics/appoptics-api-go"
@@ -9,7 +10,7 @@ import (
 )
 
 func TestAlertsService_List(t *testing.T) {
-	alertResponse, err := client.AlertsService().List()
+	alertResponse, err := client.AlertsService().List(nil)
 	require.Nil(t, err)
 
 	query := alertResponse.Query
@@ -112,3 +113,39 @@ func TestAlertsService_Disassociate(t *testing.T) {
 
 	assert.Equal(t, nil, err)
 }
+
+func TestListAllAlerts(t *testing.T) {
+	alerts, err := appoptics.ListAllAlerts(client.AlertsService())
+	require.Nil(t, err)
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 1400310, alerts[0].ID)
+}
+
+// pagedAlerts is an AlertsCommunicator listing its Alerts one per page
+type pagedAlerts struct {
+	appoptics.AlertsCommunicator
+	alerts []*appoptics.Alert
+}
+
+func (p *pagedAlerts) List(rp *appoptics.PaginationParameters) (*appoptics.AlertsListResponse, error) {
+	return p.ListContext(context.Background(), rp)
+}
+
+func (p *pagedAlerts) ListContext(ctx context.Context, rp *appoptics.PaginationParameters) (*appoptics.AlertsListResponse, error) {
+	resp := &appoptics.AlertsListResponse{Query: appoptics.QueryInfo{Found: len(p.alerts), Length: 1}}
+	if rp != nil {
+		resp.Query.Offset = rp.Offset
+	}
+	if resp.Query.Offset < len(p.alerts) {
+		resp.Alerts = p.alerts[resp.Query.Offset : resp.Query.Offset+1]
+	}
+	return resp, nil
+}
+
+func TestListAllAlerts_Communicator(t *testing.T) {
+	ac := &pagedAlerts{alerts: []*appoptics.Alert{{ID: 1}, {ID: 2}, {ID: 3}}}
+	alerts, err := appoptics.ListAllAlerts(ac)
+	require.Nil(t, err)
+	assert.Equal(t, ac.alerts, alerts)
+}
@@ -0,0 +1,139 @@
+package appoptics_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func describeTransition(e appoptics.AlertTransitionEvent) string {
+	return fmt.Sprintf("%s %s (%s -> %s)", e.Alert.Name, e.Transition, e.From, e.To)
+}
+
+func TestWatchAlerts(t *testing.T) {
+	serveAlertPolls(t, []alertPoll{
+		{states: map[int]string{1: "ok", 2: "triggered", 3: "ok"}},
+		{fail: http.StatusTooManyRequests},
+		{fail: http.StatusInternalServerError},
+		{states: map[int]string{1: "triggered", 2: "ok", 3: "disabled"}},
+		{states: map[int]string{1: "triggered", 2: "ok", 3: "ok"}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var transitions []string
+	var errs []error
+	err := appoptics.WatchAlerts(ctx, client.AlertsService(), func(e appoptics.AlertTransitionEvent) {
+		transitions = append(transitions, describeTransition(e))
+		if len(transitions) == 4 {
+			cancel()
+		}
+	},
+		appoptics.WatchInterval(time.Millisecond),
+		appoptics.WatchMaxBackoff(2*time.Millisecond),
+		appoptics.WatchErrors(func(err error) {
+			errs = append(errs, err)
+		}),
+	)
+	assert.Equal(t, context.Canceled, err)
+
+	assert.Equal(t, []string{
+		"alert.1 triggered (ok -> triggered)",
+		"alert.2 cleared (triggered -> ok)",
+		"alert.3 disabled (ok -> disabled)",
+		"alert.3 enabled (disabled -> ok)",
+	}, transitions)
+
+	// the rate limited poll is retried without being reported
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "500")
+}
+
+func TestWatchAlertsChanAlertIDs(t *testing.T) {
+	serveAlertPolls(t, []alertPoll{
+		{states: map[int]string{1: "ok", 2: "ok"}},
+		{states: map[int]string{1: "triggered", 2: "triggered"}},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errs := appoptics.WatchAlertsChan(ctx, client.AlertsService(), appoptics.WatchInterval(time.Millisecond), appoptics.WatchAlertIDs(2))
+
+	select {
+	case e := <-events:
+		assert.Equal(t, "alert.2 triggered (ok -> triggered)", describeTransition(e))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no transition was reported")
+	}
+
+	cancel()
+	for e := range events {
+		t.Errorf("unexpected transition %s", describeTransition(e))
+	}
+	assert.Equal(t, context.Canceled, <-errs)
+}
+
+func TestWatchAlertsInvalidInterval(t *testing.T) {
+	for _, opt := range []appoptics.WatchAlertsOption{appoptics.WatchInterval(0), appoptics.WatchMaxBackoff(-time.Second)} {
+		err := appoptics.WatchAlerts(context.Background(), client.AlertsService(), func(appoptics.AlertTransitionEvent) {
+			t.Error("no transition can be reported")
+		}, opt)
+		assert.Error(t, err)
+
+		events, errs := appoptics.WatchAlertsChan(context.Background(), client.AlertsService(), opt)
+		for range events {
+			t.Error("no transition can be reported")
+		}
+		assert.Error(t, <-errs)
+	}
+}
+
+// watchedAlerts is an AlertsCommunicator whose Alerts are listed one per page, and whose third Alert is
+// triggered from the second poll on
+type watchedAlerts struct {
+	pagedAlerts
+	polls int
+}
+
+func (w *watchedAlerts) ListContext(ctx context.Context, rp *appoptics.PaginationParameters) (*appoptics.AlertsListResponse, error) {
+	if rp == nil || rp.Offset == 0 {
+		w.polls++
+	}
+	return w.pagedAlerts.ListContext(ctx, rp)
+}
+
+func (w *watchedAlerts) StatusContext(ctx context.Context, id int) (*appoptics.AlertStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	status := &appoptics.AlertStatus{Alert: appoptics.Alert{ID: id}, Status: "ok"}
+	if id == 3 && w.polls > 1 {
+		status.Status = "triggered"
+	}
+	return status, nil
+}
+
+func TestWatchAlerts_Communicator(t *testing.T) {
+	ac := &watchedAlerts{pagedAlerts: pagedAlerts{alerts: []*appoptics.Alert{
+		{ID: 1, Name: "alert.1"}, {ID: 2, Name: "alert.2"}, {ID: 3, Name: "alert.3"},
+	}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var transitions []string
+	err := appoptics.WatchAlerts(ctx, ac, func(e appoptics.AlertTransitionEvent) {
+		transitions = append(transitions, describeTransition(e))
+		cancel()
+	}, appoptics.WatchInterval(time.Millisecond))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, []string{"alert.3 triggered (ok -> triggered)"}, transitions)
+}
@@ -0,0 +1,91 @@
+package appoptics_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployMarker(t *testing.T) {
+	// the start time of the Event returned by CreateAnnotationHandler
+	start := time.Unix(1234567890, 0)
+
+	marker, err := appoptics.StartDeploy(client.AnnotationsService(), &appoptics.Deploy{
+		Stream:      "api-deploys",
+		Title:       "Deploy v1.2.3",
+		Source:      "ci",
+		Description: "Rolling deploy of the API",
+		Links: []appoptics.AnnotationLink{
+			appoptics.CommitLink("https://github.com/acme/api/commit/abc123"),
+			appoptics.BuildLink("https://ci.example.com/builds/42"),
+		},
+		StartTime: start,
+	})
+	require.NoError(t, err)
+
+	recorded := lastRecordedRequest()
+	assert.Equal(t, "POST", recorded.method)
+	assert.Equal(t, "/v1/annotations/api-deploys", recorded.path)
+	var created map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(recorded.body), &created))
+	assert.Equal(t, "Deploy v1.2.3", created["title"])
+	assert.EqualValues(t, start.Unix(), created["start_time"])
+	assert.Len(t, created["links"], 2)
+	assert.NotContains(t, created, "end_time")
+	assert.Equal(t, 123, marker.Event().ID)
+	assert.Equal(t, "api-deploys", marker.Stream())
+
+	assert.Error(t, marker.FinishAt(start.Add(-time.Minute), appoptics.DeploySucceeded))
+
+	require.NoError(t, marker.FinishAt(start.Add(5*time.Minute), appoptics.DeployFailed))
+	recorded = lastRecordedRequest()
+	assert.Equal(t, "PUT", recorded.method)
+	assert.Equal(t, "/v1/annotations/api-deploys/123", recorded.path)
+	var modified map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(recorded.body), &modified))
+	assert.Equal(t, map[string]interface{}{
+		"title":    "Deploy v1.2.3 (failed)",
+		"end_time": float64(start.Add(5 * time.Minute).Unix()),
+	}, modified)
+	assert.Equal(t, "Deploy v1.2.3 (failed)", marker.Event().Title)
+
+	assert.Equal(t, appoptics.ErrDeployMarkerFinished, marker.Finish(appoptics.DeploySucceeded))
+
+	_, err = appoptics.StartDeploy(client.AnnotationsService(), &appoptics.Deploy{Title: "no stream"})
+	assert.Error(t, err)
+}
+
+// stubAnnotations is an AnnotationsCommunicator keeping the Events it creates and modifies
+type stubAnnotations struct {
+	appoptics.AnnotationsCommunicator
+	events map[int]*appoptics.AnnotationEvent
+}
+
+func (s *stubAnnotations) Create(e *appoptics.AnnotationEvent, stream string) (*appoptics.AnnotationEvent, error) {
+	created := *e
+	created.ID = len(s.events) + 1
+	s.events[created.ID] = &created
+	return &created, nil
+}
+
+func (s *stubAnnotations) ModifyEvent(stream string, id int, e *appoptics.AnnotationEvent) error {
+	s.events[id].Title = e.Title
+	s.events[id].EndTime = e.EndTime
+	return nil
+}
+
+func TestDeployMarker_Communicator(t *testing.T) {
+	ac := &stubAnnotations{events: map[int]*appoptics.AnnotationEvent{}}
+	start := time.Unix(1700000000, 0)
+
+	marker, err := appoptics.StartDeploy(ac, &appoptics.Deploy{Stream: "api-deploys", Title: "Deploy", StartTime: start})
+	require.NoError(t, err)
+	require.NoError(t, marker.FinishAt(start.Add(time.Minute), appoptics.DeploySucceeded))
+
+	assert.Equal(t, "Deploy (succeeded)", ac.events[1].Title)
+	assert.Equal(t, start.Add(time.Minute).Unix(), ac.events[1].EndTime)
+}
@@ -0,0 +1,143 @@
+package appoptics_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deployEvents(start time.Time, n int, every time.Duration) []appoptics.AnnotationEvent {
+	events := make([]appoptics.AnnotationEvent, n)
+	for i := range events {
+		events[i] = appoptics.AnnotationEvent{
+			ID:        i + 1,
+			Title:     "deploy " + strconv.Itoa(i+1),
+			StartTime: start.Add(time.Duration(i) * every).Unix(),
+		}
+		if i%2 == 0 {
+			events[i].Source = "ci"
+		}
+	}
+	return events
+}
+
+func TestAnnotationEventIterator(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// 250 events over three days, on the hour and so on window boundaries
+	events := deployEvents(start, 250, 15*time.Minute)
+	requests := serveDeployAnnotations(t, events, 50)
+
+	it := appoptics.NewAnnotationEventIterator(client.AnnotationsService(), &appoptics.AnnotationEventsRequest{
+		Name:      "deploys",
+		StartTime: start,
+		EndTime:   start.Add(72 * time.Hour),
+		// MaxEventsPerRequest matches the server's limit, so the 96 events of a day are fetched in smaller windows
+		MaxEventsPerRequest: 50,
+	})
+
+	var ids []int
+	for it.Next() {
+		event := it.Event()
+		ids = append(ids, event.ID)
+		if event.ID%2 == 1 {
+			assert.Equal(t, "ci", event.Source)
+		} else {
+			assert.Equal(t, "unassigned", event.Source)
+		}
+	}
+	require.NoError(t, it.Err())
+
+	require.Len(t, ids, 250, "every event once")
+	assert.True(t, sort.IntsAreSorted(ids), "events in order of start time")
+	assert.Greater(t, requests(), 3, "truncated windows are split")
+}
+
+func TestAnnotationEventIteratorErrors(t *testing.T) {
+	requests := serveDeployAnnotations(t, nil, 100)
+
+	it := appoptics.NewAnnotationEventIterator(client.AnnotationsService(), &appoptics.AnnotationEventsRequest{Name: "deploys"})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+	assert.Zero(t, requests())
+
+	it = appoptics.NewAnnotationEventIterator(client.AnnotationsService(), &appoptics.AnnotationEventsRequest{
+		Name:      "missing",
+		StartTime: time.Now().Add(-time.Hour),
+	})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
+
+func TestExportAnnotationEvents(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	events := deployEvents(start, 3, time.Hour)
+	events[0].EndTime = start.Add(10 * time.Minute).Unix()
+	events[0].Links = []appoptics.AnnotationLink{appoptics.CommitLink("https://example.com/c/1")}
+	serveDeployAnnotations(t, events, 100)
+
+	req := &appoptics.AnnotationEventsRequest{Name: "deploys", StartTime: start, EndTime: start.Add(24 * time.Hour)}
+
+	var buf bytes.Buffer
+	n, err := appoptics.ExportAnnotationEvents(&buf, appoptics.NewAnnotationEventIterator(client.AnnotationsService(), req), appoptics.AnnotationExportCSV)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, []string{"id", "source", "title", "description", "start_time", "end_time", "links"}, records[0])
+	assert.Equal(t, []string{
+		"1", "ci", "deploy 1", "", "2024-03-01T00:00:00Z", "2024-03-01T00:10:00Z", "commit=https://example.com/c/1",
+	}, records[1])
+	assert.Equal(t, "unassigned", records[2][1])
+
+	buf.Reset()
+	n, err = appoptics.ExportAnnotationEvents(&buf, appoptics.NewAnnotationEventIterator(client.AnnotationsService(), req), appoptics.AnnotationExportJSON)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	var exported []appoptics.AnnotationEvent
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+	require.Len(t, exported, 3)
+	assert.Equal(t, "deploy 3", exported[2].Title)
+
+	_, err = appoptics.ExportAnnotationEvents(&buf, appoptics.NewAnnotationEventIterator(client.AnnotationsService(), req), "xml")
+	assert.Error(t, err)
+}
+
+func TestAnnotationEventIteratorTruncated(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	// a burst of events within one second, more than the server returns at once
+	events := append(deployEvents(start, 2, time.Hour), deployEvents(start.Add(30*time.Minute), 5, 0)...)
+	for i := range events {
+		events[i].ID = i + 1
+	}
+	serveDeployAnnotations(t, events, 3)
+
+	req := &appoptics.AnnotationEventsRequest{
+		Name:                "deploys",
+		StartTime:           start,
+		EndTime:             start.Add(2 * time.Hour),
+		MaxEventsPerRequest: 3,
+	}
+	it := appoptics.NewAnnotationEventIterator(client.AnnotationsService(), req)
+	for it.Next() {
+	}
+	assert.True(t, errors.Is(it.Err(), appoptics.ErrAnnotationEventsTruncated), "got %v", it.Err())
+
+	var buf bytes.Buffer
+	_, err := appoptics.ExportAnnotationEvents(&buf, appoptics.NewAnnotationEventIterator(client.AnnotationsService(), req), appoptics.AnnotationExportJSON)
+	assert.True(t, errors.Is(err, appoptics.ErrAnnotationEventsTruncated), "got %v", err)
+
+	var exported []appoptics.AnnotationEvent
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &exported), "the JSON array is closed after an error")
+}
@@ -1,6 +1,40 @@
 package appoptics_test
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+// deployAnnotations holds the events of the "deploys" stream served by RetrieveDeployAnnotationsHandler
+var deployAnnotations struct {
+	sync.Mutex
+	events   []appoptics.AnnotationEvent
+	limit    int
+	requests int
+}
+
+// serveDeployAnnotations sets the events of the "deploys" stream for the rest of the test, of which at most
+// limit are returned per request like the API does. The returned function counts the requests made.
+func serveDeployAnnotations(t *testing.T, events []appoptics.AnnotationEvent, limit int) func() int {
+	setDeployAnnotations := func(events []appoptics.AnnotationEvent, limit int) {
+		deployAnnotations.Lock()
+		defer deployAnnotations.Unlock()
+		deployAnnotations.events, deployAnnotations.limit, deployAnnotations.requests = events, limit, 0
+	}
+	setDeployAnnotations(events, limit)
+	t.Cleanup(func() { setDeployAnnotations(nil, 0) })
+
+	return func() int {
+		deployAnnotations.Lock()
+		defer deployAnnotations.Unlock()
+		return deployAnnotations.requests
+	}
+}
 
 func ListAnnotationsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -141,6 +175,13 @@ func UpdateAnnotationEventHandler() http.HandlerFunc {
 		w.Write([]byte(responseBody))
 	}
 }
+
+func ModifyAnnotationEventHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
 func UpdateAnnotationStreamHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
@@ -152,3 +193,33 @@ func DeleteAnnotationHandler() http.HandlerFunc {
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+func RetrieveDeployAnnotationsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deployAnnotations.Lock()
+		defer deployAnnotations.Unlock()
+		deployAnnotations.requests++
+
+		start, _ := strconv.ParseInt(r.URL.Query().Get("start_time"), 10, 64)
+		end, _ := strconv.ParseInt(r.URL.Query().Get("end_time"), 10, 64)
+
+		bySource := map[string][]appoptics.AnnotationEvent{}
+		n := 0
+		for _, event := range deployAnnotations.events {
+			if event.StartTime < start || event.StartTime > end || n == deployAnnotations.limit {
+				continue
+			}
+			source := event.Source
+			if source == "" {
+				source = "unassigned"
+			}
+			event.Source = ""
+			bySource[source] = append(bySource[source], event)
+			n++
+		}
+		json.NewEncoder(w).Encode(appoptics.AnnotationStream{
+			Name:   "deploys",
+			Events: []map[string][]appoptics.AnnotationEvent{bySource},
+		})
+	}
+}
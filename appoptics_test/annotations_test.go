@@ -95,3 +95,8 @@ func TestAnnotationsService_UpdateEvent(t *testing.T) {
 	assert.Equal(t, "github", annResponse.Rel)
 	assert.Equal(t, "https://github.com/acme/app/commits/01beaf", annResponse.Href)
 }
+
+func TestAnnotationsService_ModifyEvent(t *testing.T) {
+	err := client.AnnotationsService().ModifyEvent("foobar", 123, &appoptics.AnnotationEvent{EndTime: 1234567990})
+	require.Nil(t, err)
+}
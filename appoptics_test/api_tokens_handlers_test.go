@@ -2,6 +2,8 @@ package appoptics_test
 
 import (
 	"net/http"
+
+	"github.com/gorilla/mux"
 )
 
 func ListApiTokensHandler() http.HandlerFunc {
@@ -97,9 +99,11 @@ func CreateApiTokenHandler() http.HandlerFunc {
 	}
 }
 
+// UpdateApiTokenHandler echoes the ID in the request path
 func UpdateApiTokenHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		responseBody := `{
+  "id": ` + mux.Vars(r)["tokenId"] + `,
   "name": "New Token Name",
   "token": "24f9fb2134399595b91da1dcac39cb6eafc68a07fa08ad3d70892b7aad10e1cf",
   "active": false,
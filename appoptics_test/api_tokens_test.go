@@ -5,6 +5,7 @@ import (
 
 	"github.com/appoptics/appoptics-api-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestApiTokensService_List(t *testing.T) {
@@ -65,3 +66,10 @@ func TestApiTokensService_Update(t *testing.T) {
 	assert.Equal(t, false, *apiToken.Active)
 	assert.Equal(t, "admin", *apiToken.Role)
 }
+
+func TestApiTokensService_UpdateWithID(t *testing.T) {
+	id := 28
+	apiToken, err := client.ApiTokensService().Update(&appoptics.ApiToken{ID: &id})
+	require.Nil(t, err)
+	assert.Equal(t, 28, *apiToken.ID)
+}
@@ -0,0 +1,124 @@
+package appoptics_test
+
+import (
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamesAreEscaped(t *testing.T) {
+	const name = "web/app deploys?v=1#frag%"
+	const escaped = "web%2Fapp%20deploys%3Fv=1%23frag%25"
+
+	for _, tc := range []struct {
+		desc   string
+		call   func() error
+		method string
+		path   string
+		query  string
+	}{
+		{
+			desc: "metrics retrieve",
+			call: func() error {
+				_, err := client.MetricsService().Retrieve(name)
+				return err
+			},
+			method: "GET", path: "/v1/metrics/" + escaped,
+		},
+		{
+			desc: "metrics create",
+			call: func() error {
+				_, err := client.MetricsService().Create(&appoptics.Metric{Name: name})
+				return err
+			},
+			method: "PUT", path: "/v1/metrics/" + escaped,
+		},
+		{
+			desc:   "metrics update",
+			call:   func() error { return client.MetricsService().Update(name, &appoptics.Metric{Name: "renamed"}) },
+			method: "PUT", path: "/v1/metrics/" + escaped,
+		},
+		{
+			desc:   "metrics delete",
+			call:   func() error { return client.MetricsService().Delete(name) },
+			method: "DELETE", path: "/v1/metrics/" + escaped,
+		},
+		{
+			desc: "annotations list",
+			call: func() error {
+				search := "a&b=c d"
+				_, err := client.AnnotationsService().List(&search)
+				return err
+			},
+			method: "GET", path: "/v1/annotations", query: "name=a%26b%3Dc+d",
+		},
+		{
+			desc: "annotations retrieve",
+			call: func() error {
+				_, err := client.AnnotationsService().Retrieve(&appoptics.RetrieveAnnotationsRequest{Name: name})
+				return err
+			},
+			method: "GET", path: "/v1/annotations/" + escaped,
+		},
+		{
+			desc: "annotations retrieve event",
+			call: func() error {
+				_, err := client.AnnotationsService().RetrieveEvent(name, 7)
+				return err
+			},
+			method: "GET", path: "/v1/annotations/" + escaped + "/7",
+		},
+		{
+			desc: "annotations create",
+			call: func() error {
+				_, err := client.AnnotationsService().Create(&appoptics.AnnotationEvent{Title: "t"}, name)
+				return err
+			},
+			method: "POST", path: "/v1/annotations/" + escaped,
+		},
+		{
+			desc: "annotations add link",
+			call: func() error {
+				_, err := client.AnnotationsService().UpdateEvent(name, 7, &appoptics.AnnotationLink{})
+				return err
+			},
+			method: "POST", path: "/v1/annotations/" + escaped + "/7/links",
+		},
+		{
+			desc: "annotations modify event",
+			call: func() error {
+				return client.AnnotationsService().ModifyEvent(name, 7, &appoptics.AnnotationEvent{EndTime: 1})
+			},
+			method: "PUT", path: "/v1/annotations/" + escaped + "/7",
+		},
+		{
+			desc:   "annotations delete",
+			call:   func() error { return client.AnnotationsService().Delete(name) },
+			method: "DELETE", path: "/v1/annotations/" + escaped,
+		},
+		{
+			desc: "api tokens retrieve",
+			call: func() error {
+				_, err := client.ApiTokensService().Retrieve(name)
+				return err
+			},
+			method: "GET", path: "/v1/api_tokens/" + escaped,
+		},
+	} {
+		require.NoError(t, tc.call(), tc.desc)
+		recorded := lastRecordedRequest()
+		assert.Equal(t, tc.method, recorded.method, tc.desc)
+		assert.Equal(t, tc.path, recorded.path, tc.desc)
+		assert.Equal(t, tc.query, recorded.query, tc.desc)
+	}
+}
+
+func TestAnnotationsService_UpdateStreamBody(t *testing.T) {
+	require.NoError(t, client.AnnotationsService().UpdateStream("api deploys", `API "Deploys"`))
+	recorded := lastRecordedRequest()
+	assert.Equal(t, "POST", recorded.method)
+	assert.Equal(t, "/v1/annotations/api%20deploys", recorded.path)
+	assert.Equal(t, `{"display_name":"API \"Deploys\""}`, recorded.body)
+}
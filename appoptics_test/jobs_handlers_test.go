@@ -1,9 +1,42 @@
 package appoptics_test
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"sync"
 
+	"github.com/gorilla/mux"
+)
+
+// RetrieveJobsHandler serves a failed Job as 123456, a Job stuck in the queue as 7, and a Job which progresses
+// to completion over successive requests under any other ID
 func RetrieveJobsHandler() http.HandlerFunc {
+	var (
+		mu       sync.Mutex
+		progress = map[string]int{}
+	)
+
 	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := mux.Vars(r)["jobID"]
+		switch jobID {
+		case "123456":
+		case "7":
+			w.Write([]byte(`{"id": 7, "state": "queued"}`))
+			return
+		default:
+			mu.Lock()
+			progress[jobID] += 50
+			current := progress[jobID]
+			mu.Unlock()
+
+			state := "working"
+			if current >= 100 {
+				state = "complete"
+			}
+			fmt.Fprintf(w, `{"id": %s, "state": "%s", "progress": %d}`, jobID, state, current)
+			return
+		}
+
 		responseBody := `{
   "id": 123456,
   "state": "failed",
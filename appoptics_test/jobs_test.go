@@ -1,7 +1,11 @@
 package appoptics_test
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -16,3 +20,72 @@ func TestRetrieveJobs(t *testing.T) {
 	assert.Equal(t, "failed", jobsResponse.State)
 	assert.Equal(t, "is invalid", jobsResponse.Errors["name"][0])
 }
+
+func TestWaitForJob(t *testing.T) {
+	var progress []float64
+	job, err := appoptics.WaitForJob(context.Background(), client.JobsService(), 42,
+		appoptics.JobPollInterval(time.Millisecond, 2*time.Millisecond),
+		appoptics.JobProgressCallback(func(p float64) {
+			progress = append(progress, p)
+		}),
+	)
+	require.Nil(t, err)
+
+	assert.Equal(t, appoptics.JobStateComplete, job.State)
+	assert.True(t, job.Finished())
+	assert.Equal(t, []float64{50, 100}, progress)
+}
+
+func TestWaitForJob_Failed(t *testing.T) {
+	job, err := appoptics.WaitForJob(context.Background(), client.JobsService(), 123456)
+	require.Error(t, err)
+
+	assert.Equal(t, appoptics.JobStateFailed, job.State)
+	jobErr, ok := err.(*appoptics.JobError)
+	require.True(t, ok)
+	assert.Equal(t, 123456, jobErr.ID)
+	assert.Equal(t, "job 123456 failed: name is invalid", jobErr.Error())
+}
+
+func TestWaitForJob_Canceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	job, err := appoptics.WaitForJob(ctx, client.JobsService(), 7, appoptics.JobPollInterval(time.Millisecond, 5*time.Millisecond))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	require.NotNil(t, job)
+	assert.Equal(t, "queued", job.State)
+}
+
+// scriptedJobs is a JobsCommunicator returning its Jobs in turn
+type scriptedJobs []*appoptics.Job
+
+func (s *scriptedJobs) Retrieve(id int) (*appoptics.Job, error) {
+	job := (*s)[0]
+	if len(*s) > 1 {
+		*s = (*s)[1:]
+	}
+	return job, nil
+}
+
+func (s *scriptedJobs) RetrieveContext(ctx context.Context, id int) (*appoptics.Job, error) {
+	return s.Retrieve(id)
+}
+
+func TestWaitForJob_Communicator(t *testing.T) {
+	jobs := &scriptedJobs{{ID: 3, State: "queued"}, {ID: 3, State: "working"}, {ID: 3, State: appoptics.JobStateComplete}}
+	job, err := appoptics.WaitForJob(context.Background(), jobs, 3, appoptics.JobPollInterval(time.Millisecond, time.Millisecond))
+	require.Nil(t, err)
+	assert.Equal(t, appoptics.JobStateComplete, job.State)
+}
+
+func TestWaitForJob_InvalidInterval(t *testing.T) {
+	jobs := &scriptedJobs{{ID: 3, State: "queued"}}
+	for _, opt := range []appoptics.WaitForJobOption{
+		appoptics.JobPollInterval(0, 0),
+		appoptics.JobPollInterval(time.Millisecond, -time.Second),
+	} {
+		_, err := appoptics.WaitForJob(context.Background(), jobs, 3, opt)
+		assert.Error(t, err)
+	}
+}
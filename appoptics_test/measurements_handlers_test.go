@@ -0,0 +1,97 @@
+package appoptics_test
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func RetrieveMeasurementsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if mux.Vars(r)["name"] != "cpu.percent.user" || query.Get("resolution") != "60" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":{"params":{"resolution":["is required"]}}}`))
+			return
+		}
+
+		var responseBody string
+		switch query.Get("start_time") {
+		case "1700000000":
+			responseBody = `{
+  "name": "cpu.percent.user",
+  "resolution": 60,
+  "attributes": {
+    "display_units_short": "%"
+  },
+  "series": [
+    {
+      "tags": {"host": "web01"},
+      "measurements": [
+        {"time": 1700000000, "value": 12.5},
+        {"time": 1700000060, "value": 13}
+      ]
+    },
+    {
+      "tags": {"host": "web02"},
+      "measurements": [
+        {"time": 1700000000, "value": 40}
+      ]
+    }
+  ],
+  "query": {"next_time": 1700000120}
+}`
+		case "1700000120":
+			responseBody = `{
+  "name": "cpu.percent.user",
+  "resolution": 60,
+  "series": [
+    {
+      "tags": {"host": "web01"},
+      "measurements": [
+        {"time": 1700000120, "value": 14}
+      ]
+    },
+    {
+      "tags": {"host": "web03"},
+      "measurements": [
+        {"time": 1700000120, "value": 7.25}
+      ]
+    }
+  ]
+}`
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(responseBody))
+	}
+}
+
+func RetrieveCompositeMeasurementsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("compose") != `sum(s("api.requests", {"env": "prod"}))` || query.Get("duration") != "3600" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		responseBody := `{
+  "compose": "sum(s(\"api.requests\", {\"env\": \"prod\"}))",
+  "resolution": 60,
+  "series": [
+    {
+      "tags": {},
+      "metric": {
+        "name": "api.requests",
+        "type": "gauge"
+      },
+      "measurements": [
+        {"time": 1700000000, "value": 1250}
+      ]
+    }
+  ]
+}`
+		w.Write([]byte(responseBody))
+	}
+}
@@ -0,0 +1,79 @@
+package appoptics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeasurementsService_Retrieve(t *testing.T) {
+	measurementsResponse, err := client.MeasurementsService().Retrieve(&appoptics.RetrieveMeasurementsRequest{
+		Name:       "cpu.percent.user",
+		StartTime:  time.Unix(1700000000, 0),
+		Resolution: 60,
+		Tags:       map[string]string{"env": "prod"},
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, "cpu.percent.user", measurementsResponse.Name)
+	assert.Equal(t, 60, measurementsResponse.Resolution)
+	assert.Equal(t, "%", measurementsResponse.Attributes.DisplayUnitsShort)
+	assert.Equal(t, int64(1700000120), measurementsResponse.Query.NextTime)
+
+	require.Len(t, measurementsResponse.Series, 2)
+	firstSeries := measurementsResponse.Series[0]
+	assert.Equal(t, "web01", firstSeries.Tags["host"])
+	assert.Equal(t, 12.5, firstSeries.Measurements[0].Value)
+	assert.Equal(t, time.Unix(1700000060, 0), firstSeries.Measurements[1].Timestamp())
+}
+
+func TestMeasurementsService_RetrieveComposite(t *testing.T) {
+	measurementsResponse, err := client.MeasurementsService().Retrieve(&appoptics.RetrieveMeasurementsRequest{
+		Composite:  `sum(s("api.requests", {"env": "prod"}))`,
+		Duration:   time.Hour,
+		Resolution: 60,
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, `sum(s("api.requests", {"env": "prod"}))`, measurementsResponse.Compose)
+	require.Len(t, measurementsResponse.Series, 1)
+	assert.Equal(t, "api.requests", measurementsResponse.Series[0].Metric.Name)
+	assert.Equal(t, float64(1250), measurementsResponse.Series[0].Measurements[0].Value)
+}
+
+func TestMeasurementsService_RetrieveErrors(t *testing.T) {
+	_, err := client.MeasurementsService().Retrieve(&appoptics.RetrieveMeasurementsRequest{})
+	assert.Error(t, err)
+
+	_, err = client.MeasurementsService().Retrieve(&appoptics.RetrieveMeasurementsRequest{
+		Name:      "cpu.percent.user",
+		StartTime: time.Unix(1700000000, 0),
+	})
+	require.Error(t, err)
+	_, ok := err.(*appoptics.ErrorResponse)
+	assert.True(t, ok)
+}
+
+func TestRetrieveAll(t *testing.T) {
+	measurementsResponse, err := appoptics.RetrieveAll(client.MeasurementsService(), &appoptics.RetrieveMeasurementsRequest{
+		Name:       "cpu.percent.user",
+		StartTime:  time.Unix(1700000000, 0),
+		Resolution: 60,
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, int64(0), measurementsResponse.Query.NextTime)
+	require.Len(t, measurementsResponse.Series, 3)
+
+	web01 := measurementsResponse.Series[0]
+	assert.Equal(t, "web01", web01.Tags["host"])
+	assert.Equal(t, []appoptics.MeasurementPoint{
+		{Time: 1700000000, Value: 12.5},
+		{Time: 1700000060, Value: 13},
+		{Time: 1700000120, Value: 14},
+	}, web01.Measurements)
+	assert.Equal(t, "web03", measurementsResponse.Series[2].Tags["host"])
+}
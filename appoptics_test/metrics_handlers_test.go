@@ -4,6 +4,20 @@ import "net/http"
 
 func ListMetricsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("name") == "cpu" && query.Get("tags[host]") == "web01" {
+			offset := query.Get("offset")
+			if offset == "" {
+				offset = "0"
+			}
+			name := map[string]string{"0": "cpu_temp", "1": "cpu_load"}[offset]
+			w.Write([]byte(`{
+  "query": {"found": 2, "length": 1, "offset": ` + offset + `, "total": 40},
+  "metrics": [{"name": "` + name + `", "type": "gauge"}]
+}`))
+			return
+		}
+
 		responseBody := `{
   "query": {
     "found": 2,
@@ -67,6 +81,7 @@ func CreateMetricHandler() http.HandlerFunc {
 
 func UpdateMetricHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/v1/jobs/123456")
 		w.WriteHeader(http.StatusAccepted)
 	}
 }
@@ -97,3 +112,27 @@ func DeleteMetricHandler() http.HandlerFunc {
 		w.WriteHeader(http.StatusAccepted)
 	}
 }
+
+func ListTagsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("metric") != "cpu_temp" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		responseBody := `{
+  "query": {"found": 2, "length": 2, "offset": 0, "total": 2},
+  "tags": [
+    {"name": "host", "values": ["web01", "web02", "web03"]},
+    {"name": "region", "values": ["us-east-1"]}
+  ]
+}`
+		w.Write([]byte(responseBody))
+	}
+}
+
+func RetrieveTagHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseBody := `{"name": "host", "values": ["web01", "web02", "web03"]}`
+		w.Write([]byte(responseBody))
+	}
+}
@@ -1,7 +1,9 @@
 package appoptics_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/appoptics/appoptics-api-go"
 	"github.com/stretchr/testify/assert"
@@ -9,7 +11,7 @@ import (
 )
 
 func TestMetricsService_List(t *testing.T) {
-	apiMetricsResponse, err := client.MetricsService().List()
+	apiMetricsResponse, err := client.MetricsService().List(nil)
 
 	require.Nil(t, err)
 
@@ -58,3 +60,73 @@ func TestMetricsService_Retrieve(t *testing.T) {
 	assert.Equal(t, "cpu_temp", apiMetricsResponse.DisplayName)
 	assert.Equal(t, "gauge", apiMetricsResponse.Type)
 }
+
+func TestMetricsService_UpdateAll(t *testing.T) {
+	job, err := client.MetricsService().UpdateAll(&appoptics.MetricUpdatePayload{
+		Pattern:    "cpu.*",
+		Attributes: appoptics.MetricAttributes{DisplayUnitsShort: "%"},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 123456, job.ID)
+
+	_, err = client.MetricsService().UpdateAll(&appoptics.MetricUpdatePayload{})
+	assert.Error(t, err)
+}
+
+func TestUpdateMetricsAndWait(t *testing.T) {
+	job, err := appoptics.UpdateMetricsAndWait(context.Background(), client, &appoptics.MetricUpdatePayload{
+		Names:      []string{"cpu.temp"},
+		Attributes: appoptics.MetricAttributes{Color: "#ff0000"},
+	}, appoptics.JobPollInterval(time.Millisecond, time.Millisecond))
+	require.Error(t, err)
+
+	assert.Equal(t, "failed", job.State)
+	updateErr, ok := err.(*appoptics.MetricUpdateError)
+	require.True(t, ok)
+	assert.Equal(t, 123456, updateErr.JobID)
+	assert.Equal(t, []appoptics.MetricUpdateFailure{{Name: "name", Errors: []string{"is invalid"}}}, updateErr.Failures)
+}
+
+func TestMetricsService_ListFiltered(t *testing.T) {
+	apiMetricsResponse, err := client.MetricsService().List(&appoptics.ListMetricsRequest{
+		Name:       "cpu",
+		Tags:       map[string]string{"host": "web01"},
+		Pagination: &appoptics.PaginationParameters{Offset: 1, Length: 1},
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, 1, apiMetricsResponse.Query.Offset)
+	require.Len(t, apiMetricsResponse.Metrics, 1)
+	assert.Equal(t, "cpu_load", apiMetricsResponse.Metrics[0].Name)
+}
+
+func TestListAllMetrics(t *testing.T) {
+	metrics, err := appoptics.ListAllMetrics(client.MetricsService(), &appoptics.ListMetricsRequest{
+		Name: "cpu",
+		Tags: map[string]string{"host": "web01"},
+	})
+	require.Nil(t, err)
+
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "cpu_temp", metrics[0].Name)
+	assert.Equal(t, "cpu_load", metrics[1].Name)
+}
+
+func TestMetricsService_ListTags(t *testing.T) {
+	tagsResponse, err := client.MetricsService().ListTags(&appoptics.ListTagsRequest{Metric: "cpu_temp"})
+	require.Nil(t, err)
+
+	assert.Equal(t, 2, tagsResponse.Query.Found)
+	require.Len(t, tagsResponse.Tags, 2)
+	assert.Equal(t, "host", tagsResponse.Tags[0].Name)
+	assert.Len(t, tagsResponse.Tags[0].Values, 3)
+	assert.Equal(t, []string{"us-east-1"}, tagsResponse.Tags[1].Values)
+}
+
+func TestMetricsService_RetrieveTag(t *testing.T) {
+	tag, err := client.MetricsService().RetrieveTag("host", nil)
+	require.Nil(t, err)
+
+	assert.Equal(t, "host", tag.Name)
+	assert.Equal(t, []string{"web01", "web02", "web03"}, tag.Values)
+}
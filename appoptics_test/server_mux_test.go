@@ -1,9 +1,15 @@
 package appoptics_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/appoptics/appoptics-api-go"
@@ -13,11 +19,52 @@ import (
 var (
 	client *appoptics.Client
 	server *httptest.Server
+
+	lastRequestMu sync.Mutex
+	lastRequest   recordedRequest
 )
 
+// recordedRequest is what the test server saw of a request
+type recordedRequest struct {
+	method string
+	// path is the escaped path, as sent on the wire
+	path  string
+	query string
+	// body is the uncompressed request body
+	body string
+}
+
+// recordRequests records each request as the last one received before passing it on to next
+func recordRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded := recordedRequest{method: r.Method, path: r.URL.EscapedPath(), query: r.URL.RawQuery}
+		if r.Header.Get("Content-Encoding") == "gzip" && r.ContentLength != 0 {
+			data, _ := io.ReadAll(r.Body)
+			r.Body = io.NopCloser(bytes.NewReader(data))
+			if body, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+				uncompressed, _ := io.ReadAll(body)
+				recorded.body = strings.TrimSpace(string(uncompressed))
+			}
+		}
+
+		lastRequestMu.Lock()
+		lastRequest = recorded
+		lastRequestMu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// lastRecordedRequest returns the last request received by the test server
+func lastRecordedRequest() recordedRequest {
+	lastRequestMu.Lock()
+	defer lastRequestMu.Unlock()
+	return lastRequest
+}
+
 func setup() {
 	router := NewServerTestMux()
-	server = httptest.NewServer(router)
+	server = httptest.NewServer(recordRequests(router))
 	serverURLWithVersion := fmt.Sprintf("%s/v1/", server.URL)
 	client = appoptics.NewClient("deadbeef", appoptics.BaseURLClientOption(serverURLWithVersion))
 }
@@ -35,15 +82,23 @@ func TestMain(m *testing.M) {
 
 func NewServerTestMux() *mux.Router {
 	router := mux.NewRouter()
+	// names are matched as sent, so that escaped slashes in them do not split the path
+	router.UseEncodedPath()
 
 	// Measurements
+	router.Handle("/v1/measurements", RetrieveCompositeMeasurementsHandler()).Methods("GET")
+	router.Handle("/v1/measurements/{name}", RetrieveMeasurementsHandler()).Methods("GET")
 
 	// Metrics
 	router.Handle("/v1/metrics", ListMetricsHandler()).Methods("GET")
 	router.Handle("/v1/metrics/{name}", CreateMetricHandler()).Methods("PUT")
 	router.Handle("/v1/metrics/{name}", RetrieveMetricHandler()).Methods("GET")
 	router.Handle("/v1/metrics", UpdateMetricHandler()).Methods("PUT")
-	router.Handle("/v1/metrics/", DeleteMetricHandler()).Methods("DELETE")
+	router.Handle("/v1/metrics/{name}", DeleteMetricHandler()).Methods("DELETE")
+
+	// Tags
+	router.Handle("/v1/tags", ListTagsHandler()).Methods("GET")
+	router.Handle("/v1/tags/{name}", RetrieveTagHandler()).Methods("GET")
 
 	// Spaces
 	router.Handle("/v1/spaces", ListSpacesHandler()).Methods("GET")
@@ -70,12 +125,18 @@ func NewServerTestMux() *mux.Router {
 	router.Handle("/v1/annotations", ListAnnotationsHandler()).Methods("GET")
 	router.Handle("/v1/annotations/{streamName}", CreateAnnotationHandler()).Methods("POST")
 	router.Handle("/v1/annotations/{streamName}", UpdateAnnotationStreamHandler()).Methods("PUT")
+	// the streams read by the annotation event iterator tests
+	router.Handle("/v1/annotations/deploys", RetrieveDeployAnnotationsHandler()).Methods("GET")
+	router.Handle("/v1/annotations/missing", http.NotFoundHandler()).Methods("GET")
 	router.Handle("/v1/annotations/{streamName}", RetrieveAnnotationsHandler()).Methods("GET")
 	router.Handle("/v1/annotations/{streamName}/{eventID}", RetrieveAnnotationEventHandler()).Methods("GET")
+	router.Handle("/v1/annotations/{streamName}/{eventID}", ModifyAnnotationEventHandler()).Methods("PUT")
 	router.Handle("/v1/annotations/{streamName}/{eventID}/links", UpdateAnnotationEventHandler()).Methods("POST")
 	router.Handle("/v1/annotations/{streamName}", DeleteAnnotationHandler()).Methods("DELETE")
 
 	// Alerts
+	router.Handle("/v1/alerts", ListScriptedAlertsHandler()).Methods("GET").MatcherFunc(alertPollsScripted)
+	router.Handle("/v1/alerts/{alertId}/status", StatusScriptedAlertHandler()).Methods("GET").MatcherFunc(alertPollsScripted)
 	router.Handle("/v1/alerts", ListAlertsHandler()).Methods("GET")
 	router.Handle("/v1/alerts", CreateAlertHandler()).Methods("POST")
 	router.Handle("/v1/alerts/{alertId}", RetrieveAlertHandler()).Methods("GET")
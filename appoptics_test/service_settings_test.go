@@ -0,0 +1,85 @@
+package appoptics_test
+
+import (
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceSettingsRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		settings appoptics.ServiceSettings
+		asMap    map[string]string
+	}{
+		{
+			&appoptics.EmailSettings{Addresses: []string{"george@example.com", "fred@example.com"}},
+			map[string]string{"addresses": "george@example.com,fred@example.com"},
+		},
+		{
+			&appoptics.SlackSettings{URL: "https://hooks.slack.com/services/T0/B0/x"},
+			map[string]string{"url": "https://hooks.slack.com/services/T0/B0/x"},
+		},
+		{
+			&appoptics.PagerDutySettings{ServiceKey: "abc", EventType: "trigger", Description: "API alerts"},
+			map[string]string{"service_key": "abc", "event_type": "trigger", "description": "API alerts"},
+		},
+		{
+			&appoptics.WebhookSettings{URL: "http://example.com/hook"},
+			map[string]string{"url": "http://example.com/hook"},
+		},
+		{
+			&appoptics.OpsGenieSettings{APIKey: "key", Recipients: []string{"ops"}, Tags: []string{"api", "prod"}},
+			map[string]string{"api_key": "key", "recipients": "ops", "tags": "api,prod"},
+		},
+		{
+			&appoptics.VictorOpsSettings{APIKey: "key", RoutingKey: "ops"},
+			map[string]string{"api_key": "key", "routing_key": "ops"},
+		},
+	} {
+		service, err := appoptics.NewService("Ops", tc.settings)
+		require.NoError(t, err, tc.settings.ServiceType())
+		assert.Equal(t, tc.settings.ServiceType(), service.Type)
+		assert.Equal(t, tc.asMap, service.Settings)
+
+		typed, err := service.TypedSettings()
+		require.NoError(t, err)
+		assert.Equal(t, tc.settings, typed)
+	}
+}
+
+func TestServiceSettingsValidate(t *testing.T) {
+	for _, settings := range []appoptics.ServiceSettings{
+		&appoptics.EmailSettings{},
+		&appoptics.EmailSettings{Addresses: []string{"not an address"}},
+		&appoptics.SlackSettings{},
+		&appoptics.SlackSettings{URL: "ftp://example.com"},
+		&appoptics.PagerDutySettings{},
+		&appoptics.WebhookSettings{URL: "/relative"},
+		&appoptics.OpsGenieSettings{Recipients: []string{"ops"}},
+		&appoptics.VictorOpsSettings{APIKey: "key"},
+	} {
+		assert.Error(t, settings.Validate(), "%#v", settings)
+		_, err := appoptics.NewService("Ops", settings)
+		assert.Error(t, err)
+	}
+
+	_, err := appoptics.NewService("", &appoptics.VictorOpsSettings{APIKey: "key", RoutingKey: "ops"})
+	assert.Error(t, err, "a title is required")
+}
+
+func TestServiceTypedSettings(t *testing.T) {
+	service := &appoptics.Service{Type: "mail", Settings: map[string]string{"addresses": " a@example.com, ,b@example.com"}}
+	typed, err := service.TypedSettings()
+	require.NoError(t, err)
+	assert.Equal(t, &appoptics.EmailSettings{Addresses: []string{"a@example.com", "b@example.com"}}, typed)
+
+	pagerDuty := &appoptics.Service{Type: "pagerduty", Settings: map[string]string{"service_key": "abc"}}
+	typed, err = pagerDuty.TypedSettings()
+	require.NoError(t, err)
+	assert.Equal(t, "trigger", typed.ToMap()["event_type"], "event type defaults to trigger")
+
+	_, err = (&appoptics.Service{Type: "campfire"}).TypedSettings()
+	assert.Error(t, err)
+}
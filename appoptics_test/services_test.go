@@ -8,7 +8,7 @@ import (
 )
 
 func TestServicesService_List(t *testing.T) {
-	serviceResponse, err := client.ServicesService().List()
+	serviceResponse, err := client.ServicesService().List(nil)
 
 	if err != nil {
 		t.Errorf("error running List: %v", err)
@@ -58,3 +58,42 @@ func TestServicesService_Create(t *testing.T) {
 	assert.Equal(t, "campfire", service.Type)
 	assert.Equal(t, "Notify Ops Room", service.Title)
 }
+
+func TestListAllServices(t *testing.T) {
+	services, err := appoptics.ListAllServices(client.ServicesService())
+	if err != nil {
+		t.Errorf("error running ListAllServices: %v", err)
+	}
+
+	assert.Len(t, services, 2)
+	assert.Equal(t, 145, services[0].ID)
+}
+
+func TestCreateServiceWithSettings(t *testing.T) {
+	_, err := appoptics.CreateServiceWithSettings(client.ServicesService(), "Ops hook", &appoptics.WebhookSettings{URL: "not a url"})
+	assert.Error(t, err)
+
+	service, err := appoptics.CreateServiceWithSettings(client.ServicesService(), "Ops hook", &appoptics.WebhookSettings{URL: "https://example.com/hook"})
+	if err != nil {
+		t.Errorf("error running CreateServiceWithSettings: %v", err)
+	}
+	assert.Equal(t, 145, service.ID)
+}
+
+func TestFindServiceByTitle(t *testing.T) {
+	service, err := appoptics.FindServiceByTitle(client.ServicesService(), appoptics.ServiceTypeEmail, "Email ops team")
+	if err != nil {
+		t.Errorf("error running FindServiceByTitle: %v", err)
+	}
+	assert.Equal(t, 156, service.ID)
+
+	_, err = appoptics.FindServiceByTitle(client.ServicesService(), appoptics.ServiceTypeSlack, "Email ops team")
+	assert.Equal(t, appoptics.ErrServiceNotFound, err)
+
+	services, err := appoptics.FindServicesByType(client.ServicesService(), appoptics.ServiceTypeSlack)
+	if err != nil {
+		t.Errorf("error running FindServicesByType: %v", err)
+	}
+	assert.Len(t, services, 1)
+	assert.Equal(t, "Notify Ops Room", services[0].Title)
+}
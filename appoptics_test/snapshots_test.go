@@ -1,7 +1,13 @@
 package appoptics_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/appoptics/appoptics-api-go"
 	"github.com/stretchr/testify/assert"
@@ -31,3 +37,41 @@ func TestRetrieveSnapshot(t *testing.T) {
 	assert.False(t, sResponse.CreatedAt.IsZero())
 	assert.False(t, sResponse.UpdatedAt.IsZero())
 }
+
+func TestCreateAndDownloadSnapshot(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nimage")
+
+	var imageServer *httptest.Server
+	imageServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/snapshots":
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprintf(w, `{"href": "%[1]s/v1/snapshots/9", "job_href": "%[1]s/v1/jobs/42", "subject": {}}`, imageServer.URL)
+		case "/v1/jobs/42":
+			w.Write([]byte(`{"id": 42, "state": "complete", "progress": 100}`))
+		case "/v1/snapshots/9":
+			fmt.Fprintf(w, `{"href": "%[1]s/v1/snapshots/9", "image_href": "%[1]s/chart.png", "subject": {}}`, imageServer.URL)
+		case "/chart.png":
+			if _, _, ok := r.BasicAuth(); ok {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(png)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer imageServer.Close()
+
+	snapshotClient := appoptics.NewClient("deadbeef", appoptics.BaseURLClientOption(imageServer.URL+"/v1/"))
+
+	var buf bytes.Buffer
+	snapshot, err := appoptics.CreateAndDownloadSnapshot(context.Background(), snapshotClient,
+		appoptics.NewChartSnapshot(1, "line", time.Hour), &buf,
+		appoptics.JobPollInterval(time.Millisecond, time.Millisecond))
+	require.Nil(t, err)
+
+	assert.Equal(t, imageServer.URL+"/chart.png", snapshot.ImageHref)
+	assert.Equal(t, png, buf.Bytes())
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var alertCommands = map[string]command{
+	"list": {
+		help: "list Alerts",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			page := addPageFlags(fs, true)
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			if page.all {
+				alerts, err := appoptics.ListAllAlerts(c.client.AlertsService())
+				if err != nil {
+					return nil, err
+				}
+				return alertsTable(alerts), nil
+			}
+
+			resp, err := c.client.AlertsService().List(page.params())
+			if err != nil {
+				return nil, err
+			}
+			return pageMessage(alertsTable(resp.Alerts), len(resp.Alerts), resp.Query), nil
+		},
+	},
+	"get": {
+		args: "ID",
+		help: "show an Alert",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "alert ID")
+			if err != nil {
+				return nil, err
+			}
+			alert, err := c.client.AlertsService().Retrieve(id[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: alert}, nil
+		},
+	},
+	"status": {
+		args: "ID",
+		help: "show whether an Alert is triggered",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "alert ID")
+			if err != nil {
+				return nil, err
+			}
+			status, err := c.client.AlertsService().Status(id[0])
+			if err != nil {
+				return nil, err
+			}
+			return table(status, []string{"ID", "NAME", "STATUS"},
+				[][]string{{itoa(status.Alert.ID), status.Alert.Name, status.Status}}), nil
+		},
+	},
+	"watch": {
+		args: "[ID...]",
+		help: "report Alerts being triggered, cleared, disabled and enabled until interrupted",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			interval := fs.Duration("interval", time.Minute, "delay between polls, which must be positive")
+			args, err := parse(fs, args, 0, -1)
+			if err != nil {
+				return nil, err
+			}
+			if *interval <= 0 {
+				return nil, errUsage
+			}
+			opts := []appoptics.WatchAlertsOption{
+				appoptics.WatchInterval(*interval),
+				appoptics.WatchErrors(func(err error) {
+					fmt.Fprintln(c.stderr, "appoptics:", err)
+				}),
+			}
+			for _, arg := range args {
+				id, err := intArg(arg, "alert ID")
+				if err != nil {
+					return nil, err
+				}
+				opts = append(opts, appoptics.WatchAlertIDs(id))
+			}
+
+			enc := json.NewEncoder(c.stdout)
+			err = appoptics.WatchAlerts(c.ctx, c.client.AlertsService(), func(e appoptics.AlertTransitionEvent) {
+				if c.format == "json" {
+					enc.Encode(e)
+					return
+				}
+				fmt.Fprintf(c.stdout, "%s\t%d\t%s\t%s\n", e.Time.Format(time.RFC3339), e.Alert.ID, e.Alert.Name, e.Transition)
+			}, opts...)
+			if err == context.Canceled {
+				err = nil
+			}
+			return nil, err
+		},
+	},
+	"create": {
+		help: "create an Alert from a JSON AlertRequest",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Alert")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+			req := &appoptics.AlertRequest{}
+			if err := c.readJSON(*file, req); err != nil {
+				return nil, err
+			}
+			alert, err := c.client.AlertsService().Create(req)
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: alert}, nil
+		},
+	},
+	"update": {
+		args: "ID",
+		help: "replace an Alert with a JSON AlertRequest",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Alert")
+			id, err := idArgs(fs, args, "alert ID")
+			if err != nil {
+				return nil, err
+			}
+			req := &appoptics.AlertRequest{}
+			if err := c.readJSON(*file, req); err != nil {
+				return nil, err
+			}
+			req.ID = id[0]
+			if err := c.client.AlertsService().Update(req); err != nil {
+				return nil, err
+			}
+			return message("updated alert %d", id[0]), nil
+		},
+	},
+	"delete": {
+		args: "ID",
+		help: "delete an Alert",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "alert ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.AlertsService().Delete(id[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted alert %d", id[0]), nil
+		},
+	},
+	"associate": {
+		args: "ID SERVICE_ID",
+		help: "notify a Service when an Alert triggers",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			ids, err := idArgs(fs, args, "alert ID", "service ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.AlertsService().AssociateToService(ids[0], ids[1]); err != nil {
+				return nil, err
+			}
+			return message("associated alert %d with service %d", ids[0], ids[1]), nil
+		},
+	},
+	"disassociate": {
+		args: "ID SERVICE_ID",
+		help: "stop notifying a Service when an Alert triggers",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			ids, err := idArgs(fs, args, "alert ID", "service ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.AlertsService().DisassociateFromService(ids[0], ids[1]); err != nil {
+				return nil, err
+			}
+			return message("disassociated alert %d from service %d", ids[0], ids[1]), nil
+		},
+	},
+}
+
+// idArgs parses flags and exactly one numeric positional argument per name
+func idArgs(fs *flag.FlagSet, args []string, names ...string) ([]int, error) {
+	args, err := parse(fs, args, len(names), len(names))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(names))
+	for i, name := range names {
+		if ids[i], err = intArg(args[i], name); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func alertsTable(alerts []*appoptics.Alert) *result {
+	rows := make([][]string, 0, len(alerts))
+	for _, a := range alerts {
+		var services []string
+		for _, s := range a.Services {
+			services = append(services, s.Title)
+		}
+		rows = append(rows, []string{
+			itoa(a.ID), a.Name, btoa(a.Active), itoa(len(a.Conditions)), strings.Join(services, ","),
+		})
+	}
+	return table(alerts, []string{"ID", "NAME", "ACTIVE", "CONDITIONS", "SERVICES"}, rows)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var annotationCommands = map[string]command{
+	"list": {
+		help: "list annotation streams",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			name := fs.String("name", "", "only streams whose names contain this string")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			var search *string
+			if *name != "" {
+				search = name
+			}
+			resp, err := c.client.AnnotationsService().List(search)
+			if err != nil {
+				return nil, err
+			}
+
+			rows := make([][]string, 0, len(resp.AnnotationStreams))
+			for _, stream := range resp.AnnotationStreams {
+				rows = append(rows, []string{stream.Name, stream.DisplayName})
+			}
+			res := table(resp.AnnotationStreams, []string{"NAME", "DISPLAY NAME"}, rows)
+			return pageMessage(res, len(resp.AnnotationStreams), resp.Query), nil
+		},
+	},
+	"get": {
+		args: "STREAM",
+		help: "list the events in an annotation stream",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			var start, end timeFlag
+			var sources listFlag
+			fs.Var(&start, "start", "only events after this time")
+			fs.Var(&end, "end", "only events before this time")
+			fs.Var(&sources, "source", "only events from this source; may be repeated")
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			stream, err := c.client.AnnotationsService().Retrieve(&appoptics.RetrieveAnnotationsRequest{
+				Name:      args[0],
+				StartTime: start.Time,
+				EndTime:   end.Time,
+				Sources:   sources,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			var rows [][]string
+			for _, bySource := range stream.Events {
+				for _, source := range sortedKeys(bySource) {
+					for _, event := range bySource[source] {
+						rows = append(rows, []string{
+							itoa(event.ID), source, event.Title, formatUnix(event.StartTime), formatUnix(event.EndTime),
+						})
+					}
+				}
+			}
+			return table(stream, []string{"ID", "SOURCE", "TITLE", "START", "END"}, rows), nil
+		},
+	},
+	"export": {
+		args: "STREAM",
+		help: "write the events of an annotation stream over a time range as CSV or JSON",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			var start, end timeFlag
+			var sources listFlag
+			fs.Var(&start, "start", "beginning of the exported range (default 7 days ago)")
+			fs.Var(&end, "end", "end of the exported range (default now)")
+			fs.Var(&sources, "source", "only events from this source; may be repeated")
+			format := fs.String("format", "csv", "export format, csv or json")
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			if start.IsZero() {
+				start.Time = time.Now().Add(-7 * 24 * time.Hour)
+			}
+			it := appoptics.NewAnnotationEventIterator(c.client.AnnotationsService(), &appoptics.AnnotationEventsRequest{
+				Name:      args[0],
+				StartTime: start.Time,
+				EndTime:   end.Time,
+				Sources:   sources,
+			})
+			_, err = appoptics.ExportAnnotationEvents(c.stdout, it, appoptics.AnnotationExportFormat(*format))
+			return nil, err
+		},
+	},
+	"event": {
+		args: "STREAM ID",
+		help: "show an annotation event",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 2, 2)
+			if err != nil {
+				return nil, err
+			}
+			id, err := intArg(args[1], "event ID")
+			if err != nil {
+				return nil, err
+			}
+			event, err := c.client.AnnotationsService().RetrieveEvent(args[0], id)
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: event}, nil
+		},
+	},
+	"create": {
+		args: "STREAM",
+		help: "add an event to an annotation stream, creating the stream if needed",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "AnnotationEvent, instead of the other flags")
+			title := fs.String("title", "", "event title")
+			description := fs.String("description", "", "event description")
+			source := fs.String("source", "", "event source, such as a host name")
+			var start, end timeFlag
+			var links listFlag
+			fs.Var(&start, "start", "event start time (default now)")
+			fs.Var(&end, "end", "event end time")
+			fs.Var(&links, "link", "URL to link from the event; may be repeated")
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			event := &appoptics.AnnotationEvent{}
+			if *file != "" {
+				if err := c.readJSON(*file, event); err != nil {
+					return nil, err
+				}
+			} else {
+				if *title == "" {
+					return nil, errUsage
+				}
+				event.Title = *title
+				event.Description = *description
+				event.Source = *source
+				event.StartTime = start.Unix()
+				if start.IsZero() {
+					event.StartTime = time.Now().Unix()
+				}
+				if !end.IsZero() {
+					event.EndTime = end.Unix()
+				}
+				for _, href := range links {
+					event.Links = append(event.Links, appoptics.AnnotationLink{Rel: "link", Href: href})
+				}
+			}
+
+			created, err := c.client.AnnotationsService().Create(event, args[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: created}, nil
+		},
+	},
+	"update": {
+		args: "STREAM DISPLAY_NAME",
+		help: "set the display name of an annotation stream",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 2, 2)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.AnnotationsService().UpdateStream(args[0], args[1]); err != nil {
+				return nil, err
+			}
+			return message("updated annotation stream %s", args[0]), nil
+		},
+	},
+	"link": {
+		args: "STREAM ID",
+		help: "add a link to an annotation event",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			href := fs.String("href", "", "link URL")
+			rel := fs.String("rel", "link", "link relation")
+			label := fs.String("label", "", "link label")
+			args, err := parse(fs, args, 2, 2)
+			if err != nil {
+				return nil, err
+			}
+			id, err := intArg(args[1], "event ID")
+			if err != nil {
+				return nil, err
+			}
+			if *href == "" {
+				return nil, errUsage
+			}
+			link, err := c.client.AnnotationsService().UpdateEvent(args[0], id, &appoptics.AnnotationLink{
+				Rel:   *rel,
+				Href:  *href,
+				Label: *label,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: link}, nil
+		},
+	},
+	"modify": {
+		args: "STREAM ID",
+		help: "change the title, description or times of an annotation event",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			title := fs.String("title", "", "new event title")
+			description := fs.String("description", "", "new event description")
+			source := fs.String("source", "", "new event source")
+			var start, end timeFlag
+			fs.Var(&start, "start", "new event start time")
+			fs.Var(&end, "end", "new event end time")
+			args, err := parse(fs, args, 2, 2)
+			if err != nil {
+				return nil, err
+			}
+			id, err := intArg(args[1], "event ID")
+			if err != nil {
+				return nil, err
+			}
+
+			event := &appoptics.AnnotationEvent{Title: *title, Description: *description, Source: *source}
+			if !start.IsZero() {
+				event.StartTime = start.Unix()
+			}
+			if !end.IsZero() {
+				event.EndTime = end.Unix()
+			}
+			if err := c.client.AnnotationsService().ModifyEvent(args[0], id, event); err != nil {
+				return nil, err
+			}
+			return message("updated annotation event %d", id), nil
+		},
+	},
+	"delete": {
+		args: "STREAM",
+		help: "delete an annotation stream and all its events",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.AnnotationsService().Delete(args[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted annotation stream %s", args[0]), nil
+		},
+	},
+}
+
+func formatUnix(t int64) string {
+	if t == 0 {
+		return ""
+	}
+	return time.Unix(t, 0).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var resources = map[string]map[string]command{
+	"alerts":       alertCommands,
+	"annotations":  annotationCommands,
+	"charts":       chartCommands,
+	"jobs":         jobCommands,
+	"measurements": measurementCommands,
+	"metrics":      metricCommands,
+	"services":     serviceCommands,
+	"snapshots":    snapshotCommands,
+	"spaces":       spaceCommands,
+	"tokens":       tokenCommands,
+}
+
+// parse parses flags interspersed with positional arguments, returning the positional arguments. errUsage is
+// returned unless there are between min and max of them, a negative max allowing any number.
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) < min || (max >= 0 && len(positional) > max) {
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+// intArg converts a positional argument to an ID
+func intArg(s, what string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, not %q", what, s)
+	}
+	return id, nil
+}
+
+// fileFlag registers the -f flag naming a JSON input file
+func fileFlag(fs *flag.FlagSet, what string) *string {
+	return fs.String("f", "", fmt.Sprintf("JSON file holding the %s, or - for standard input", what))
+}
+
+// readJSON decodes the JSON object in the file given with -f, rejecting unknown fields
+func (c *cli) readJSON(path string, v interface{}) error {
+	var r io.Reader
+	switch path {
+	case "":
+		return errUsage
+	case "-":
+		r = c.stdin
+	default:
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("reading %s: %s", path, err)
+	}
+	return nil
+}
+
+// tagsFlag is a repeatable name=value flag
+type tagsFlag map[string]string
+
+func (t tagsFlag) String() string {
+	pairs := make([]string, 0, len(t))
+	for _, k := range sortedKeys(t) {
+		pairs = append(pairs, k+"="+t[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (t tagsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, not %q", s)
+	}
+	t[name] = value
+	return nil
+}
+
+func addTagsFlag(fs *flag.FlagSet, usage string) tagsFlag {
+	tags := tagsFlag{}
+	fs.Var(tags, "tag", usage+" as name=value; may be repeated")
+	return tags
+}
+
+// listFlag is a repeatable string flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// timeFlag accepts RFC 3339 times and Unix timestamps
+type timeFlag struct {
+	time.Time
+}
+
+func (t *timeFlag) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (t *timeFlag) Set(s string) error {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		t.Time = time.Unix(unix, 0)
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("expected an RFC 3339 time or Unix timestamp, not %q", s)
+	}
+	t.Time = parsed
+	return nil
+}
+
+// pageFlags selects a page of a list, or every page with -all
+type pageFlags struct {
+	offset int
+	length int
+	all    bool
+}
+
+func addPageFlags(fs *flag.FlagSet, all bool) *pageFlags {
+	p := &pageFlags{}
+	fs.IntVar(&p.offset, "offset", 0, "index of the first result")
+	fs.IntVar(&p.length, "length", 0, "number of results to return (default chosen by the API)")
+	if all {
+		fs.BoolVar(&p.all, "all", false, "fetch every page")
+	}
+	return p
+}
+
+func (p *pageFlags) params() *appoptics.PaginationParameters {
+	if p.offset == 0 && p.length == 0 {
+		return nil
+	}
+	return &appoptics.PaginationParameters{Offset: p.offset, Length: p.length}
+}
+
+// pageMessage notes when a listing is not complete
+func pageMessage(res *result, shown int, query appoptics.QueryInfo) *result {
+	if end := query.Offset + shown; end < query.Found {
+		res.message = fmt.Sprintf("showing %d-%d of %d; use -offset or -all for more", query.Offset+1, end, query.Found)
+	}
+	return res
+}
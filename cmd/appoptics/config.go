@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// config holds the settings read from the config file, the environment and the global flags
+type config struct {
+	Token string `yaml:"token"`
+	URL   string `yaml:"url"`
+}
+
+// loadConfig reads the config file and applies the environment and then the flags over it. A missing config
+// file is only an error if its path was given explicitly.
+func loadConfig(path, token, url string) (*config, error) {
+	cfg := &config{}
+
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			path = filepath.Join(dir, "appoptics", "config.yaml")
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist) && !explicit:
+		case err != nil:
+			return nil, err
+		default:
+			if err := yaml.Unmarshal(data, cfg); err != nil {
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+		}
+	}
+
+	for _, setting := range []struct {
+		value *string
+		env   string
+		flag  string
+	}{
+		{&cfg.Token, "APPOPTICS_TOKEN", token},
+		{&cfg.URL, "APPOPTICS_URL", url},
+	} {
+		if v := os.Getenv(setting.env); v != "" {
+			*setting.value = v
+		}
+		if setting.flag != "" {
+			*setting.value = setting.flag
+		}
+	}
+
+	return cfg, nil
+}
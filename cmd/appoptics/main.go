@@ -0,0 +1,198 @@
+// Command appoptics is a command-line client for the AppOptics API.
+//
+// Usage:
+//
+//	appoptics [global flags] <resource> <command> [flags] [arguments]
+//
+// Run "appoptics help" for the list of resources and commands. The API token is taken from the -token flag, the
+// APPOPTICS_TOKEN environment variable or the config file, in that order. The config file defaults to
+// appoptics/config.yaml in the user configuration directory, and may hold
+//
+//	token: 0123456789abcdef
+//	url: https://api.appoptics.com/v1/
+//
+// Results are printed as a table, or as JSON with -o json. Objects passed to create and update commands are
+// read as JSON from the file given with -f, or from standard input when it is "-".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+// version is the version of the command, which release builds may set with -ldflags "-X main.version=...".
+// Otherwise it is taken from the module version the binary was built from.
+var version string
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// cli holds what a command needs to talk to the API and to the user
+type cli struct {
+	ctx    context.Context
+	client appoptics.ServiceAccessor
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	format string
+}
+
+// command is a single operation on a resource
+type command struct {
+	// args is the synopsis of the positional arguments
+	args string
+	help string
+	run  func(c *cli, fs *flag.FlagSet, args []string) (*result, error)
+}
+
+// errUsage is returned by commands given the wrong arguments
+var errUsage = errors.New("invalid arguments")
+
+// run executes the command line and returns the exit status
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("appoptics", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	token := global.String("token", "", "API token, overriding $APPOPTICS_TOKEN and the config file")
+	configPath := global.String("config", "", "config file (default appoptics/config.yaml in the user config directory)")
+	baseURL := global.String("url", "", "API base URL, overriding $APPOPTICS_URL and the config file")
+	format := global.String("o", "table", "output format, table or json")
+	debug := global.Bool("debug", false, "dump HTTP requests and responses")
+	global.Usage = func() {
+		printUsage(stderr)
+		fmt.Fprintln(stderr, "\nGlobal flags:")
+		global.PrintDefaults()
+	}
+
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "table" && *format != "json" {
+		fmt.Fprintf(stderr, "appoptics: unknown output format %q\n", *format)
+		return 2
+	}
+
+	args = global.Args()
+	if len(args) == 0 || args[0] == "help" {
+		global.Usage()
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	if args[0] == "version" {
+		fmt.Fprintln(stdout, cliVersion())
+		return 0
+	}
+
+	commands, ok := resources[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "appoptics: unknown resource %q\n", args[0])
+		printUsage(stderr)
+		return 2
+	}
+	if len(args) < 2 {
+		printResourceUsage(stderr, args[0])
+		return 2
+	}
+	cmd, ok := commands[args[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "appoptics: unknown %s command %q\n", args[0], args[1])
+		printResourceUsage(stderr, args[0])
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, *token, *baseURL)
+	if err != nil {
+		fmt.Fprintln(stderr, "appoptics:", err)
+		return 1
+	}
+	if cfg.Token == "" {
+		fmt.Fprintln(stderr, "appoptics: no API token; use -token, $APPOPTICS_TOKEN or the config file")
+		return 1
+	}
+
+	opts := []func(*appoptics.Client) error{appoptics.UserAgentClientOption("appoptics-cli")}
+	if cfg.URL != "" {
+		opts = append(opts, appoptics.BaseURLClientOption(cfg.URL))
+	}
+	if *debug {
+		opts = append(opts, appoptics.SetDebugMode())
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := &cli{ctx: ctx, client: appoptics.NewClient(cfg.Token, opts...), stdin: stdin, stdout: stdout, stderr: stderr, format: *format}
+
+	name := args[0] + " " + args[1]
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: appoptics %s [flags] %s\n\n%s\n", name, cmd.args, cmd.help)
+		fs.PrintDefaults()
+	}
+
+	res, err := cmd.run(c, fs, args[2:])
+	switch {
+	case err == flag.ErrHelp:
+		return 0
+	case err == errUsage:
+		fs.Usage()
+		return 2
+	case err != nil:
+		fmt.Fprintln(stderr, "appoptics:", err)
+		return 1
+	}
+
+	if err := res.render(stdout, *format); err != nil {
+		fmt.Fprintln(stderr, "appoptics:", err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: appoptics [global flags] <resource> <command> [flags] [arguments]")
+	fmt.Fprintln(w, "\nResources:")
+	for _, name := range sortedKeys(resources) {
+		fmt.Fprintf(w, "  %-12s %s\n", name, strings.Join(sortedKeys(resources[name]), ", "))
+	}
+	fmt.Fprintln(w, "\nRun \"appoptics <resource>\" for the commands of a resource, and \"appoptics version\" for the version.")
+}
+
+func printResourceUsage(w io.Writer, resource string) {
+	fmt.Fprintf(w, "usage: appoptics %s <command> [flags] [arguments]\n\nCommands:\n", resource)
+	for _, name := range sortedKeys(resources[resource]) {
+		cmd := resources[resource][name]
+		fmt.Fprintf(w, "  %-28s %s\n", strings.TrimSpace(name+" "+cmd.args), cmd.help)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cliVersion returns the version of the command, or "(devel)" when it was built from a working tree
+func cliVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI records posted measurement batches and serves canned responses for other paths
+type fakeAPI struct {
+	mu      sync.Mutex
+	batches []appoptics.MeasurementsBatch
+	auth    []string
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, string) {
+	api := &fakeAPI{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/measurements", func(w http.ResponseWriter, r *http.Request) {
+		body, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		batch := appoptics.MeasurementsBatch{}
+		require.NoError(t, json.NewDecoder(body).Decode(&batch))
+
+		api.mu.Lock()
+		api.batches = append(api.batches, batch)
+		api.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("/v1/alerts", func(w http.ResponseWriter, r *http.Request) {
+		_, token, _ := r.BasicAuth()
+		api.mu.Lock()
+		api.auth = append(api.auth, token)
+		api.mu.Unlock()
+		fmt.Fprint(w, `{"query": {"found": 3, "length": 2, "offset": 0, "total": 3}, "alerts": [
+			{"id": 1, "name": "cpu.high", "active": true, "conditions": [{"type": "above"}],
+			 "services": [{"id": 5, "title": "Ops"}]},
+			{"id": 2, "name": "disk.full", "active": false}]}`)
+	})
+	mux.HandleFunc("/v1/alerts/9", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"errors": {"request": ["not found"]}}`)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return api, server.URL + "/v1/"
+}
+
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestReadMeasurements(t *testing.T) {
+	jsonLines := `{"name": "cpu", "value": 0.5, "tags": {"host": "a"}}
+{"name": "mem", "value": 12, "time": 1700000000}
+`
+	csvInput := "name,value,time,host\ncpu,0.5,,a\nmem,12,1700000000,\n"
+
+	for _, tc := range []struct {
+		input, format string
+	}{
+		{jsonLines, "json"},
+		{jsonLines, "auto"},
+		{"\n  " + jsonLines, "auto"},
+		{csvInput, "csv"},
+		{csvInput, "auto"},
+	} {
+		measurements, err := readMeasurements(strings.NewReader(tc.input), tc.format)
+		require.NoError(t, err, tc.input)
+		require.Len(t, measurements, 2, tc.input)
+		assert.Equal(t, "cpu", measurements[0].Name)
+		assert.EqualValues(t, 0.5, measurements[0].Value)
+		assert.Equal(t, map[string]string{"host": "a"}, measurements[0].Tags)
+		assert.Nil(t, measurements[1].Tags)
+		assert.Equal(t, int64(1700000000), measurements[1].Time)
+	}
+
+	for name, input := range map[string]string{
+		"unknown field": `{"name": "cpu", "valeu": 1}`,
+		"no name":       `{"value": 1}`,
+		"no CSV name":   "metric,value\ncpu,1\n",
+		"bad value":     "name,value\ncpu,high\n",
+		"bad time":      "name,value,time\ncpu,1,yesterday\n",
+	} {
+		_, err := readMeasurements(strings.NewReader(input), "auto")
+		assert.Error(t, err, name)
+	}
+
+	measurements, err := readMeasurements(strings.NewReader(""), "auto")
+	assert.NoError(t, err)
+	assert.Empty(t, measurements)
+}
+
+func TestMeasurementsPost(t *testing.T) {
+	api, url := newFakeAPI(t)
+
+	var input strings.Builder
+	input.WriteString("name,value\n")
+	for i := 0; i < 1500; i++ {
+		fmt.Fprintf(&input, "requests,%d\n", i)
+	}
+
+	code, stdout, stderr := runCLI(t, input.String(), "-token", "t", "-url", url,
+		"measurements", "post", "-tag", "env=test")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "posted 1500 measurements\n", stdout)
+
+	require.Len(t, api.batches, 2)
+	assert.Len(t, api.batches[0].Measurements, appoptics.MeasurementPostMaxBatchSize)
+	assert.Len(t, api.batches[1].Measurements, 500)
+	assert.Equal(t, map[string]string{"env": "test"}, *api.batches[0].Tags)
+	assert.NotZero(t, api.batches[0].Time)
+}
+
+func TestListOutput(t *testing.T) {
+	_, url := newFakeAPI(t)
+
+	code, stdout, stderr := runCLI(t, "", "-token", "t", "-url", url, "alerts", "list")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, `ID  NAME       ACTIVE  CONDITIONS  SERVICES
+1   cpu.high   true    1           Ops
+2   disk.full  false   0           
+showing 1-2 of 3; use -offset or -all for more
+`, stdout)
+
+	code, stdout, stderr = runCLI(t, "", "-token", "t", "-url", url, "-o", "json", "alerts", "list")
+	require.Equal(t, 0, code, stderr)
+	var alerts []appoptics.Alert
+	require.NoError(t, json.Unmarshal([]byte(stdout), &alerts))
+	assert.Len(t, alerts, 2)
+}
+
+func TestErrors(t *testing.T) {
+	_, url := newFakeAPI(t)
+
+	code, _, stderr := runCLI(t, "", "-token", "t", "-url", url, "alerts", "get", "9")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "404")
+
+	for _, args := range [][]string{
+		{},
+		{"widgets"},
+		{"alerts"},
+		{"alerts", "frobnicate"},
+		{"alerts", "get"},
+		{"alerts", "get", "1", "2"},
+		{"-o", "xml", "alerts", "list"},
+		{"alerts", "watch", "-interval", "0"},
+	} {
+		code, _, _ := runCLI(t, "", append([]string{"-token", "t", "-url", url}, args...)...)
+		assert.Equal(t, 2, code, args)
+	}
+
+	code, _, stderr = runCLI(t, "", "-token", "t", "-url", url, "alerts", "get", "one")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "alert ID must be a number")
+}
+
+func TestConfig(t *testing.T) {
+	api, url := newFakeAPI(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf("token: from-file\nurl: %s\n", url)), 0o600))
+
+	t.Setenv("APPOPTICS_TOKEN", "")
+	t.Setenv("APPOPTICS_URL", "")
+	code, _, stderr := runCLI(t, "", "-config", path, "alerts", "list")
+	require.Equal(t, 0, code, stderr)
+
+	t.Setenv("APPOPTICS_TOKEN", "from-env")
+	code, _, stderr = runCLI(t, "", "-config", path, "alerts", "list")
+	require.Equal(t, 0, code, stderr)
+
+	code, _, stderr = runCLI(t, "", "-config", path, "-token", "from-flag", "alerts", "list")
+	require.Equal(t, 0, code, stderr)
+
+	assert.Equal(t, []string{"from-file", "from-env", "from-flag"}, api.auth)
+
+	code, _, _ = runCLI(t, "", "-config", filepath.Join(t.TempDir(), "missing.yaml"), "alerts", "list")
+	assert.Equal(t, 1, code, "an explicitly given config file must exist")
+}
+
+func TestVersion(t *testing.T) {
+	code, stdout, _ := runCLI(t, "", "version")
+	assert.Equal(t, 0, code)
+	assert.NotEmpty(t, strings.TrimSpace(stdout))
+
+	defer func(v string) { version = v }(version)
+	version = "v1.2.3"
+	_, stdout, _ = runCLI(t, "", "version")
+	assert.Equal(t, "v1.2.3\n", stdout)
+}
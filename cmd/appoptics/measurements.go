@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var measurementCommands = map[string]command{
+	"post": {
+		help: "post Measurements read from standard input as JSON lines or CSV",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			format := fs.String("format", "auto", "input format: json, csv, or auto to detect it")
+			tags := addTagsFlag(fs, "tag applied to every Measurement")
+			period := fs.Int64("period", 0, "period of the Measurements in seconds, for service-side aggregation")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			measurements, err := readMeasurements(c.stdin, *format)
+			if err != nil {
+				return nil, err
+			}
+
+			now := time.Now().Unix()
+			for start := 0; start < len(measurements); start += appoptics.MeasurementPostMaxBatchSize {
+				end := min(start+appoptics.MeasurementPostMaxBatchSize, len(measurements))
+				batch := &appoptics.MeasurementsBatch{Measurements: measurements[start:end], Period: *period, Time: now}
+				if len(tags) > 0 {
+					batchTags := map[string]string(tags)
+					batch.Tags = &batchTags
+				}
+				if _, err := c.client.MeasurementsService().Create(batch); err != nil {
+					return nil, fmt.Errorf("posted %d of %d measurements: %s", start, len(measurements), err)
+				}
+			}
+			return message("posted %d measurements", len(measurements)), nil
+		},
+	},
+	"query": {
+		args: "[NAME]",
+		help: "show the Measurements of a Metric or composite expression",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			composite := fs.String("composite", "", "composite expression to query instead of a Metric")
+			duration := fs.Duration("duration", time.Hour, "period to query, ending now, unless -start is given")
+			var start, end timeFlag
+			fs.Var(&start, "start", "beginning of the queried period")
+			fs.Var(&end, "end", "end of the queried period (default now)")
+			resolution := fs.Int("resolution", 60, "resolution of the returned series in seconds")
+			tags := addTagsFlag(fs, "only series with this tag")
+			groupBy := fs.String("group-by", "", "tag name, or *, used to combine series")
+			groupByFunction := fs.String("group-by-function", "", "function combining grouped series")
+			summaryFunction := fs.String("summary-function", "", "summary field used to produce each point")
+			args, err := parse(fs, args, 0, 1)
+			if err != nil {
+				return nil, err
+			}
+			if (len(args) == 1) == (*composite != "") {
+				return nil, errUsage
+			}
+
+			rmr := &appoptics.RetrieveMeasurementsRequest{
+				Composite:       *composite,
+				StartTime:       start.Time,
+				EndTime:         end.Time,
+				Resolution:      *resolution,
+				Tags:            tags,
+				GroupBy:         *groupBy,
+				GroupByFunction: *groupByFunction,
+				SummaryFunction: *summaryFunction,
+			}
+			if len(args) == 1 {
+				rmr.Name = args[0]
+			}
+			if start.IsZero() {
+				rmr.Duration = *duration
+			}
+
+			resp, err := appoptics.RetrieveAll(c.client.MeasurementsService(), rmr)
+			if err != nil {
+				return nil, err
+			}
+
+			var rows [][]string
+			for _, series := range resp.Series {
+				labels := tagsFlag(series.Tags).String()
+				for _, point := range series.Measurements {
+					rows = append(rows, []string{labels, point.Timestamp().UTC().Format(time.RFC3339), ftoa(point.Value)})
+				}
+			}
+			return table(resp, []string{"TAGS", "TIME", "VALUE"}, rows), nil
+		},
+	},
+}
+
+// readMeasurements reads Measurements as JSON objects, one per line, or as CSV with a header row. CSV columns
+// named name, value, time, count, sum, min, max and last set those fields, and any other column is a tag. Empty
+// cells are ignored.
+func readMeasurements(r io.Reader, format string) ([]appoptics.Measurement, error) {
+	br := bufio.NewReader(r)
+	if format == "auto" {
+		format = "csv"
+		for {
+			b, err := br.ReadByte()
+			if err == io.EOF {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !strings.ContainsRune(" \t\r\n", rune(b)) {
+				if b == '{' {
+					format = "json"
+				}
+				br.UnreadByte()
+				break
+			}
+		}
+	}
+
+	switch format {
+	case "json":
+		return readMeasurementsJSON(br)
+	case "csv":
+		return readMeasurementsCSV(br)
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+func readMeasurementsJSON(r io.Reader) ([]appoptics.Measurement, error) {
+	var measurements []appoptics.Measurement
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	for {
+		var m appoptics.Measurement
+		err := dec.Decode(&m)
+		if err == io.EOF {
+			return measurements, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("measurement %d: %s", len(measurements)+1, err)
+		}
+		if m.Name == "" {
+			return nil, fmt.Errorf("measurement %d has no name", len(measurements)+1)
+		}
+		measurements = append(measurements, m)
+	}
+}
+
+func readMeasurementsCSV(r io.Reader) ([]appoptics.Measurement, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nameColumn := -1
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+		if header[i] == "name" {
+			nameColumn = i
+		}
+	}
+	if nameColumn < 0 {
+		return nil, errors.New("CSV header has no name column")
+	}
+
+	var measurements []appoptics.Measurement
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return measurements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+
+		m := appoptics.Measurement{Name: record[nameColumn]}
+		if m.Name == "" {
+			return nil, fmt.Errorf("line %d: no name", line)
+		}
+		for i, column := range header {
+			cell := record[i]
+			if i == nameColumn || cell == "" {
+				continue
+			}
+
+			switch column {
+			case "time":
+				t, err := strconv.ParseInt(cell, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: time must be a Unix timestamp, not %q", line, cell)
+				}
+				m.Time = t
+			case "count":
+				n, err := strconv.ParseInt(cell, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: count must be an integer, not %q", line, cell)
+				}
+				m.Count = n
+			case "value", "sum", "min", "max", "last":
+				v, err := strconv.ParseFloat(cell, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s must be a number, not %q", line, column, cell)
+				}
+				switch column {
+				case "value":
+					m.Value = v
+				case "sum":
+					m.Sum = v
+				case "min":
+					m.Min = v
+				case "max":
+					m.Max = v
+				case "last":
+					m.Last = v
+				}
+			default:
+				if m.Tags == nil {
+					m.Tags = map[string]string{}
+				}
+				m.Tags[column] = cell
+			}
+		}
+		measurements = append(measurements, m)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var metricCommands = map[string]command{
+	"list": {
+		help: "list Metrics",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			name := fs.String("name", "", "only Metrics whose names contain this string")
+			tags := addTagsFlag(fs, "only Metrics reported with this tag")
+			page := addPageFlags(fs, true)
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			req := &appoptics.ListMetricsRequest{Name: *name, Tags: tags}
+			if page.all {
+				metrics, err := appoptics.ListAllMetrics(c.client.MetricsService(), req)
+				if err != nil {
+					return nil, err
+				}
+				return metricsTable(metrics), nil
+			}
+
+			req.Pagination = page.params()
+			resp, err := c.client.MetricsService().List(req)
+			if err != nil {
+				return nil, err
+			}
+			return pageMessage(metricsTable(resp.Metrics), len(resp.Metrics), resp.Query), nil
+		},
+	},
+	"get": {
+		args: "NAME",
+		help: "show a Metric",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			metric, err := c.client.MetricsService().Retrieve(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: metric}, nil
+		},
+	},
+	"create": {
+		help: "create a Metric from JSON",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Metric")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+			metric := &appoptics.Metric{}
+			if err := c.readJSON(*file, metric); err != nil {
+				return nil, err
+			}
+			created, err := c.client.MetricsService().Create(metric)
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: created}, nil
+		},
+	},
+	"update": {
+		args: "NAME",
+		help: "replace a Metric with JSON, renaming it if the name differs",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Metric")
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			metric := &appoptics.Metric{}
+			if err := c.readJSON(*file, metric); err != nil {
+				return nil, err
+			}
+			if err := c.client.MetricsService().Update(args[0], metric); err != nil {
+				return nil, err
+			}
+			return message("updated metric %s", args[0]), nil
+		},
+	},
+	"update-all": {
+		help: "update the attributes of many Metrics from a JSON MetricUpdatePayload",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "update payload")
+			wait := fs.Bool("wait", false, "wait for the update Job to finish")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+			payload := &appoptics.MetricUpdatePayload{}
+			if err := c.readJSON(*file, payload); err != nil {
+				return nil, err
+			}
+
+			var job *appoptics.Job
+			var err error
+			if *wait {
+				job, err = appoptics.UpdateMetricsAndWait(c.ctx, c.client, payload)
+			} else {
+				job, err = c.client.MetricsService().UpdateAll(payload)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return jobTable(job), nil
+		},
+	},
+	"delete": {
+		args: "NAME",
+		help: "delete a Metric",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.MetricsService().Delete(args[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted metric %s", args[0]), nil
+		},
+	},
+	"tags": {
+		help: "list tag names and values",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			metric := fs.String("metric", "", "only tags reported with this Metric")
+			name := fs.String("name", "", "only tags whose names contain this string")
+			page := addPageFlags(fs, false)
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			resp, err := c.client.MetricsService().ListTags(&appoptics.ListTagsRequest{
+				Metric:     *metric,
+				Name:       *name,
+				Pagination: page.params(),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			rows := make([][]string, 0, len(resp.Tags))
+			for _, tag := range resp.Tags {
+				rows = append(rows, []string{tag.Name, strings.Join(tag.Values, ",")})
+			}
+			return pageMessage(table(resp.Tags, []string{"NAME", "VALUES"}, rows), len(resp.Tags), resp.Query), nil
+		},
+	},
+}
+
+func metricsTable(metrics []*appoptics.Metric) *result {
+	rows := make([][]string, 0, len(metrics))
+	for _, m := range metrics {
+		rows = append(rows, []string{m.Name, m.Type, itoa(m.Period), m.Description})
+	}
+	return table(metrics, []string{"NAME", "TYPE", "PERIOD", "DESCRIPTION"}, rows)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// result is the outcome of a command. In table format the columns and rows are printed if present, and the
+// value as JSON otherwise; in json format only the value is printed. The message is printed in table format only.
+type result struct {
+	value   interface{}
+	columns []string
+	rows    [][]string
+	message string
+}
+
+func (r *result) render(w io.Writer, format string) error {
+	if r == nil {
+		return nil
+	}
+
+	if format == "json" {
+		if r.value == nil {
+			return nil
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.value)
+	}
+
+	if r.columns != nil {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(r.columns, "\t"))
+		for _, row := range r.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	} else if r.value != nil && r.message == "" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r.value); err != nil {
+			return err
+		}
+	}
+
+	if r.message != "" {
+		_, err := fmt.Fprintln(w, r.message)
+		return err
+	}
+	return nil
+}
+
+// table returns a result showing value as JSON, or as a table with one row per item
+func table(value interface{}, columns []string, rows [][]string) *result {
+	if rows == nil {
+		rows = [][]string{}
+	}
+	return &result{value: value, columns: columns, rows: rows}
+}
+
+// message returns a result for a command which produces no object
+func message(format string, args ...interface{}) *result {
+	return &result{message: fmt.Sprintf(format, args...)}
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
+
+func ftoa(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func btoa(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
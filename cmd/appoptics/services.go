@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var serviceCommands = map[string]command{
+	"list": {
+		help: "list notification Services",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			page := addPageFlags(fs, true)
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			if page.all {
+				services, err := appoptics.ListAllServices(c.client.ServicesService())
+				if err != nil {
+					return nil, err
+				}
+				return servicesTable(services), nil
+			}
+
+			resp, err := c.client.ServicesService().List(page.params())
+			if err != nil {
+				return nil, err
+			}
+			return pageMessage(servicesTable(resp.Services), len(resp.Services), resp.Query), nil
+		},
+	},
+	"get": {
+		args: "ID",
+		help: "show a Service",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "service ID")
+			if err != nil {
+				return nil, err
+			}
+			service, err := c.client.ServicesService().Retrieve(id[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: service}, nil
+		},
+	},
+	"create": {
+		help: "create a Service from JSON",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Service")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+			service := &appoptics.Service{}
+			if err := c.readJSON(*file, service); err != nil {
+				return nil, err
+			}
+			created, err := c.client.ServicesService().Create(service)
+			if err != nil {
+				return nil, err
+			}
+			return servicesTable([]*appoptics.Service{created}), nil
+		},
+	},
+	"update": {
+		args: "ID",
+		help: "replace a Service with JSON",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Service")
+			id, err := idArgs(fs, args, "service ID")
+			if err != nil {
+				return nil, err
+			}
+			service := &appoptics.Service{}
+			if err := c.readJSON(*file, service); err != nil {
+				return nil, err
+			}
+			service.ID = id[0]
+			if err := c.client.ServicesService().Update(service); err != nil {
+				return nil, err
+			}
+			return message("updated service %d", id[0]), nil
+		},
+	},
+	"delete": {
+		args: "ID",
+		help: "delete a Service",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "service ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.ServicesService().Delete(id[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted service %d", id[0]), nil
+		},
+	},
+}
+
+func servicesTable(services []*appoptics.Service) *result {
+	rows := make([][]string, 0, len(services))
+	for _, s := range services {
+		rows = append(rows, []string{itoa(s.ID), s.Type, s.Title})
+	}
+	return table(services, []string{"ID", "TYPE", "TITLE"}, rows)
+}
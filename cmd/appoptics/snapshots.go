@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var snapshotCommands = map[string]command{
+	"create": {
+		args: "CHART_ID",
+		help: "snapshot a Chart, optionally waiting for the image and saving it",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			chartType := fs.String("type", "line", "chart type: line, stacked or bignumber")
+			duration := fs.Duration("duration", time.Hour, "period covered by the snapshot, ending now")
+			var sources listFlag
+			fs.Var(&sources, "source", "source shown in the snapshot (default all); may be repeated")
+			download := fs.String("download", "", "wait for the image and write it to this PNG file")
+			ids, err := idArgs(fs, args, "chart ID")
+			if err != nil {
+				return nil, err
+			}
+
+			snapshot := appoptics.NewChartSnapshot(ids[0], *chartType, *duration)
+			if len(sources) > 0 {
+				chart := snapshot.Subject["chart"]
+				chart.Sources = sources
+				snapshot.Subject["chart"] = chart
+			}
+
+			if *download == "" {
+				created, err := c.client.SnapshotsService().Create(snapshot)
+				if err != nil {
+					return nil, err
+				}
+				return &result{value: created}, nil
+			}
+
+			f, err := os.Create(*download)
+			if err != nil {
+				return nil, err
+			}
+			created, err := appoptics.CreateAndDownloadSnapshot(c.ctx, c.client, snapshot, f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(*download)
+				return nil, err
+			}
+			return &result{value: created, message: fmt.Sprintf("saved %s", *download)}, nil
+		},
+	},
+	"get": {
+		args: "ID",
+		help: "show a Snapshot",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "snapshot ID")
+			if err != nil {
+				return nil, err
+			}
+			snapshot, err := c.client.SnapshotsService().Retrieve(id[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: snapshot}, nil
+		},
+	},
+}
+
+var jobCommands = map[string]command{
+	"get": {
+		args: "ID",
+		help: "show the state of a Job",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "job ID")
+			if err != nil {
+				return nil, err
+			}
+			job, err := c.client.JobsService().Retrieve(id[0])
+			if err != nil {
+				return nil, err
+			}
+			return jobTable(job), nil
+		},
+	},
+	"wait": {
+		args: "ID",
+		help: "wait for a Job to finish, failing if the Job fails",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			timeout := fs.Duration("timeout", 0, "give up after this long (default never)")
+			id, err := idArgs(fs, args, "job ID")
+			if err != nil {
+				return nil, err
+			}
+
+			ctx := c.ctx
+			if *timeout > 0 {
+				var cancel func()
+				ctx, cancel = context.WithTimeout(ctx, *timeout)
+				defer cancel()
+			}
+			job, err := appoptics.WaitForJob(ctx, c.client.JobsService(), id[0])
+			if err != nil {
+				return nil, err
+			}
+			return jobTable(job), nil
+		},
+	},
+}
+
+func jobTable(job *appoptics.Job) *result {
+	return table(job, []string{"ID", "STATE", "PROGRESS"}, [][]string{{itoa(job.ID), job.State, ftoa(job.Progress)}})
+}
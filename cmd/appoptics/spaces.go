@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+// spacesPageLength is the page length used by spaces list -all
+const spacesPageLength = 100
+
+var spaceCommands = map[string]command{
+	"list": {
+		help: "list Spaces",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			page := addPageFlags(fs, true)
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+
+			if !page.all {
+				spaces, err := c.client.SpacesService().List(page.params())
+				if err != nil {
+					return nil, err
+				}
+				return spacesTable(spaces), nil
+			}
+
+			var spaces []*appoptics.Space
+			rp := &appoptics.PaginationParameters{Length: spacesPageLength}
+			for {
+				pageSpaces, err := c.client.SpacesService().List(rp)
+				if err != nil {
+					return nil, err
+				}
+				spaces = append(spaces, pageSpaces...)
+				if len(pageSpaces) < spacesPageLength {
+					return spacesTable(spaces), nil
+				}
+				rp.Offset += len(pageSpaces)
+			}
+		},
+	},
+	"get": {
+		args: "ID",
+		help: "show a Space and the IDs of its Charts",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "space ID")
+			if err != nil {
+				return nil, err
+			}
+			space, err := c.client.SpacesService().Retrieve(id[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: space}, nil
+		},
+	},
+	"create": {
+		args: "NAME",
+		help: "create a Space",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			space, err := c.client.SpacesService().Create(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return spacesTable([]*appoptics.Space{space}), nil
+		},
+	},
+	"update": {
+		args: "ID NAME",
+		help: "rename a Space",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 2, 2)
+			if err != nil {
+				return nil, err
+			}
+			id, err := intArg(args[0], "space ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.SpacesService().Update(id, args[1]); err != nil {
+				return nil, err
+			}
+			return message("renamed space %d to %s", id, args[1]), nil
+		},
+	},
+	"delete": {
+		args: "ID",
+		help: "delete a Space and its Charts",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "space ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.SpacesService().Delete(id[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted space %d", id[0]), nil
+		},
+	},
+}
+
+var chartCommands = map[string]command{
+	"list": {
+		args: "SPACE_ID",
+		help: "list the Charts in a Space",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "space ID")
+			if err != nil {
+				return nil, err
+			}
+			charts, err := c.client.ChartsService().List(id[0])
+			if err != nil {
+				return nil, err
+			}
+
+			rows := make([][]string, 0, len(charts))
+			for _, chart := range charts {
+				rows = append(rows, []string{itoa(chart.ID), chart.Name, chart.Type, itoa(len(chart.Streams))})
+			}
+			return table(charts, []string{"ID", "NAME", "TYPE", "STREAMS"}, rows), nil
+		},
+	},
+	"get": {
+		args: "SPACE_ID CHART_ID",
+		help: "show a Chart",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			ids, err := idArgs(fs, args, "space ID", "chart ID")
+			if err != nil {
+				return nil, err
+			}
+			chart, err := c.client.ChartsService().Retrieve(ids[1], ids[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: chart}, nil
+		},
+	},
+	"create": {
+		args: "SPACE_ID",
+		help: "create a Chart in a Space from JSON",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Chart")
+			id, err := idArgs(fs, args, "space ID")
+			if err != nil {
+				return nil, err
+			}
+			chart := &appoptics.Chart{}
+			if err := c.readJSON(*file, chart); err != nil {
+				return nil, err
+			}
+			created, err := c.client.ChartsService().Create(chart, id[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: created}, nil
+		},
+	},
+	"update": {
+		args: "SPACE_ID CHART_ID",
+		help: "update a Chart from JSON",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			file := fileFlag(fs, "Chart")
+			ids, err := idArgs(fs, args, "space ID", "chart ID")
+			if err != nil {
+				return nil, err
+			}
+			chart := &appoptics.Chart{}
+			if err := c.readJSON(*file, chart); err != nil {
+				return nil, err
+			}
+			chart.ID = ids[1]
+			updated, err := c.client.ChartsService().Update(chart, ids[0])
+			if err != nil {
+				return nil, err
+			}
+			return &result{value: updated}, nil
+		},
+	},
+	"delete": {
+		args: "SPACE_ID CHART_ID",
+		help: "delete a Chart",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			ids, err := idArgs(fs, args, "space ID", "chart ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.ChartsService().Delete(ids[1], ids[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted chart %d", ids[1]), nil
+		},
+	},
+}
+
+func spacesTable(spaces []*appoptics.Space) *result {
+	rows := make([][]string, 0, len(spaces))
+	for _, s := range spaces {
+		rows = append(rows, []string{itoa(s.ID), s.Name})
+	}
+	return table(spaces, []string{"ID", "NAME"}, rows)
+}
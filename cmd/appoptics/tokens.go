@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var tokenCommands = map[string]command{
+	"list": {
+		help: "list API tokens",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+			resp, err := c.client.ApiTokensService().List()
+			if err != nil {
+				return nil, err
+			}
+			return pageMessage(tokensTable(resp.ApiTokens), len(resp.ApiTokens), resp.Query), nil
+		},
+	},
+	"get": {
+		args: "NAME",
+		help: "show the API tokens with a name",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			args, err := parse(fs, args, 1, 1)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := c.client.ApiTokensService().Retrieve(args[0])
+			if err != nil {
+				return nil, err
+			}
+			return tokensTable(resp.ApiTokens), nil
+		},
+	},
+	"create": {
+		help: "create an API token",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			name := fs.String("name", "", "token name")
+			role := fs.String("role", "recorder", "token role: admin, recorder or viewer")
+			if _, err := parse(fs, args, 0, 0); err != nil {
+				return nil, err
+			}
+			if *name == "" {
+				return nil, errUsage
+			}
+			active := true
+			token, err := c.client.ApiTokensService().Create(&appoptics.ApiToken{Name: name, Role: role, Active: &active})
+			if err != nil {
+				return nil, err
+			}
+			return tokensTable([]*appoptics.ApiToken{token}), nil
+		},
+	},
+	"update": {
+		args: "ID",
+		help: "rename, change the role of, or deactivate an API token",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			name := fs.String("name", "", "new token name")
+			role := fs.String("role", "", "new token role")
+			active := fs.String("active", "", "true or false")
+			id, err := idArgs(fs, args, "token ID")
+			if err != nil {
+				return nil, err
+			}
+
+			token := &appoptics.ApiToken{ID: &id[0]}
+			if *name != "" {
+				token.Name = name
+			}
+			if *role != "" {
+				token.Role = role
+			}
+			switch *active {
+			case "":
+			case "true", "false":
+				isActive := *active == "true"
+				token.Active = &isActive
+			default:
+				return nil, errUsage
+			}
+
+			updated, err := c.client.ApiTokensService().Update(token)
+			if err != nil {
+				return nil, err
+			}
+			return tokensTable([]*appoptics.ApiToken{updated}), nil
+		},
+	},
+	"delete": {
+		args: "ID",
+		help: "delete an API token",
+		run: func(c *cli, fs *flag.FlagSet, args []string) (*result, error) {
+			id, err := idArgs(fs, args, "token ID")
+			if err != nil {
+				return nil, err
+			}
+			if err := c.client.ApiTokensService().Delete(id[0]); err != nil {
+				return nil, err
+			}
+			return message("deleted token %d", id[0]), nil
+		},
+	},
+}
+
+func tokensTable(tokens []*appoptics.ApiToken) *result {
+	rows := make([][]string, 0, len(tokens))
+	for _, t := range tokens {
+		id := ""
+		if t.ID != nil {
+			id = itoa(*t.ID)
+		}
+		rows = append(rows, []string{id, deref(t.Name), deref(t.Role), btoa(t.Active), deref(t.Token)})
+	}
+	return table(tokens, []string{"ID", "NAME", "ROLE", "ACTIVE", "TOKEN"}, rows)
+}
@@ -0,0 +1,155 @@
+// Package composite models AppOptics composite metric expressions, such as
+//
+//	sum(s("api.requests", {"env": "prod"}))
+//
+// Expressions can be built in Go with the functions in this package, rendered to their canonical string form
+// with String, and parsed back from the strings used in Metric.Composite and Stream.Composite with Parse.
+// https://docs.appoptics.com/kb/custom_metrics/composite_metrics/
+package composite
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node is an element of a composite expression
+type Node interface {
+	// String renders the node in canonical form
+	String() string
+	node()
+}
+
+// StringLit is a quoted string, such as a metric name or a tag value
+type StringLit string
+
+// NumberLit is a numeric literal
+type NumberLit float64
+
+// MapLit is a set of key/value pairs, used for tag filters and function options. It renders with its keys
+// sorted.
+type MapLit map[string]Node
+
+// ListLit is a list of expressions, used by functions combining several sets of series such as divide
+type ListLit []Node
+
+// Call is a function call, such as s(...) or sum(...)
+type Call struct {
+	Name string
+	Args []Node
+}
+
+func (StringLit) node() {}
+func (NumberLit) node() {}
+func (MapLit) node()    {}
+func (ListLit) node()   {}
+func (*Call) node()     {}
+
+// String renders s in double quotes, escaping only what the lexer decodes: backslashes, double quotes,
+// newlines and tabs. Any other character is written as is.
+func (s StringLit) String() string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (n NumberLit) String() string {
+	return strconv.FormatFloat(float64(n), 'g', -1, 64)
+}
+
+func (m MapLit) String() string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, len(keys))
+	for i, k := range keys {
+		entries[i] = StringLit(k).String() + ": " + m[k].String()
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+func (l ListLit) String() string {
+	return "[" + joinNodes(l) + "]"
+}
+
+func (c *Call) String() string {
+	return c.Name + "(" + joinNodes(c.Args) + ")"
+}
+
+func joinNodes(nodes []Node) string {
+	rendered := make([]string, len(nodes))
+	for i, n := range nodes {
+		rendered[i] = n.String()
+	}
+	return strings.Join(rendered, ", ")
+}
+
+// Walk calls fn for node and each of its descendants, depth first. Children are not visited if fn returns
+// false.
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case MapLit:
+		keys := make([]string, 0, len(n))
+		for k := range n {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			Walk(n[k], fn)
+		}
+	case ListLit:
+		for _, child := range n {
+			Walk(child, fn)
+		}
+	case *Call:
+		for _, child := range n.Args {
+			Walk(child, fn)
+		}
+	}
+}
+
+// Metrics returns the names of the metrics referenced by series in the expression, in order of appearance and
+// without duplicates
+func Metrics(node Node) []string {
+	var names []string
+	seen := map[string]bool{}
+
+	Walk(node, func(n Node) bool {
+		call, ok := n.(*Call)
+		if !ok || !isSeriesFunc(call.Name) || len(call.Args) == 0 {
+			return true
+		}
+		if name, ok := call.Args[0].(StringLit); ok && !seen[string(name)] {
+			seen[string(name)] = true
+			names = append(names, string(name))
+		}
+		return false
+	})
+	return names
+}
+
+func isSeriesFunc(name string) bool {
+	return name == "s" || name == "series"
+}
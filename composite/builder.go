@@ -0,0 +1,129 @@
+package composite
+
+import "strconv"
+
+// AllTags matches series regardless of their tags when used as the tag filter of S
+const AllTags = StringLit("*")
+
+// S selects the series of metric matching tags, rendering as s("metric", {"tag": "value"}). A nil or empty tags
+// map selects all series.
+func S(metric string, tags map[string]string) *Call {
+	return &Call{Name: "s", Args: []Node{StringLit(metric), tagFilter(tags)}}
+}
+
+// SWithOptions is S with additional series options, such as "period" or "function"
+func SWithOptions(metric string, tags map[string]string, options map[string]string) *Call {
+	call := S(metric, tags)
+	call.Args = append(call.Args, Options(options))
+	return call
+}
+
+// Options converts string options into a MapLit
+func Options(options map[string]string) MapLit {
+	m := make(MapLit, len(options))
+	for k, v := range options {
+		m[k] = StringLit(v)
+	}
+	return m
+}
+
+func tagFilter(tags map[string]string) Node {
+	if len(tags) == 0 {
+		return AllTags
+	}
+	return Options(tags)
+}
+
+// Sum adds the series in set together
+func Sum(set Node) *Call {
+	return &Call{Name: "sum", Args: []Node{set}}
+}
+
+// Mean averages the series in set
+func Mean(set Node) *Call {
+	return &Call{Name: "mean", Args: []Node{set}}
+}
+
+// Min takes the minimum of the series in set at each point
+func Min(set Node) *Call {
+	return &Call{Name: "min", Args: []Node{set}}
+}
+
+// Max takes the maximum of the series in set at each point
+func Max(set Node) *Call {
+	return &Call{Name: "max", Args: []Node{set}}
+}
+
+// Abs takes the absolute value of each point
+func Abs(set Node) *Call {
+	return &Call{Name: "abs", Args: []Node{set}}
+}
+
+// Derive returns the change between consecutive points. If detectReset is true, decreases are treated as
+// counter resets.
+func Derive(set Node, detectReset bool) *Call {
+	call := &Call{Name: "derive", Args: []Node{set}}
+	if detectReset {
+		call.Args = append(call.Args, MapLit{"detect_reset": StringLit("true")})
+	}
+	return call
+}
+
+// Rate returns the per-second rate of change between consecutive points
+func Rate(set Node) *Call {
+	return &Call{Name: "rate", Args: []Node{set}}
+}
+
+// Integrate returns the running total of the series
+func Integrate(set Node) *Call {
+	return &Call{Name: "integrate", Args: []Node{set}}
+}
+
+// Divide divides the series of dividend by those of divisor
+func Divide(dividend, divisor Node) *Call {
+	return &Call{Name: "divide", Args: []Node{ListLit{dividend, divisor}}}
+}
+
+// Subtract subtracts the series of subtrahend from those of minuend
+func Subtract(minuend, subtrahend Node) *Call {
+	return &Call{Name: "subtract", Args: []Node{ListLit{minuend, subtrahend}}}
+}
+
+// Multiply multiplies the series of the given sets together
+func Multiply(sets ...Node) *Call {
+	return &Call{Name: "multiply", Args: []Node{ListLit(sets)}}
+}
+
+// Scale multiplies each point by factor
+func Scale(set Node, factor float64) *Call {
+	return &Call{Name: "scale", Args: []Node{set, MapLit{"factor": StringLit(strconv.FormatFloat(factor, 'g', -1, 64))}}}
+}
+
+// Timeshift shifts the series by the given offset, such as "1h" or "7d"
+func Timeshift(offset string, set Node) *Call {
+	return &Call{Name: "timeshift", Args: []Node{StringLit(offset), set}}
+}
+
+// Window applies function, such as "mean" or "max", over a moving window of the given size, such as "5m"
+func Window(set Node, size, function string) *Call {
+	options := MapLit{"size": StringLit(size)}
+	if function != "" {
+		options["function"] = StringLit(function)
+	}
+	return &Call{Name: "window", Args: []Node{set, options}}
+}
+
+// ZeroFill replaces gaps in the series with zeros
+func ZeroFill(set Node) *Call {
+	return &Call{Name: "zero_fill", Args: []Node{set}}
+}
+
+// LastFill replaces gaps in the series with the last known value
+func LastFill(set Node) *Call {
+	return &Call{Name: "last_fill", Args: []Node{set}}
+}
+
+// MapTag evaluates expr once per value of the given tag, substituting each value where expr uses "&"
+func MapTag(tag string, expr Node) *Call {
+	return &Call{Name: "map", Args: []Node{MapLit{tag: AllTags}, expr}}
+}
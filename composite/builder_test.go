@@ -0,0 +1,37 @@
+package composite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilders(t *testing.T) {
+	requests := S("api.requests", map[string]string{"env": "prod"})
+	errors := SWithOptions("api.errors", nil, map[string]string{"function": "sum", "period": "60"})
+
+	tests := []struct {
+		node     Node
+		expected string
+	}{
+		{Sum(requests), `sum(s("api.requests", {"env": "prod"}))`},
+		{Mean(errors), `mean(s("api.errors", "*", {"function": "sum", "period": "60"}))`},
+		{Divide(Sum(errors), Sum(requests)), `divide([sum(s("api.errors", "*", {"function": "sum", "period": "60"})), sum(s("api.requests", {"env": "prod"}))])`},
+		{Scale(Rate(requests), 0.5), `scale(rate(s("api.requests", {"env": "prod"})), {"factor": "0.5"})`},
+		{Derive(requests, true), `derive(s("api.requests", {"env": "prod"}), {"detect_reset": "true"})`},
+		{Timeshift("1d", Max(requests)), `timeshift("1d", max(s("api.requests", {"env": "prod"})))`},
+		{Window(requests, "5m", "mean"), `window(s("api.requests", {"env": "prod"}), {"function": "mean", "size": "5m"})`},
+		{Multiply(requests, requests, requests), `multiply([s("api.requests", {"env": "prod"}), s("api.requests", {"env": "prod"}), s("api.requests", {"env": "prod"})])`},
+		{MapTag("host", ZeroFill(S("cpu", map[string]string{"host": "&"}))), `map({"host": "*"}, zero_fill(s("cpu", {"host": "&"})))`},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.node.String())
+		assert.NoError(t, Validate(test.node), test.expected)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	node := Divide(Sum(S("api.errors", nil)), Sum(Subtract(S("api.requests", nil), S("api.errors", nil))))
+	assert.Equal(t, []string{"api.errors", "api.requests"}, Metrics(node))
+}
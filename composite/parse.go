@@ -0,0 +1,328 @@
+package composite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SyntaxError reports a malformed composite expression
+type SyntaxError struct {
+	// Pos is the byte offset in the expression at which the error was detected
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("composite: %s at offset %d", e.Msg, e.Pos)
+}
+
+// Parse parses a composite expression and validates it with Validate
+func Parse(expr string) (Node, error) {
+	node, err := ParseUnchecked(expr)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// ParseUnchecked parses a composite expression without validating function names or arguments
+func ParseUnchecked(expr string) (Node, error) {
+	p := &parser{lexer: lexer{input: expr}}
+	p.next()
+
+	node, err := p.parseNode()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok)
+	}
+	return node, nil
+}
+
+// MustParse is Parse, panicking on error. It is intended for expressions known at compile time.
+func MustParse(expr string) Node {
+	node, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+type parser struct {
+	lexer lexer
+	tok   token
+	err   error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lexer.next()
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	if p.err != nil {
+		return p.err
+	}
+	return &SyntaxError{Pos: p.tok.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) expect(kind tokenKind) error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok.kind != kind {
+		return p.errorf("expected %s, found %s", kind, p.tok)
+	}
+	p.next()
+	return p.err
+}
+
+func (p *parser) parseNode() (Node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	switch p.tok.kind {
+	case tokString:
+		s := StringLit(p.tok.text)
+		p.next()
+		return s, p.err
+	case tokNumber:
+		v, err := strconv.ParseFloat(p.tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.tok.text)
+		}
+		p.next()
+		return NumberLit(v), p.err
+	case tokLBrace:
+		return p.parseMap()
+	case tokLBracket:
+		return p.parseList()
+	case tokIdent:
+		return p.parseCall()
+	}
+	return nil, p.errorf("unexpected %s", p.tok)
+}
+
+func (p *parser) parseCall() (Node, error) {
+	call := &Call{Name: p.tok.text}
+	p.next()
+	if err := p.expect(tokLParen); err != nil {
+		return nil, err
+	}
+
+	args, err := p.parseSequence(tokRParen)
+	if err != nil {
+		return nil, err
+	}
+	call.Args = args
+	return call, nil
+}
+
+func (p *parser) parseList() (Node, error) {
+	p.next()
+	items, err := p.parseSequence(tokRBracket)
+	if err != nil {
+		return nil, err
+	}
+	return ListLit(items), nil
+}
+
+// parseSequence parses comma separated nodes up to and including the closing token. A trailing comma is
+// accepted.
+func (p *parser) parseSequence(closing tokenKind) ([]Node, error) {
+	var nodes []Node
+	for p.tok.kind != closing {
+		node, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		if p.tok.kind != tokComma {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(closing); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// parseMap parses a map whose keys are strings or bare identifiers, e.g. {factor: "2"} or {"env": "prod"}
+func (p *parser) parseMap() (Node, error) {
+	m := MapLit{}
+	p.next()
+
+	for p.tok.kind != tokRBrace {
+		if p.tok.kind != tokString && p.tok.kind != tokIdent {
+			return nil, p.errorf("expected map key, found %s", p.tok)
+		}
+		key, keyTok := p.tok.text, p.tok
+		if _, dup := m[key]; dup {
+			return nil, &SyntaxError{Pos: keyTok.pos, Msg: fmt.Sprintf("duplicate key %q", key)}
+		}
+		p.next()
+		if err := p.expect(tokColon); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseNode()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+
+		if p.tok.kind != tokComma {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(tokRBrace); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokLBrace
+	tokRBrace
+	tokComma
+	tokColon
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:      "end of expression",
+	tokIdent:    "identifier",
+	tokString:   "string",
+	tokNumber:   "number",
+	tokLParen:   "'('",
+	tokRParen:   "')'",
+	tokLBracket: "'['",
+	tokRBracket: "']'",
+	tokLBrace:   "'{'",
+	tokRBrace:   "'}'",
+	tokComma:    "','",
+	tokColon:    "':'",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokIdent, tokNumber:
+		return fmt.Sprintf("%s %s", t.kind, t.text)
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return t.kind.String()
+}
+
+var punctuation = map[byte]tokenKind{
+	'(': tokLParen,
+	')': tokRParen,
+	'[': tokLBracket,
+	']': tokRBracket,
+	'{': tokLBrace,
+	'}': tokRBrace,
+	',': tokComma,
+	':': tokColon,
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.input[l.pos]
+
+	if kind, ok := punctuation[c]; ok {
+		l.pos++
+		return token{kind: kind, text: string(c), pos: start}, nil
+	}
+
+	switch {
+	case c == '"' || c == '\'':
+		return l.lexString(c)
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		l.pos++
+		for l.pos < len(l.input) && strings.IndexByte("0123456789.eE+-", l.input[l.pos]) >= 0 {
+			l.pos++
+		}
+		return token{kind: tokNumber, text: l.input[start:l.pos], pos: start}, nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		for l.pos < len(l.input) && (l.input[l.pos] == '_' || unicode.IsLetter(rune(l.input[l.pos])) ||
+			unicode.IsDigit(rune(l.input[l.pos]))) {
+			l.pos++
+		}
+		return token{kind: tokIdent, text: l.input[start:l.pos], pos: start}, nil
+	}
+
+	return token{}, &SyntaxError{Pos: start, Msg: fmt.Sprintf("unexpected character %q", c)}
+}
+
+// lexString reads a string quoted with quote, supporting backslash escapes of the quote and backslash
+func (l *lexer) lexString(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+
+	var b strings.Builder
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == quote:
+			l.pos++
+			return token{kind: tokString, text: b.String(), pos: start}, nil
+		case c == '\\' && l.pos+1 < len(l.input):
+			l.pos++
+			switch esc := l.input[l.pos]; esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(esc)
+			}
+		default:
+			b.WriteByte(c)
+		}
+		l.pos++
+	}
+	return token{}, &SyntaxError{Pos: start, Msg: "unterminated string"}
+}
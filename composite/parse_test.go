@@ -0,0 +1,114 @@
+package composite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr      string
+		canonical string
+	}{
+		{`sum(s("api.requests", {"env":"prod"}))`, `sum(s("api.requests", {"env": "prod"}))`},
+		{`scale(s('cpu', '*'), {factor: "2"})`, `scale(s("cpu", "*"), {"factor": "2"})`},
+		{`divide([ sum(s("a","*")) , sum(s("b","*")), ])`, `divide([sum(s("a", "*")), sum(s("b", "*"))])`},
+		{`window(s("a", "*", {period: 60}), {size: "5m"})`, `window(s("a", "*", {"period": 60}), {"size": "5m"})`},
+		{`s("quote\"d", {"k": "it's"})`, `s("quote\"d", {"k": "it's"})`},
+		{`timeshift("-1.5h", derive(series("c", "*")))`, `timeshift("-1.5h", derive(series("c", "*")))`},
+	}
+
+	for _, test := range tests {
+		node, err := Parse(test.expr)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, test.canonical, node.String())
+
+		// the canonical form parses back to the same tree
+		reparsed, err := Parse(node.String())
+		require.NoError(t, err)
+		assert.Equal(t, node, reparsed)
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	for _, name := range []string{"back\\slash", "line\nbreak\ttab", "carriage\rreturn", "bell\x01\x7f", "caf\u00e9 \u2603", "quote\"d"} {
+		node := S(name, map[string]string{name: name})
+		reparsed, err := Parse(node.String())
+		require.NoError(t, err, node.String())
+		assert.Equal(t, node, reparsed, node.String())
+	}
+}
+
+func TestParseAST(t *testing.T) {
+	node, err := Parse(`divide([s("a", {"env": "prod"}), s("b", "*")])`)
+	require.NoError(t, err)
+	assert.Equal(t, Divide(S("a", map[string]string{"env": "prod"}), S("b", nil)), node)
+}
+
+func TestParseSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{``, 0},
+		{`sum(`, 4},
+		{`sum(s("a", "*")`, 15},
+		{`sum s("a")`, 4},
+		{`s("a", {"env" "prod"})`, 14},
+		{`s("a", {"env": "prod", "env": "dev"})`, 23},
+		{`s("unterminated)`, 2},
+		{`s("a", "*") extra`, 12},
+		{`s("a" ; "*")`, 6},
+	}
+
+	for _, test := range tests {
+		_, err := ParseUnchecked(test.expr)
+		require.Error(t, err, test.expr)
+		syntaxErr, ok := err.(*SyntaxError)
+		require.True(t, ok, "%s: %v", test.expr, err)
+		assert.Equal(t, test.pos, syntaxErr.Pos, "%s: %v", test.expr, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for expr, msg := range map[string]string{
+		`"a"`:                                `expression must be a function call`,
+		`top(sum("a"), {"limit": "5"})`:      `sum: argument 1: expected a series expression`,
+		`filter([s(1, "*")])`:                `s: argument 1: expected a string`,
+		`sum(s("a", "*"), s("b", "*"))`:      `sum: expected 1 arguments, got 2`,
+		`sum("a")`:                           `sum: argument 1: expected a series expression`,
+		`s("a")`:                             `s: expected 2 to 3 arguments, got 1`,
+		`s("a", {"env": 1})`:                 `s: argument 2: tag "env" must be a string`,
+		`divide([s("a", "*")])`:              `divide: argument 1: expected 2 series expressions, got 1`,
+		`divide(s("a", "*"), s("b", "*"))`:   `divide: expected 1 arguments, got 2`,
+		`scale(s("a", "*"), {})`:             `scale: argument 2: missing required options factor`,
+		`window(s("a", "*"), {"size": [1]})`: `window: argument 2: option "size" must be a string or number`,
+		`sum(max(s(1, "*")))`:                `s: argument 1: expected a string`,
+	} {
+		_, err := Parse(expr)
+		require.Error(t, err, expr)
+		_, ok := err.(*ValidationError)
+		assert.True(t, ok, expr)
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestValidateOtherFunctions(t *testing.T) {
+	for _, expr := range []string{
+		`moving_average(s("a", "*"), {"size": "5"})`,
+		`fill(s("a", "*"), {"value": 0})`,
+		`top(s("a", "*"), {"limit": "5", "function": "max"})`,
+		`bottom(sum(s("a", "*")))`,
+		`sort(filter(s("a", "*"), {"gt": 0}), {"order": "desc"})`,
+	} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	assert.NotPanics(t, func() { MustParse(`sum(s("a", "*"))`) })
+	assert.Panics(t, func() { MustParse(`sum(`) })
+}
@@ -0,0 +1,214 @@
+package composite
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidationError reports a well-formed expression which misuses a function
+type ValidationError struct {
+	Func string
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Func == "" {
+		return "composite: " + e.Msg
+	}
+	return fmt.Sprintf("composite: %s: %s", e.Func, e.Msg)
+}
+
+type argKind int
+
+const (
+	// argSet is an expression producing a set of series, i.e. a function call
+	argSet argKind = iota
+	// argSets is a list of expressions producing sets of series
+	argSets
+	// argString is a string literal
+	argString
+	// argTags is a tag filter, either "*" or a map of strings
+	argTags
+	// argOptions is a map of options
+	argOptions
+)
+
+type funcSpec struct {
+	args []argKind
+	// optional is the number of trailing args which may be omitted
+	optional int
+	// minSets and maxSets bound the length of an argSets list; maxSets of zero means unbounded
+	minSets, maxSets int
+	// requiredOptions are the keys required in the argOptions map
+	requiredOptions []string
+}
+
+var funcSpecs = map[string]funcSpec{
+	"s":         {args: []argKind{argString, argTags, argOptions}, optional: 1},
+	"series":    {args: []argKind{argString, argTags, argOptions}, optional: 1},
+	"sum":       {args: []argKind{argSet}},
+	"mean":      {args: []argKind{argSet}},
+	"min":       {args: []argKind{argSet}},
+	"max":       {args: []argKind{argSet}},
+	"abs":       {args: []argKind{argSet}},
+	"integrate": {args: []argKind{argSet}},
+	"zero_fill": {args: []argKind{argSet}},
+	"last_fill": {args: []argKind{argSet}},
+	"rate":      {args: []argKind{argSet, argOptions}, optional: 1},
+	"derive":    {args: []argKind{argSet, argOptions}, optional: 1},
+	"divide":    {args: []argKind{argSets}, minSets: 2, maxSets: 2},
+	"subtract":  {args: []argKind{argSets}, minSets: 2, maxSets: 2},
+	"multiply":  {args: []argKind{argSets}, minSets: 2},
+	"scale":     {args: []argKind{argSet, argOptions}, requiredOptions: []string{"factor"}},
+	"timeshift": {args: []argKind{argString, argSet}},
+	"window":    {args: []argKind{argSet, argOptions}, requiredOptions: []string{"size"}},
+	"map":       {args: []argKind{argOptions, argSet}},
+}
+
+// Functions returns the names of the functions whose arguments Validate checks in detail
+func Functions() []string {
+	names := make([]string, 0, len(funcSpecs))
+	for name := range funcSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that an expression produces a set of series and that each function listed by Functions is given
+// arguments of the expected kinds. Other functions, which the API may support without this package knowing their
+// signatures, only have the expressions nested in their arguments checked.
+func Validate(node Node) error {
+	call, ok := node.(*Call)
+	if !ok {
+		return &ValidationError{Msg: fmt.Sprintf("expression must be a function call, not %s", node)}
+	}
+	return validateCall(call)
+}
+
+func validateCall(call *Call) error {
+	spec, ok := funcSpecs[call.Name]
+	if !ok {
+		for _, arg := range call.Args {
+			if err := validateNested(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	min, max := len(spec.args)-spec.optional, len(spec.args)
+	if len(call.Args) < min || len(call.Args) > max {
+		expected := fmt.Sprint(min)
+		if min != max {
+			expected = fmt.Sprintf("%d to %d", min, max)
+		}
+		return &ValidationError{Func: call.Name, Msg: fmt.Sprintf("expected %s arguments, got %d", expected, len(call.Args))}
+	}
+
+	for i, arg := range call.Args {
+		if err := validateArg(call.Name, spec, i, spec.args[i], arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateArg(name string, spec funcSpec, i int, kind argKind, arg Node) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &ValidationError{Func: name, Msg: fmt.Sprintf("argument %d: ", i+1) + fmt.Sprintf(format, args...)}
+	}
+
+	switch kind {
+	case argSet:
+		call, ok := arg.(*Call)
+		if !ok {
+			return invalid("expected a series expression, got %s", arg)
+		}
+		return validateCall(call)
+	case argSets:
+		list, ok := arg.(ListLit)
+		if !ok {
+			return invalid("expected a list of series expressions, got %s", arg)
+		}
+		if len(list) < spec.minSets || (spec.maxSets > 0 && len(list) > spec.maxSets) {
+			return invalid("expected %s series expressions, got %d", countRange(spec.minSets, spec.maxSets), len(list))
+		}
+		for _, item := range list {
+			if err := validateArg(name, spec, i, argSet, item); err != nil {
+				return err
+			}
+		}
+	case argString:
+		if _, ok := arg.(StringLit); !ok {
+			return invalid("expected a string, got %s", arg)
+		}
+	case argTags:
+		switch tags := arg.(type) {
+		case StringLit:
+			// "*" and the "&" placeholder of map are both plain strings
+		case MapLit:
+			for k, v := range tags {
+				if _, ok := v.(StringLit); !ok {
+					return invalid("tag %q must be a string, got %s", k, v)
+				}
+			}
+		default:
+			return invalid("expected a tag filter, got %s", arg)
+		}
+	case argOptions:
+		options, ok := arg.(MapLit)
+		if !ok {
+			return invalid("expected options, got %s", arg)
+		}
+		var missing []string
+		for _, key := range spec.requiredOptions {
+			if _, ok := options[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return invalid("missing required options %s", strings.Join(missing, ", "))
+		}
+		for k, v := range options {
+			switch v.(type) {
+			case StringLit, NumberLit:
+			default:
+				return invalid("option %q must be a string or number, got %s", k, v)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNested checks the function calls within an argument of a function whose signature is not known
+func validateNested(arg Node) error {
+	switch arg := arg.(type) {
+	case *Call:
+		return validateCall(arg)
+	case ListLit:
+		for _, item := range arg {
+			if err := validateNested(item); err != nil {
+				return err
+			}
+		}
+	case MapLit:
+		for _, v := range arg {
+			if err := validateNested(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func countRange(min, max int) string {
+	switch {
+	case max == 0:
+		return fmt.Sprintf("at least %d", min)
+	case min == max:
+		return fmt.Sprint(min)
+	}
+	return fmt.Sprintf("%d to %d", min, max)
+}
@@ -0,0 +1,279 @@
+// Package expvarmetrics bridges variables published with the standard expvar package into AppOptics
+// Measurements, either through an appoptics.MeasurementSet or directly as MeasurementsBatches.
+package expvarmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+var regexpIllegalNameChars = regexp.MustCompile("[^A-Za-z0-9.:_-]")
+
+// DefaultNamer replaces characters AppOptics does not allow in metric names with underscores
+func DefaultNamer(name string) string {
+	return regexpIllegalNameChars.ReplaceAllString(name, "_")
+}
+
+// Option configures a Collector
+type Option func(*Collector)
+
+// WithPrefix sets a prefix prepended to all metric names
+func WithPrefix(prefix string) Option {
+	return func(c *Collector) {
+		c.prefix = prefix
+	}
+}
+
+// WithNamer sets the function used to turn flattened expvar names, such as "memstats.HeapAlloc", into metric
+// names. The prefix is applied to the result. The default is DefaultNamer.
+func WithNamer(namer func(string) string) Option {
+	return func(c *Collector) {
+		c.namer = namer
+	}
+}
+
+// WithInclude limits collection to flattened expvar names matching at least one of the patterns
+func WithInclude(patterns ...*regexp.Regexp) Option {
+	return func(c *Collector) {
+		c.include = append(c.include, patterns...)
+	}
+}
+
+// WithExclude skips flattened expvar names matching any of the patterns. Exclusion takes precedence
+// over inclusion.
+func WithExclude(patterns ...*regexp.Regexp) Option {
+	return func(c *Collector) {
+		c.exclude = append(c.exclude, patterns...)
+	}
+}
+
+// WithTags sets tags applied to all Measurements
+func WithTags(tags map[string]string) Option {
+	return func(c *Collector) {
+		c.tags = tags
+	}
+}
+
+// WithDeltas reports the change in each value since the previous collection rather than the value itself,
+// which suits expvars used as monotonic counters. The first collection only establishes the baseline.
+func WithDeltas() Option {
+	return func(c *Collector) {
+		c.deltas = true
+	}
+}
+
+// Collector walks the published expvars, converting numeric values into Measurements. expvar.Int and
+// expvar.Float become single values, expvar.Map entries are flattened into "<name>.<key>", and any other
+// variable, including expvar.Func, is flattened from its JSON representation, skipping non-numeric values.
+type Collector struct {
+	prefix  string
+	namer   func(string) string
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	tags    map[string]string
+	deltas  bool
+
+	lastMutex sync.Mutex
+	last      map[string]float64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewCollector returns a Collector configured with the given options
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		namer: DefaultNamer,
+		last:  map[string]float64{},
+		stop:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Collect walks expvar.Do and returns a Measurement for each numeric value, sorted by name
+func (c *Collector) Collect() []appoptics.Measurement {
+	values := map[string]float64{}
+	expvar.Do(func(kv expvar.KeyValue) {
+		flattenVar(kv.Key, kv.Value, values)
+	})
+
+	c.lastMutex.Lock()
+	defer c.lastMutex.Unlock()
+
+	measurements := []appoptics.Measurement{}
+	for key, val := range values {
+		if !c.allowed(key) {
+			continue
+		}
+		if c.deltas {
+			last, seen := c.last[key]
+			c.last[key] = val
+			if !seen {
+				continue
+			}
+			// a value lower than last time indicates a reset, in which case it is entirely new
+			if val >= last {
+				val -= last
+			}
+		}
+		measurements = append(measurements, appoptics.Measurement{
+			Name:  c.prefix + c.namer(key),
+			Tags:  c.tags,
+			Value: val,
+		})
+	}
+
+	sort.Slice(measurements, func(i, j int) bool {
+		return measurements[i].Name < measurements[j].Name
+	})
+	return measurements
+}
+
+// Batch collects the expvars into a MeasurementsBatch timestamped now
+func (c *Collector) Batch() *appoptics.MeasurementsBatch {
+	return appoptics.NewMeasurementsBatch(c.Collect(), nil)
+}
+
+// Record collects the expvars into the MeasurementSet as Aggregators. When WithDeltas is set, the changes add up
+// in the Aggregator's Sum, keeping fractional changes of expvar.Floats.
+func (c *Collector) Record(m *appoptics.MeasurementSet) {
+	tags := make(map[string]interface{}, len(c.tags))
+	for k, v := range c.tags {
+		tags[k] = v
+	}
+
+	for _, measurement := range c.Collect() {
+		key := appoptics.MetricWithTags(measurement.Name, tags)
+		m.UpdateAggregatorValue(key, measurement.Value.(float64))
+	}
+}
+
+// Start kicks off a goroutine calling Record on the given interval until Stop is called
+func (c *Collector) Start(m *appoptics.MeasurementSet, interval time.Duration) {
+	go c.forever(interval, func() {
+		c.Record(m)
+	})
+}
+
+// StartSink kicks off a goroutine sending collected Measurements to sink on the given interval until Stop is
+// called. It is intended for use with BatchPersister.MeasurementsSink.
+func (c *Collector) StartSink(sink chan<- []appoptics.Measurement, interval time.Duration) {
+	go c.forever(interval, func() {
+		if measurements := c.Collect(); len(measurements) > 0 {
+			sink <- measurements
+		}
+	})
+}
+
+// Stop ends collection started with Start or StartSink
+func (c *Collector) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+func (c *Collector) forever(interval time.Duration, collect func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			collect()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *Collector) allowed(key string) bool {
+	for _, re := range c.exclude {
+		if re.MatchString(key) {
+			return false
+		}
+	}
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, re := range c.include {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenVar adds the numeric values held by v to values, keyed by name
+func flattenVar(name string, v expvar.Var, values map[string]float64) {
+	switch v := v.(type) {
+	case *expvar.Int:
+		values[name] = float64(v.Value())
+	case *expvar.Float:
+		values[name] = v.Value()
+	case *expvar.Map:
+		v.Do(func(kv expvar.KeyValue) {
+			flattenVar(name+"."+kv.Key, kv.Value, values)
+		})
+	case expvar.Func:
+		flattenValue(name, v.Value(), values)
+	default:
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(v.String()), &decoded); err == nil {
+			flattenValue(name, decoded, values)
+		}
+	}
+}
+
+// flattenValue adds the numeric values held by v, which may be a nested map, to values. Other types, including
+// slices, are skipped.
+func flattenValue(name string, v interface{}, values map[string]float64) {
+	switch v := v.(type) {
+	case int:
+		values[name] = float64(v)
+	case int32:
+		values[name] = float64(v)
+	case int64:
+		values[name] = float64(v)
+	case uint:
+		values[name] = float64(v)
+	case uint32:
+		values[name] = float64(v)
+	case uint64:
+		values[name] = float64(v)
+	case float32:
+		values[name] = float64(v)
+	case float64:
+		values[name] = v
+	case map[string]interface{}:
+		for k, inner := range v {
+			flattenValue(name+"."+k, inner, values)
+		}
+	case map[string]int64:
+		for k, inner := range v {
+			values[name+"."+k] = float64(inner)
+		}
+	case map[string]float64:
+		for k, inner := range v {
+			values[name+"."+k] = inner
+		}
+	default:
+		// structs and other types are handled through their JSON representation
+		if b, err := json.Marshal(v); err == nil {
+			var decoded interface{}
+			if err := json.Unmarshal(b, &decoded); err == nil {
+				if _, ok := decoded.(map[string]interface{}); ok {
+					flattenValue(name, decoded, values)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package expvarmetrics
+
+import (
+	"expvar"
+	"regexp"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testRequests = expvar.NewInt("test_requests")
+	testLoad     = expvar.NewFloat("test_load")
+	testHandlers = expvar.NewMap("test_handlers")
+	testConfig   = expvar.NewString("test_config")
+)
+
+func init() {
+	testRequests.Set(10)
+	testLoad.Set(0.5)
+	testHandlers.Add("users/get", 3)
+	testHandlers.AddFloat("ratio", 0.25)
+	testConfig.Set("not a number")
+	expvar.Publish("test_func", expvar.Func(func() interface{} {
+		return map[string]interface{}{"open": 2, "name": "pool", "limits": map[string]interface{}{"max": 8}}
+	}))
+}
+
+func onlyTestVars() Option {
+	return WithInclude(regexp.MustCompile("^test_"))
+}
+
+func measurementsByName(measurements []appoptics.Measurement) map[string]appoptics.Measurement {
+	byName := map[string]appoptics.Measurement{}
+	for _, m := range measurements {
+		byName[m.Name] = m
+	}
+	return byName
+}
+
+func TestCollector_Collect(t *testing.T) {
+	c := NewCollector(onlyTestVars(), WithPrefix("app."), WithTags(map[string]string{"env": "test"}))
+	byName := measurementsByName(c.Collect())
+
+	assert.Len(t, byName, 6)
+	assert.Equal(t, float64(10), byName["app.test_requests"].Value)
+	assert.Equal(t, 0.5, byName["app.test_load"].Value)
+	assert.Equal(t, float64(3), byName["app.test_handlers.users_get"].Value)
+	assert.Equal(t, 0.25, byName["app.test_handlers.ratio"].Value)
+	assert.Equal(t, float64(2), byName["app.test_func.open"].Value)
+	assert.Equal(t, float64(8), byName["app.test_func.limits.max"].Value)
+	assert.Equal(t, map[string]string{"env": "test"}, byName["app.test_requests"].Tags)
+}
+
+func TestCollector_Exclude(t *testing.T) {
+	c := NewCollector(onlyTestVars(), WithExclude(regexp.MustCompile(`^test_(func|handlers)\.`)))
+	byName := measurementsByName(c.Collect())
+
+	assert.Len(t, byName, 2)
+	assert.Contains(t, byName, "test_requests")
+	assert.Contains(t, byName, "test_load")
+}
+
+func TestCollector_Record(t *testing.T) {
+	t.Run("as gauges", func(t *testing.T) {
+		m := appoptics.NewMeasurementSet()
+		NewCollector(onlyTestVars(), WithNamer(func(s string) string { return "x." + s })).Record(m)
+
+		report := m.Reset()
+		assert.Empty(t, report.Counts)
+		assert.EqualValues(t, 10, report.Aggregators["x.test_requests"].Last)
+	})
+
+	t.Run("as deltas", func(t *testing.T) {
+		m := appoptics.NewMeasurementSet()
+		c := NewCollector(WithInclude(regexp.MustCompile("^test_(requests|load)$")), WithDeltas())
+
+		c.Record(m)
+		assert.Empty(t, m.Reset().Aggregators, "the first collection establishes the baseline")
+
+		testRequests.Add(5)
+		testLoad.Add(0.5)
+		defer testLoad.Set(0.5)
+		c.Record(m)
+		testRequests.Add(2)
+		testLoad.Add(0.25)
+		c.Record(m)
+
+		report := m.Reset()
+		assert.Empty(t, report.Counts)
+		assert.EqualValues(t, 7, report.Aggregators["test_requests"].Sum)
+		assert.EqualValues(t, 0.75, report.Aggregators["test_load"].Sum)
+	})
+}
+
+func TestCollector_Batch(t *testing.T) {
+	batch := NewCollector(WithInclude(regexp.MustCompile("^test_load$"))).Batch()
+
+	require.Len(t, batch.Measurements, 1)
+	assert.Equal(t, "test_load", batch.Measurements[0].Name)
+	assert.NotZero(t, batch.Time)
+}
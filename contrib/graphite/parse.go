@@ -0,0 +1,87 @@
+// Package graphite provides TCP listeners for the Graphite plaintext and Carbon pickle protocols, converting
+// received points into Measurements and submitting them through a BatchPersister's MeasurementsSink.
+package graphite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Point is a single Graphite datapoint
+type Point struct {
+	// Path is the metric path, without any tags
+	Path string
+	// Tags holds the tags of a tagged series, e.g. disk.used;host=a;mount=/
+	Tags      map[string]string
+	Value     float64
+	Timestamp int64
+}
+
+var errEmptyLine = errors.New("empty line")
+
+// ParseLine parses a plaintext protocol line of the form <path> <value> [<timestamp>]. A missing timestamp, or
+// a timestamp of -1, is replaced by the current time.
+func ParseLine(line string) (Point, error) {
+	var p Point
+
+	fields := strings.Fields(line)
+	switch len(fields) {
+	case 0:
+		return p, errEmptyLine
+	case 2, 3:
+	default:
+		return p, fmt.Errorf("expected <path> <value> [<timestamp>], got %q", line)
+	}
+
+	path, tags, err := ParsePath(fields[0])
+	if err != nil {
+		return p, err
+	}
+	p.Path = path
+	p.Tags = tags
+
+	p.Value, err = strconv.ParseFloat(fields[1], 64)
+	if err != nil || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+		return p, fmt.Errorf("invalid value in %q", line)
+	}
+
+	p.Timestamp = -1
+	if len(fields) == 3 {
+		ts, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid timestamp in %q", line)
+		}
+		p.Timestamp = int64(ts)
+	}
+	if p.Timestamp < 0 {
+		p.Timestamp = time.Now().Unix()
+	}
+
+	return p, nil
+}
+
+// ParsePath splits a Graphite series name in the tagged series syntax, <path>;<tag>=<value>;..., into the path
+// and its tags. Untagged names return nil tags.
+func ParsePath(name string) (string, map[string]string, error) {
+	parts := strings.Split(name, ";")
+	if parts[0] == "" {
+		return "", nil, fmt.Errorf("empty path in %q", name)
+	}
+	if len(parts) == 1 {
+		return name, nil, nil
+	}
+
+	tags := make(map[string]string, len(parts)-1)
+	for _, part := range parts[1:] {
+		i := strings.Index(part, "=")
+		if i <= 0 || i == len(part)-1 {
+			return "", nil, fmt.Errorf("invalid tag %q in %q", part, name)
+		}
+		tags[part[:i]] = part[i+1:]
+	}
+	return parts[0], tags, nil
+}
@@ -0,0 +1,55 @@
+package graphite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	t.Run("plain", func(t *testing.T) {
+		p, err := ParseLine("servers.web01.load 0.75 1700000000")
+		require.NoError(t, err)
+		assert.Equal(t, Point{Path: "servers.web01.load", Value: 0.75, Timestamp: 1700000000}, p)
+	})
+
+	t.Run("tagged", func(t *testing.T) {
+		p, err := ParseLine("disk.used;host=a;mount=/var 42 1700000000")
+		require.NoError(t, err)
+		assert.Equal(t, "disk.used", p.Path)
+		assert.Equal(t, map[string]string{"host": "a", "mount": "/var"}, p.Tags)
+	})
+
+	t.Run("missing timestamp uses now", func(t *testing.T) {
+		before := time.Now().Unix()
+		for _, line := range []string{"a.b 1", "a.b 1 -1"} {
+			p, err := ParseLine(line)
+			require.NoError(t, err)
+			assert.True(t, p.Timestamp >= before, line)
+		}
+	})
+
+	t.Run("fractional timestamp", func(t *testing.T) {
+		p, err := ParseLine("a.b 1 1700000000.9")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1700000000), p.Timestamp)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, line := range []string{
+			"a.b",
+			"a.b 1 2 3",
+			"a.b x 1700000000",
+			"a.b NaN 1700000000",
+			"a.b 1 yesterday",
+			";host=a 1 1700000000",
+			"a.b;host 1 1700000000",
+			"a.b;host= 1 1700000000",
+		} {
+			_, err := ParseLine(line)
+			assert.Error(t, err, line)
+		}
+	})
+}
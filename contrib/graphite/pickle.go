@@ -0,0 +1,472 @@
+package graphite
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxPickleSize is the largest Carbon pickle payload accepted by default
+const DefaultMaxPickleSize = 1 << 20
+
+// ParsePickle decodes a Carbon pickle payload, a pickled list of (path, (timestamp, value)) tuples, without
+// its length header. Only the opcodes needed to represent lists, tuples, strings and numbers are supported;
+// payloads referring to Python classes or functions are rejected.
+func ParsePickle(payload []byte) ([]Point, error) {
+	value, err := unpickle(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := value.(*[]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of points, got %T", value)
+	}
+
+	points := make([]Point, 0, len(*list))
+	for _, item := range *list {
+		point, err := pickledPoint(item)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// ReadPickle reads a single length-prefixed Carbon pickle payload from r and decodes it
+func ReadPickle(r io.Reader, maxSize uint32) ([]Point, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size > maxSize {
+		return nil, fmt.Errorf("pickle payload of %d bytes exceeds the limit of %d", size, maxSize)
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return ParsePickle(payload)
+}
+
+func pickledPoint(item interface{}) (Point, error) {
+	var p Point
+
+	outer := tupleItems(item)
+	if len(outer) != 2 {
+		return p, fmt.Errorf("expected a (path, (timestamp, value)) tuple, got %v", item)
+	}
+	name, ok := outer[0].(string)
+	if !ok {
+		return p, fmt.Errorf("expected a string path, got %T", outer[0])
+	}
+	inner := tupleItems(outer[1])
+	if len(inner) != 2 {
+		return p, fmt.Errorf("expected a (timestamp, value) tuple for %q", name)
+	}
+
+	path, tags, err := ParsePath(name)
+	if err != nil {
+		return p, err
+	}
+	ts, err := pickledFloat(inner[0])
+	if err != nil {
+		return p, fmt.Errorf("invalid timestamp for %q: %s", name, err)
+	}
+	value, err := pickledFloat(inner[1])
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return p, fmt.Errorf("invalid value for %q", name)
+	}
+
+	p.Path = path
+	p.Tags = tags
+	p.Timestamp = int64(ts)
+	p.Value = value
+	return p, nil
+}
+
+// tupleItems returns the items of a tuple or list
+func tupleItems(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		return v
+	case *[]interface{}:
+		return *v
+	}
+	return nil
+}
+
+func pickledFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("unexpected %T", v)
+}
+
+// pickle opcodes, see Lib/pickletools.py in the Python distribution
+const (
+	opMark           = '('
+	opStop           = '.'
+	opPop            = '0'
+	opPopMark        = '1'
+	opDup            = '2'
+	opFloat          = 'F'
+	opInt            = 'I'
+	opBinInt         = 'J'
+	opBinInt1        = 'K'
+	opLong           = 'L'
+	opBinInt2        = 'M'
+	opNone           = 'N'
+	opString         = 'S'
+	opBinString      = 'T'
+	opShortBinString = 'U'
+	opUnicode        = 'V'
+	opBinUnicode     = 'X'
+	opAppend         = 'a'
+	opGet            = 'g'
+	opBinGet         = 'h'
+	opLongBinGet     = 'j'
+	opList           = 'l'
+	opPut            = 'p'
+	opBinPut         = 'q'
+	opLongBinPut     = 'r'
+	opTuple          = 't'
+	opAppends        = 'e'
+	opEmptyList      = ']'
+	opEmptyTuple     = ')'
+	opBinFloat       = 'G'
+	opBinBytes       = 'B'
+	opShortBinBytes  = 'C'
+	opProto          = 0x80
+	opTuple1         = 0x85
+	opTuple2         = 0x86
+	opTuple3         = 0x87
+	opNewTrue        = 0x88
+	opNewFalse       = 0x89
+	opLong1          = 0x8a
+	opLong4          = 0x8b
+	opShortBinUni    = 0x8c
+	opBinUnicode8    = 0x8d
+	opBinBytes8      = 0x8e
+	opMemoize        = 0x94
+	opFrame          = 0x95
+)
+
+// pickleMark separates the items belonging to MARK based opcodes on the stack
+type pickleMark struct{}
+
+var errPickleStack = errors.New("pickle stack underflow")
+
+// unpickle evaluates the subset of the pickle machine needed for Carbon payloads. Lists are represented as
+// *[]interface{} so that APPEND affects memoized references, tuples as []interface{}, integers as int64 and
+// strings and bytes as string.
+func unpickle(payload []byte) (interface{}, error) {
+	r := bufio.NewReader(bytes.NewReader(payload))
+	var stack []interface{}
+	memo := map[int]interface{}{}
+
+	pop := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errPickleStack
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v, nil
+	}
+	popMark := func() ([]interface{}, error) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if _, ok := stack[i].(pickleMark); ok {
+				items := append([]interface{}{}, stack[i+1:]...)
+				stack = stack[:i]
+				return items, nil
+			}
+		}
+		return nil, errPickleStack
+	}
+	top := func() (interface{}, error) {
+		if len(stack) == 0 {
+			return nil, errPickleStack
+		}
+		return stack[len(stack)-1], nil
+	}
+	readN := func(n int) ([]byte, error) {
+		if n < 0 || n > len(payload) {
+			return nil, fmt.Errorf("invalid pickle length %d", n)
+		}
+		buf := make([]byte, n)
+		_, err := io.ReadFull(r, buf)
+		return buf, err
+	}
+	readLine := func() (string, error) {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(line, "\n"), nil
+	}
+	readUint := func(n int) (uint64, error) {
+		buf, err := readN(n)
+		if err != nil {
+			return 0, err
+		}
+		var v uint64
+		for i := n - 1; i >= 0; i-- {
+			v = v<<8 | uint64(buf[i])
+		}
+		return v, nil
+	}
+	appendTo := func(list interface{}, items ...interface{}) error {
+		l, ok := list.(*[]interface{})
+		if !ok {
+			return fmt.Errorf("cannot append to %T", list)
+		}
+		*l = append(*l, items...)
+		return nil
+	}
+
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated pickle: %s", err)
+		}
+
+		switch op {
+		case opProto:
+			_, err = r.ReadByte()
+		case opFrame:
+			_, err = readN(8)
+		case opStop:
+			return pop()
+		case opMark:
+			stack = append(stack, pickleMark{})
+		case opPop:
+			_, err = pop()
+		case opPopMark:
+			_, err = popMark()
+		case opDup:
+			var v interface{}
+			if v, err = top(); err == nil {
+				stack = append(stack, v)
+			}
+		case opNone:
+			stack = append(stack, nil)
+		case opNewTrue:
+			stack = append(stack, true)
+		case opNewFalse:
+			stack = append(stack, false)
+		case opInt:
+			var line string
+			if line, err = readLine(); err == nil {
+				switch line {
+				case "00":
+					stack = append(stack, false)
+				case "01":
+					stack = append(stack, true)
+				default:
+					var v int64
+					if v, err = strconv.ParseInt(line, 10, 64); err == nil {
+						stack = append(stack, v)
+					}
+				}
+			}
+		case opLong:
+			var line string
+			if line, err = readLine(); err == nil {
+				var v int64
+				if v, err = strconv.ParseInt(strings.TrimSuffix(line, "L"), 10, 64); err == nil {
+					stack = append(stack, v)
+				}
+			}
+		case opBinInt:
+			var v uint64
+			if v, err = readUint(4); err == nil {
+				stack = append(stack, int64(int32(v)))
+			}
+		case opBinInt1:
+			var v uint64
+			if v, err = readUint(1); err == nil {
+				stack = append(stack, int64(v))
+			}
+		case opBinInt2:
+			var v uint64
+			if v, err = readUint(2); err == nil {
+				stack = append(stack, int64(v))
+			}
+		case opLong1, opLong4:
+			var n uint64
+			if op == opLong1 {
+				n, err = readUint(1)
+			} else {
+				n, err = readUint(4)
+			}
+			if err != nil {
+				break
+			}
+			if n > 8 {
+				err = fmt.Errorf("integer of %d bytes is too large", n)
+				break
+			}
+			var v uint64
+			if v, err = readUint(int(n)); err == nil {
+				// sign extend the little endian two's complement value
+				if n > 0 && n < 8 && v&(1<<(8*n-1)) != 0 {
+					v |= ^uint64(0) << (8 * n)
+				}
+				stack = append(stack, int64(v))
+			}
+		case opFloat:
+			var line string
+			if line, err = readLine(); err == nil {
+				var v float64
+				if v, err = strconv.ParseFloat(line, 64); err == nil {
+					stack = append(stack, v)
+				}
+			}
+		case opBinFloat:
+			var buf []byte
+			if buf, err = readN(8); err == nil {
+				stack = append(stack, math.Float64frombits(binary.BigEndian.Uint64(buf)))
+			}
+		case opString:
+			var line string
+			if line, err = readLine(); err == nil {
+				if len(line) < 2 || (line[0] != '\'' && line[0] != '"') || line[len(line)-1] != line[0] {
+					err = fmt.Errorf("invalid quoted string %q", line)
+					break
+				}
+				stack = append(stack, strings.NewReplacer(`\\`, `\`, `\'`, `'`).Replace(line[1:len(line)-1]))
+			}
+		case opUnicode:
+			var line string
+			if line, err = readLine(); err == nil {
+				stack = append(stack, line)
+			}
+		case opShortBinString, opShortBinBytes, opShortBinUni, opBinString, opBinUnicode, opBinBytes,
+			opBinUnicode8, opBinBytes8:
+			var n uint64
+			switch op {
+			case opShortBinString, opShortBinBytes, opShortBinUni:
+				n, err = readUint(1)
+			case opBinUnicode8, opBinBytes8:
+				n, err = readUint(8)
+			default:
+				n, err = readUint(4)
+			}
+			if err != nil {
+				break
+			}
+			var buf []byte
+			if buf, err = readN(int(n)); err == nil {
+				stack = append(stack, string(buf))
+			}
+		case opEmptyList:
+			stack = append(stack, &[]interface{}{})
+		case opList:
+			var items []interface{}
+			if items, err = popMark(); err == nil {
+				stack = append(stack, &items)
+			}
+		case opAppend:
+			var v, list interface{}
+			if v, err = pop(); err == nil {
+				if list, err = top(); err == nil {
+					err = appendTo(list, v)
+				}
+			}
+		case opAppends:
+			var items []interface{}
+			var list interface{}
+			if items, err = popMark(); err == nil {
+				if list, err = top(); err == nil {
+					err = appendTo(list, items...)
+				}
+			}
+		case opEmptyTuple:
+			stack = append(stack, []interface{}{})
+		case opTuple:
+			var items []interface{}
+			if items, err = popMark(); err == nil {
+				stack = append(stack, items)
+			}
+		case opTuple1, opTuple2, opTuple3:
+			n := int(op-opTuple1) + 1
+			if len(stack) < n {
+				err = errPickleStack
+				break
+			}
+			items := append([]interface{}{}, stack[len(stack)-n:]...)
+			stack = append(stack[:len(stack)-n], items)
+		case opPut, opBinPut, opLongBinPut, opMemoize:
+			var idx int
+			switch op {
+			case opPut:
+				var line string
+				if line, err = readLine(); err == nil {
+					idx, err = strconv.Atoi(line)
+				}
+			case opBinPut:
+				var v uint64
+				v, err = readUint(1)
+				idx = int(v)
+			case opLongBinPut:
+				var v uint64
+				v, err = readUint(4)
+				idx = int(v)
+			case opMemoize:
+				idx = len(memo)
+			}
+			if err != nil {
+				break
+			}
+			var v interface{}
+			if v, err = top(); err == nil {
+				memo[idx] = v
+			}
+		case opGet, opBinGet, opLongBinGet:
+			var idx int
+			switch op {
+			case opGet:
+				var line string
+				if line, err = readLine(); err == nil {
+					idx, err = strconv.Atoi(line)
+				}
+			case opBinGet:
+				var v uint64
+				v, err = readUint(1)
+				idx = int(v)
+			case opLongBinGet:
+				var v uint64
+				v, err = readUint(4)
+				idx = int(v)
+			}
+			if err != nil {
+				break
+			}
+			v, ok := memo[idx]
+			if !ok {
+				err = fmt.Errorf("unknown memo index %d", idx)
+				break
+			}
+			stack = append(stack, v)
+		default:
+			return nil, fmt.Errorf("unsupported pickle opcode 0x%02x", op)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid pickle: %s", err)
+		}
+	}
+}
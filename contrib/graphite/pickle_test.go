@@ -0,0 +1,71 @@
+package graphite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// payloads generated by pickle.dumps in Python 3 for
+// [('servers.web01.cpu.user', (1700000000, 1.5)), ('disk.used;host=a', (1700000010.0, 42)), ('big', (2**40, -3))]
+var pickleFixtures = map[string]string{
+	"protocol 0": "(lp0\n(Vservers.web01.cpu.user\np1\n(I1700000000\nF1.5\ntp2\ntp3\na(Vdisk.used;host=a\np4\n" +
+		"(F1700000010.0\nI42\ntp5\ntp6\na(Vbig\np7\n(L1099511627776L\nI-3\ntp8\ntp9\na.",
+	"protocol 2": "\x80\x02]q\x00(X\x16\x00\x00\x00servers.web01.cpu.userq\x01J\x00\xf1SeG?\xf8\x00\x00\x00\x00\x00\x00" +
+		"\x86q\x02\x86q\x03X\x10\x00\x00\x00disk.used;host=aq\x04GA\xd9T\xfcB\x80\x00\x00K*\x86q\x05\x86q\x06" +
+		"X\x03\x00\x00\x00bigq\x07\x8a\x06\x00\x00\x00\x00\x00\x01J\xfd\xff\xff\xff\x86q\x08\x86q\te.",
+	"protocol 4": "\x80\x04\x95i\x00\x00\x00\x00\x00\x00\x00]\x94(\x8c\x16servers.web01.cpu.user\x94J\x00\xf1SeG?\xf8" +
+		"\x00\x00\x00\x00\x00\x00\x86\x94\x86\x94\x8c\x10disk.used;host=a\x94GA\xd9T\xfcB\x80\x00\x00K*\x86\x94" +
+		"\x86\x94\x8c\x03big\x94\x8a\x06\x00\x00\x00\x00\x00\x01J\xfd\xff\xff\xff\x86\x94\x86\x94e.",
+}
+
+func TestParsePickle(t *testing.T) {
+	expected := []Point{
+		{Path: "servers.web01.cpu.user", Value: 1.5, Timestamp: 1700000000},
+		{Path: "disk.used", Tags: map[string]string{"host": "a"}, Value: 42, Timestamp: 1700000010},
+		{Path: "big", Value: -3, Timestamp: 1 << 40},
+	}
+
+	for name, payload := range pickleFixtures {
+		t.Run(name, func(t *testing.T) {
+			points, err := ParsePickle([]byte(payload))
+			require.NoError(t, err)
+			assert.Equal(t, expected, points)
+		})
+	}
+
+	t.Run("python 2 strings", func(t *testing.T) {
+		points, err := ParsePickle([]byte("(lp0\n(S'a.b'\np1\n(I1700000000\nS'7'\ntp2\ntp3\na."))
+		require.NoError(t, err)
+		assert.Equal(t, []Point{{Path: "a.b", Value: 7, Timestamp: 1700000000}}, points)
+	})
+}
+
+func TestParsePickleRejects(t *testing.T) {
+	for name, payload := range map[string]string{
+		"global":    "cos\nsystem\n(S'true'\ntR.",
+		"truncated": "\x80\x02]q\x00(X\x16\x00\x00",
+		"not list":  "I1\n.",
+		"bad point": "(lp0\nI1\na.",
+	} {
+		_, err := ParsePickle([]byte(payload))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestReadPickle(t *testing.T) {
+	payload := []byte(pickleFixtures["protocol 2"])
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(len(payload)))
+	buf.Write(payload)
+
+	points, err := ReadPickle(bytes.NewReader(buf.Bytes()), DefaultMaxPickleSize)
+	require.NoError(t, err)
+	assert.Len(t, points, 3)
+
+	_, err = ReadPickle(bytes.NewReader(buf.Bytes()), 10)
+	assert.Error(t, err)
+}
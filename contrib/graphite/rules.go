@@ -0,0 +1,95 @@
+package graphite
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Rule maps dotted Graphite paths onto a metric name and tags. Pattern is matched against the whole path,
+// node by node, where "*" matches a single node. Name and tag values may refer to the nodes matched by
+// wildcards as $1, $2, ... For example, the Rule
+//
+//	Rule{Pattern: "servers.*.cpu.*", Name: "cpu.$2", Tags: map[string]string{"host": "$1"}}
+//
+// maps servers.web01.cpu.user to the metric cpu.user with the tag host=web01.
+type Rule struct {
+	Pattern string
+	Name    string
+	Tags    map[string]string
+}
+
+type compiledRule struct {
+	Rule
+	nodes []string
+}
+
+var reRuleReference = regexp.MustCompile(`\$(\d+)`)
+
+func compileRule(r Rule) (compiledRule, error) {
+	if r.Pattern == "" {
+		return compiledRule{}, fmt.Errorf("rule has an empty pattern")
+	}
+	if r.Name == "" {
+		return compiledRule{}, fmt.Errorf("rule %q has an empty name", r.Pattern)
+	}
+
+	nodes := strings.Split(r.Pattern, ".")
+	wildcards := 0
+	for _, node := range nodes {
+		if node == "*" {
+			wildcards++
+		}
+	}
+
+	check := func(s string) error {
+		for _, match := range reRuleReference.FindAllStringSubmatch(s, -1) {
+			n, _ := strconv.Atoi(match[1])
+			if n < 1 || n > wildcards {
+				return fmt.Errorf("rule %q refers to %s but has %d wildcards", r.Pattern, match[0], wildcards)
+			}
+		}
+		return nil
+	}
+	if err := check(r.Name); err != nil {
+		return compiledRule{}, err
+	}
+	for _, v := range r.Tags {
+		if err := check(v); err != nil {
+			return compiledRule{}, err
+		}
+	}
+
+	return compiledRule{Rule: r, nodes: nodes}, nil
+}
+
+// apply returns the mapped name and tags if path matches the rule
+func (r compiledRule) apply(path string) (string, map[string]string, bool) {
+	nodes := strings.Split(path, ".")
+	if len(nodes) != len(r.nodes) {
+		return "", nil, false
+	}
+
+	var captured []string
+	for i, node := range r.nodes {
+		if node == "*" {
+			captured = append(captured, nodes[i])
+		} else if node != nodes[i] {
+			return "", nil, false
+		}
+	}
+
+	expand := func(s string) string {
+		return reRuleReference.ReplaceAllStringFunc(s, func(ref string) string {
+			n, _ := strconv.Atoi(ref[1:])
+			return captured[n-1]
+		})
+	}
+
+	tags := make(map[string]string, len(r.Tags))
+	for k, v := range r.Tags {
+		tags[k] = expand(v)
+	}
+	return expand(r.Name), tags, true
+}
@@ -0,0 +1,39 @@
+package graphite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	rule, err := compileRule(Rule{
+		Pattern: "servers.*.cpu.*",
+		Name:    "cpu.$2",
+		Tags:    map[string]string{"host": "$1"},
+	})
+	require.NoError(t, err)
+
+	name, tags, ok := rule.apply("servers.web01.cpu.user")
+	assert.True(t, ok)
+	assert.Equal(t, "cpu.user", name)
+	assert.Equal(t, map[string]string{"host": "web01"}, tags)
+
+	for _, path := range []string{"servers.web01.mem.used", "servers.web01.cpu", "servers.web01.cpu.user.total"} {
+		_, _, ok = rule.apply(path)
+		assert.False(t, ok, path)
+	}
+}
+
+func TestRulesInvalid(t *testing.T) {
+	for _, rule := range []Rule{
+		{Name: "x"},
+		{Pattern: "a.*"},
+		{Pattern: "a.*", Name: "a.$2"},
+		{Pattern: "a.*", Name: "a", Tags: map[string]string{"t": "$0"}},
+	} {
+		_, err := NewServer(nil, WithRules(rule))
+		assert.Error(t, err, rule.Pattern)
+	}
+}
@@ -0,0 +1,343 @@
+package graphite
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	minAcceptBackoff = 5 * time.Millisecond
+	maxAcceptBackoff = time.Second
+)
+
+var regexpIllegalNameChars = regexp.MustCompile("[^A-Za-z0-9.:_-]")
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithRules sets the rules used to map dotted paths onto metric names and tags. Rules are tried in order and
+// the first match wins; paths matching no rule are submitted unchanged, unless WithDropUnmatched is given.
+func WithRules(rules ...Rule) Option {
+	return func(s *Server) {
+		s.rules = append(s.rules, rules...)
+	}
+}
+
+// WithDropUnmatched discards points whose path matches none of the rules
+func WithDropUnmatched() Option {
+	return func(s *Server) {
+		s.dropUnmatched = true
+	}
+}
+
+// WithGlobalTags sets tags applied to every Measurement. Tags from the series or a rule take precedence.
+func WithGlobalTags(tags map[string]string) Option {
+	return func(s *Server) {
+		s.globalTags = tags
+	}
+}
+
+// WithMaxPickleSize sets the largest Carbon pickle payload accepted; connections sending larger payloads are
+// closed. The default is DefaultMaxPickleSize.
+func WithMaxPickleSize(size uint32) Option {
+	return func(s *Server) {
+		s.maxPickleSize = size
+	}
+}
+
+// Server accepts Graphite connections and submits the received points as Measurements to a sink, normally
+// the one returned by BatchPersister.MeasurementsSink
+type Server struct {
+	sink          chan<- []appoptics.Measurement
+	rules         []Rule
+	compiled      []compiledRule
+	dropUnmatched bool
+	globalTags    map[string]string
+	maxPickleSize uint32
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	conns     map[net.Conn]struct{}
+	closed    bool
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewServer returns a Server submitting Measurements to sink. An error is returned if any of the rules is
+// invalid.
+func NewServer(sink chan<- []appoptics.Measurement, opts ...Option) (*Server, error) {
+	s := &Server{
+		sink:          sink,
+		maxPickleSize: DefaultMaxPickleSize,
+		conns:         map[net.Conn]struct{}{},
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, rule := range s.rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		s.compiled = append(s.compiled, compiled)
+	}
+
+	return s, nil
+}
+
+// ListenAndServe listens for the plaintext protocol on the given TCP address and serves in a background
+// goroutine until Close is called. It returns the bound address, which is useful when listening on port 0.
+func (s *Server) ListenAndServe(address string) (net.Addr, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	s.Serve(l)
+	return l.Addr(), nil
+}
+
+// ListenAndServePickle listens for the Carbon pickle protocol on the given TCP address and serves in a
+// background goroutine until Close is called
+func (s *Server) ListenAndServePickle(address string) (net.Addr, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	s.ServePickle(l)
+	return l.Addr(), nil
+}
+
+// Serve accepts plaintext protocol connections on l in a background goroutine until Close is called
+func (s *Server) Serve(l net.Listener) {
+	s.serve(l, s.handlePlaintext)
+}
+
+// ServePickle accepts Carbon pickle protocol connections on l in a background goroutine until Close is called
+func (s *Server) ServePickle(l net.Listener) {
+	s.serve(l, s.handlePickle)
+}
+
+// Close stops all listeners, closes open connections and waits for them to finish. Batches not yet taken
+// from the sink are discarded.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	listeners := s.listeners
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, l := range listeners {
+		if err := l.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.wg.Wait()
+	return firstErr
+}
+
+// Measurement converts a Point into a Measurement, applying the rules and global tags and replacing characters
+// AppOptics does not allow in metric names with underscores. It returns false if the point should be dropped.
+func (s *Server) Measurement(p Point) (appoptics.Measurement, bool) {
+	name := p.Path
+	var ruleTags map[string]string
+
+	matched := false
+	for _, rule := range s.compiled {
+		if mappedName, tags, ok := rule.apply(p.Path); ok {
+			name, ruleTags, matched = mappedName, tags, true
+			break
+		}
+	}
+	if !matched && s.dropUnmatched {
+		return appoptics.Measurement{}, false
+	}
+
+	var tags map[string]string
+	if len(s.globalTags)+len(ruleTags)+len(p.Tags) > 0 {
+		tags = make(map[string]string, len(s.globalTags)+len(ruleTags)+len(p.Tags))
+		for _, source := range []map[string]string{s.globalTags, ruleTags, p.Tags} {
+			for k, v := range source {
+				tags[k] = v
+			}
+		}
+	}
+
+	return appoptics.Measurement{
+		Name:  regexpIllegalNameChars.ReplaceAllString(name, "_"),
+		Tags:  tags,
+		Value: p.Value,
+		Time:  p.Timestamp,
+	}, true
+}
+
+func (s *Server) serve(l net.Listener, handle func(net.Conn)) {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		var backoff time.Duration
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				if s.isClosed() || errors.Is(err, net.ErrClosed) {
+					return
+				}
+				if backoff *= 2; backoff == 0 {
+					backoff = minAcceptBackoff
+				} else if backoff > maxAcceptBackoff {
+					backoff = maxAcceptBackoff
+				}
+				log.Error("Error accepting Graphite connection", "err", err, "retry", backoff)
+				select {
+				case <-s.done:
+					return
+				case <-time.After(backoff):
+				}
+				continue
+			}
+			backoff = 0
+
+			if !s.track(conn) {
+				conn.Close()
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				defer s.untrack(conn)
+				handle(conn)
+			}()
+		}
+	}()
+}
+
+// handlePlaintext reads lines until the connection is closed. Measurements are submitted whenever the
+// connection has no more buffered input, or a full batch has accumulated.
+func (s *Server) handlePlaintext(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	var pending []appoptics.Measurement
+
+	flush := func() {
+		if len(pending) > 0 {
+			s.send(pending)
+			pending = nil
+		}
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			s.handleLine(strings.TrimRight(line, "\r\n"), &pending)
+		}
+		if err != nil {
+			flush()
+			if err != io.EOF && !s.isClosed() {
+				log.Error("Error reading Graphite connection", "err", err)
+			}
+			return
+		}
+		if r.Buffered() == 0 || len(pending) >= appoptics.MeasurementPostMaxBatchSize {
+			flush()
+		}
+	}
+}
+
+func (s *Server) handleLine(line string, pending *[]appoptics.Measurement) {
+	p, err := ParseLine(line)
+	if err == errEmptyLine {
+		return
+	}
+	if err != nil {
+		log.Debug("Skipping malformed Graphite line", "err", err)
+		return
+	}
+	if m, ok := s.Measurement(p); ok {
+		*pending = append(*pending, m)
+	}
+}
+
+// handlePickle reads length-prefixed pickle payloads until the connection is closed or sends an invalid
+// payload
+func (s *Server) handlePickle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	for {
+		points, err := ReadPickle(r, s.maxPickleSize)
+		if err != nil {
+			if err != io.EOF && !s.isClosed() {
+				log.Error("Error reading Carbon pickle connection", "err", err)
+			}
+			return
+		}
+
+		var measurements []appoptics.Measurement
+		for _, p := range points {
+			if m, ok := s.Measurement(p); ok {
+				measurements = append(measurements, m)
+			}
+		}
+		for len(measurements) > 0 {
+			n := len(measurements)
+			if n > appoptics.MeasurementPostMaxBatchSize {
+				n = appoptics.MeasurementPostMaxBatchSize
+			}
+			if !s.send(measurements[:n]) {
+				return
+			}
+			measurements = measurements[n:]
+		}
+	}
+}
+
+// send submits a batch to the sink, returning false if the Server was closed first
+func (s *Server) send(batch []appoptics.Measurement) bool {
+	select {
+	case s.sink <- batch:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// track records an open connection so Close can interrupt it, returning false if the Server is closed
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+	conn.Close()
+}
@@ -0,0 +1,192 @@
+package graphite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerMeasurement(t *testing.T) {
+	s, err := NewServer(nil,
+		WithRules(Rule{Pattern: "servers.*.cpu.*", Name: "cpu.$2", Tags: map[string]string{"host": "$1"}}),
+		WithGlobalTags(map[string]string{"env": "prod", "host": "unknown"}),
+	)
+	require.NoError(t, err)
+
+	m, ok := s.Measurement(Point{Path: "servers.web01.cpu.user", Value: 1.5, Timestamp: 1700000000})
+	assert.True(t, ok)
+	assert.Equal(t, appoptics.Measurement{
+		Name:  "cpu.user",
+		Tags:  map[string]string{"env": "prod", "host": "web01"},
+		Value: 1.5,
+		Time:  1700000000,
+	}, m)
+
+	m, ok = s.Measurement(Point{Path: "jobs.duration", Tags: map[string]string{"env": "staging"}, Value: 3})
+	assert.True(t, ok)
+	assert.Equal(t, "jobs.duration", m.Name)
+	assert.Equal(t, map[string]string{"env": "staging", "host": "unknown"}, m.Tags)
+
+	m, ok = s.Measurement(Point{Path: "servers.web 01.cpu.us%er", Value: 1})
+	assert.True(t, ok)
+	assert.Equal(t, "cpu.us_er", m.Name)
+
+	m, ok = s.Measurement(Point{Path: "disk./var/log.used", Value: 1})
+	assert.True(t, ok)
+	assert.Equal(t, "disk._var_log.used", m.Name)
+
+	s, err = NewServer(nil, WithRules(Rule{Pattern: "a.*", Name: "$1"}), WithDropUnmatched())
+	require.NoError(t, err)
+	_, ok = s.Measurement(Point{Path: "b.c"})
+	assert.False(t, ok)
+}
+
+func TestServerPlaintext(t *testing.T) {
+	sink := make(chan []appoptics.Measurement, 10)
+	s, err := NewServer(sink)
+	require.NoError(t, err)
+	defer s.Close()
+
+	addr, err := s.ListenAndServe("127.0.0.1:0")
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("a.b 1 1700000000\r\nbogus\nc.d;host=x 2 1700000001\n"))
+	require.NoError(t, err)
+	conn.Close()
+
+	received := collect(t, sink, 2)
+	assert.Equal(t, []appoptics.Measurement{
+		{Name: "a.b", Value: 1.0, Time: 1700000000},
+		{Name: "c.d", Tags: map[string]string{"host": "x"}, Value: 2.0, Time: 1700000001},
+	}, received)
+}
+
+func TestServerPickle(t *testing.T) {
+	sink := make(chan []appoptics.Measurement, 10)
+	s, err := NewServer(sink)
+	require.NoError(t, err)
+	defer s.Close()
+
+	addr, err := s.ListenAndServePickle("127.0.0.1:0")
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	payload := []byte(pickleFixtures["protocol 4"])
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, uint32(len(payload)))
+	buf.Write(payload)
+	_, err = conn.Write(buf.Bytes())
+	require.NoError(t, err)
+
+	received := collect(t, sink, 3)
+	assert.Equal(t, "servers.web01.cpu.user", received[0].Name)
+	assert.Equal(t, int64(1700000010), received[1].Time)
+	assert.Equal(t, -3.0, received[2].Value)
+}
+
+func TestServerClose(t *testing.T) {
+	s, err := NewServer(make(chan []appoptics.Measurement))
+	require.NoError(t, err)
+
+	addr, err := s.ListenAndServe("127.0.0.1:0")
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return with an idle connection open")
+	}
+}
+
+func TestServerCloseBlockedSink(t *testing.T) {
+	s, err := NewServer(make(chan []appoptics.Measurement))
+	require.NoError(t, err)
+
+	addr, err := s.ListenAndServe("127.0.0.1:0")
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", addr.String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("a.b 1 1700000000\n"))
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not return while a batch was waiting for the sink")
+	}
+}
+
+// failingListener is a net.Listener whose Accept always fails without being closed
+type failingListener struct {
+	net.Listener
+	accepts int32
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	atomic.AddInt32(&l.accepts, 1)
+	return nil, errors.New("too many open files")
+}
+
+func (l *failingListener) Close() error {
+	return nil
+}
+
+func TestServerAcceptBackoff(t *testing.T) {
+	s, err := NewServer(make(chan []appoptics.Measurement))
+	require.NoError(t, err)
+
+	l := &failingListener{}
+	s.Serve(l)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, s.Close())
+
+	// retrying after 5ms, 10ms, 20ms and 40ms fits about five attempts in 100ms, not thousands
+	accepts := atomic.LoadInt32(&l.accepts)
+	assert.True(t, accepts > 1 && accepts < 10, "%d accepts", accepts)
+}
+
+func collect(t *testing.T, sink chan []appoptics.Measurement, n int) []appoptics.Measurement {
+	var received []appoptics.Measurement
+	timeout := time.After(time.Second)
+	for len(received) < n {
+		select {
+		case batch := <-sink:
+			received = append(received, batch...)
+		case <-timeout:
+			t.Fatalf("received %d of %d measurements", len(received), n)
+		}
+	}
+	return received
+}
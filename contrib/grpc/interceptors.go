@@ -3,15 +3,41 @@ package interceptors
 import (
 	"fmt"
 	"path"
+	"sync/atomic"
 	"time"
 
 	"github.com/appoptics/appoptics-api-go"
+	"github.com/golang/protobuf/proto"
 	ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/status"
 )
 
+// Option configures optional behavior of the interceptors
+type Option func(*options)
+
+type options struct {
+	messageSizes bool
+}
+
+// WithMessageSizes enables per-message size tracking. The size of each proto.Message sent or received
+// is recorded in the sent_bytes and received_bytes aggregators. Messages which are not proto.Messages are
+// ignored.
+func WithMessageSizes() Option {
+	return func(o *options) {
+		o.messageSizes = true
+	}
+}
+
+func evaluateOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
 type InstrumentedServer struct {
 	m       *appoptics.MeasurementSet
 	service string
@@ -32,41 +58,78 @@ func NewInstrumentedServer(m *appoptics.MeasurementSet, service string, method s
 }
 
 func (s *InstrumentedServer) key(key string) string {
-	return appoptics.MetricWithTags(fmt.Sprintf("%s.%s.%s", s.service, s.method, key), s.tags)
+	return s.keyWithTags(key, s.tags)
+}
+
+func (s *InstrumentedServer) keyWithTags(key string, tags map[string]interface{}) string {
+	return appoptics.MetricWithTags(fmt.Sprintf("%s.%s.%s", s.service, s.method, key), tags)
 }
 
 func (s *InstrumentedServer) received() {
 	s.m.Incr(s.key("received"))
 }
 
+// handled records the result of a call tagged with its status code. The status tag is applied to a copy
+// of the tags so it does not leak into other metrics emitted by this InstrumentedServer.
 func (s *InstrumentedServer) handled(err error) {
-	s.tags["status"] = status.Code(err).String()
-	s.m.Incr(s.key("result"))
+	tags := make(map[string]interface{}, len(s.tags)+1)
+	for k, v := range s.tags {
+		tags[k] = v
+	}
+	tags["status"] = status.Code(err).String()
+	s.m.Incr(s.keyWithTags("result", tags))
 }
 
 func (s *InstrumentedServer) timed(t time.Duration) {
 	s.m.UpdateAggregatorValue(s.key("time_ms"), float64(t/time.Millisecond)+float64(t%time.Millisecond)/1e9)
 }
 
-// InstrumentedServerStream implements gRPC's `Stream` interface, providing metrics for
-// the number of times Send- and RecvMsg() are called. Timing metrics are not emitted
+// InstrumentedServerStream implements gRPC's `Stream` interface, counting the messages successfully sent
+// and received over the lifetime of the stream. When the stream completes the counts are recorded in the
+// messages_sent and messages_received aggregators, so each stream contributes a single value to each.
+// Stream duration and final status are recorded by StreamServerInterceptor.
 type InstrumentedServerStream struct {
 	grpc.ServerStream
 	*InstrumentedServer
+	messageSizes bool
+	sent         int64
+	recv         int64
 }
 
 func (s *InstrumentedServerStream) SendMsg(m interface{}) error {
 	err := s.ServerStream.SendMsg(m)
-	s.handled(err)
+	if err == nil {
+		atomic.AddInt64(&s.sent, 1)
+		s.sized("sent_bytes", m)
+	}
 	return err
 }
 
 func (s *InstrumentedServerStream) RecvMsg(m interface{}) error {
 	err := s.ServerStream.RecvMsg(m)
-	s.received()
+	if err == nil {
+		atomic.AddInt64(&s.recv, 1)
+		s.sized("received_bytes", m)
+	}
 	return err
 }
 
+// sized records the encoded size of m if message size tracking is enabled
+func (s *InstrumentedServerStream) sized(key string, m interface{}) {
+	if !s.messageSizes {
+		return
+	}
+	if msg, ok := m.(proto.Message); ok {
+		s.m.UpdateAggregatorValue(s.key(key), float64(proto.Size(msg)))
+	}
+}
+
+// finished records the per-stream message counts
+func (s *InstrumentedServerStream) finished() {
+	s.m.UpdateAggregatorValue(s.key("messages_sent"), float64(atomic.LoadInt64(&s.sent)))
+	s.m.UpdateAggregatorValue(s.key("messages_received"), float64(atomic.LoadInt64(&s.recv)))
+}
+
 // Creates a UnaryServerInterceptor that submits AO metrics using the given MeasurementSet. Emits
 // counts of requests received, counts of requests handled (tagged by status code) and timings
 func UnaryServerInterceptor(m *appoptics.MeasurementSet) grpc.UnaryServerInterceptor {
@@ -88,23 +151,30 @@ func UnaryServerInterceptor(m *appoptics.MeasurementSet) grpc.UnaryServerInterce
 	}
 }
 
-// Creates a StreamServerInterceptor that submits AO metrics using the given MeasurementSet. See
-// InstrumentedServerStream for a list of metrics emitted
-func StreamServerInterceptor(m *appoptics.MeasurementSet) grpc.StreamServerInterceptor {
+// Creates a StreamServerInterceptor that submits AO metrics using the given MeasurementSet. Emits
+// counts of streams received, counts of streams handled (tagged by final status code), stream duration
+// and the per-stream message counts described by InstrumentedServerStream. Tags set by the ctxtags
+// interceptors are applied to all metrics.
+func StreamServerInterceptor(m *appoptics.MeasurementSet, opts ...Option) grpc.StreamServerInterceptor {
+	o := evaluateOptions(opts)
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		instrument := &InstrumentedServerStream{
-			ss,
-			NewInstrumentedServer(
+			ServerStream: ss,
+			InstrumentedServer: NewInstrumentedServer(
 				m,
 				path.Dir(info.FullMethod)[1:],
 				path.Base(info.FullMethod),
-				nil,
+				ctxtags.Extract(ss.Context()).Values(),
 			),
+			messageSizes: o.messageSizes,
 		}
 
+		instrument.received()
+
 		start := time.Now()
 		err := handler(srv, instrument)
 		instrument.timed(time.Now().Sub(start))
+		instrument.finished()
 		instrument.handled(err)
 
 		return err
@@ -2,13 +2,19 @@ package interceptors
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
 	"github.com/appoptics/appoptics-api-go"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var (
@@ -33,3 +39,114 @@ func TestUnaryRequest(t *testing.T) {
 	assert.NotNil(t, strings.Contains(stupidTestShenanigans, "something.blah.result::status::OK"))
 	assert.NotNil(t, strings.Contains(stupidTestShenanigans, "something.blah.time_ms"))
 }
+
+// contextWithTags returns a context carrying ctxtags populated with tags, as the ctxtags interceptors would
+func contextWithTags(tags map[string]interface{}) context.Context {
+	var tagged context.Context
+	ctxtags.UnaryServerInterceptor()(context.Background(), nil, uInfo, func(ctx context.Context, req interface{}) (interface{}, error) {
+		tagged = ctx
+		return nil, nil
+	})
+	for k, v := range tags {
+		ctxtags.Extract(tagged).Set(k, v)
+	}
+	return tagged
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	incoming []interface{}
+}
+
+func (f *fakeServerStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeServerStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func (f *fakeServerStream) RecvMsg(m interface{}) error {
+	if len(f.incoming) == 0 {
+		return io.EOF
+	}
+	f.incoming = f.incoming[1:]
+	return nil
+}
+
+var sInfo = &grpc.StreamServerInfo{
+	FullMethod:     "/something/stream",
+	IsServerStream: true,
+}
+
+func TestUnaryRequestDoesNotMutateTags(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	intercept := UnaryServerInterceptor(measures)
+	ctx := contextWithTags(map[string]interface{}{"peer": "tester"})
+	var handler grpc.UnaryHandler = func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "nope")
+	}
+	intercept(ctx, "some data", uInfo, handler)
+
+	report := measures.Reset()
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.blah.received", map[string]interface{}{"peer": "tester"})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.blah.result", map[string]interface{}{"peer": "tester", "status": "NotFound"})])
+	assert.False(t, ctxtags.Extract(ctx).Has("status"), "status should not leak into the request tags")
+}
+
+func TestStreamRequest(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	intercept := StreamServerInterceptor(measures, WithMessageSizes())
+	ss := &fakeServerStream{
+		ctx:      context.Background(),
+		incoming: []interface{}{"a", "b"},
+	}
+	var handler grpc.StreamHandler = func(srv interface{}, stream grpc.ServerStream) error {
+		for {
+			if err := stream.RecvMsg(&wrappers.StringValue{}); err == io.EOF {
+				break
+			}
+		}
+		for i := 0; i < 3; i++ {
+			stream.SendMsg(&wrappers.StringValue{Value: "hello"})
+		}
+		return nil
+	}
+	err := intercept(srv, ss, sInfo, handler)
+	require.NoError(t, err)
+
+	report := measures.Reset()
+	assert.EqualValues(t, 1, report.Counts["something.stream.received"])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.stream.result", map[string]interface{}{"status": "OK"})])
+	assert.Contains(t, report.Aggregators, "something.stream.time_ms")
+
+	sent := report.Aggregators["something.stream.messages_sent"]
+	assert.EqualValues(t, 1, sent.Count)
+	assert.EqualValues(t, 3, sent.Sum)
+
+	received := report.Aggregators["something.stream.messages_received"]
+	assert.EqualValues(t, 1, received.Count)
+	assert.EqualValues(t, 2, received.Sum, "io.EOF should not be counted as a received message")
+
+	sentBytes := report.Aggregators["something.stream.sent_bytes"]
+	assert.EqualValues(t, 3, sentBytes.Count)
+	assert.EqualValues(t, 7, sentBytes.Max)
+}
+
+func TestStreamRequestWithCtxTags(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	intercept := StreamServerInterceptor(measures)
+	tags := map[string]interface{}{"peer": "tester"}
+	ss := &fakeServerStream{ctx: contextWithTags(tags)}
+	var handler grpc.StreamHandler = func(srv interface{}, stream grpc.ServerStream) error {
+		return status.Error(codes.Internal, "boom")
+	}
+	intercept(srv, ss, sInfo, handler)
+
+	report := measures.Reset()
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.stream.received", tags)])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.stream.result", map[string]interface{}{"peer": "tester", "status": "Internal"})])
+	assert.NotContains(t, report.Aggregators, "something.stream.sent_bytes")
+	assert.False(t, ctxtags.Extract(ss.ctx).Has("status"), "status should not leak into the stream tags")
+}
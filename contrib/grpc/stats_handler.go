@@ -0,0 +1,131 @@
+package interceptors
+
+import (
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+type rpcStatsCtxMarker struct{}
+
+var rpcStatsCtxMarkerKey = &rpcStatsCtxMarker{}
+
+// StatsHandler implements gRPC's stats.Handler, submitting AO metrics using the given MeasurementSet. Unlike
+// the interceptors it sees wire-level events, so it can be used on servers (grpc.StatsHandler) and clients
+// (grpc.WithStatsHandler) to record:
+//
+//   - <service>.<method>.in_bytes and out_bytes: wire size of each payload received and sent
+//   - <service>.<method>.time_to_first_byte_ms: time from the start of the RPC until the first response
+//     payload is received (clients) or sent (servers)
+//   - <service>.<method>.time_ms: RPC latency
+//   - <service>.<method>.result: count of completed RPCs, tagged by status code
+//   - grpc.conn.opened and grpc.conn.closed: counts of connections opened and closed
+//
+// All metrics are tagged with role, either "client" or "server".
+type StatsHandler struct {
+	m *appoptics.MeasurementSet
+}
+
+// NewStatsHandler returns a StatsHandler recording into the given MeasurementSet
+func NewStatsHandler(m *appoptics.MeasurementSet) *StatsHandler {
+	return &StatsHandler{m: m}
+}
+
+// rpcState tracks a single RPC between calls to HandleRPC
+type rpcState struct {
+	service   string
+	method    string
+	mu        sync.Mutex
+	beginTime time.Time
+	firstByte bool
+}
+
+// TagRPC attaches per-RPC state to the context
+func (h *StatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	state := &rpcState{
+		service: path.Dir(info.FullMethodName)[1:],
+		method:  path.Base(info.FullMethodName),
+	}
+	return context.WithValue(ctx, rpcStatsCtxMarkerKey, state)
+}
+
+// HandleRPC records the stats for a single RPC event
+func (h *StatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	state, ok := ctx.Value(rpcStatsCtxMarkerKey).(*rpcState)
+	if !ok {
+		return
+	}
+	tags := roleTags(s.IsClient())
+
+	switch s := s.(type) {
+	case *stats.Begin:
+		state.mu.Lock()
+		state.beginTime = s.BeginTime
+		state.mu.Unlock()
+	case *stats.InPayload:
+		h.m.UpdateAggregatorValue(state.key("in_bytes", tags), float64(s.WireLength))
+		if s.Client {
+			h.firstByte(state, s.RecvTime, tags)
+		}
+	case *stats.OutPayload:
+		h.m.UpdateAggregatorValue(state.key("out_bytes", tags), float64(s.WireLength))
+		if !s.Client {
+			h.firstByte(state, s.SentTime, tags)
+		}
+	case *stats.End:
+		h.m.UpdateAggregatorValue(state.key("time_ms", tags), milliseconds(s.EndTime.Sub(s.BeginTime)))
+		tags["status"] = status.Code(s.Error).String()
+		h.m.Incr(state.key("result", tags))
+	}
+}
+
+// firstByte records the time to first byte the first time it is called for an RPC
+func (h *StatsHandler) firstByte(state *rpcState, t time.Time, tags map[string]interface{}) {
+	state.mu.Lock()
+	if state.firstByte || state.beginTime.IsZero() {
+		state.mu.Unlock()
+		return
+	}
+	state.firstByte = true
+	elapsed := t.Sub(state.beginTime)
+	state.mu.Unlock()
+
+	h.m.UpdateAggregatorValue(state.key("time_to_first_byte_ms", tags), milliseconds(elapsed))
+}
+
+// TagConn returns the context unchanged; connections are counted but not individually tracked
+func (h *StatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn counts connections opened and closed
+func (h *StatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {
+	tags := roleTags(s.IsClient())
+	switch s.(type) {
+	case *stats.ConnBegin:
+		h.m.Incr(appoptics.MetricWithTags("grpc.conn.opened", tags))
+	case *stats.ConnEnd:
+		h.m.Incr(appoptics.MetricWithTags("grpc.conn.closed", tags))
+	}
+}
+
+func (s *rpcState) key(key string, tags map[string]interface{}) string {
+	return appoptics.MetricWithTags(fmt.Sprintf("%s.%s.%s", s.service, s.method, key), tags)
+}
+
+func roleTags(client bool) map[string]interface{} {
+	if client {
+		return map[string]interface{}{"role": "client"}
+	}
+	return map[string]interface{}{"role": "server"}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,83 @@
+package interceptors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatsHandler_Client(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	h := NewStatsHandler(measures)
+	begin := time.Now()
+
+	ctx := h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/something/blah"})
+	h.HandleRPC(ctx, &stats.Begin{Client: true, BeginTime: begin})
+	h.HandleRPC(ctx, &stats.OutPayload{Client: true, WireLength: 12, SentTime: begin.Add(time.Millisecond)})
+	h.HandleRPC(ctx, &stats.InPayload{Client: true, WireLength: 30, RecvTime: begin.Add(5 * time.Millisecond)})
+	h.HandleRPC(ctx, &stats.InPayload{Client: true, WireLength: 40, RecvTime: begin.Add(9 * time.Millisecond)})
+	h.HandleRPC(ctx, &stats.End{Client: true, BeginTime: begin, EndTime: begin.Add(10 * time.Millisecond)})
+
+	client := map[string]interface{}{"role": "client"}
+	report := measures.Reset()
+
+	inBytes := report.Aggregators[appoptics.MetricWithTags("something.blah.in_bytes", client)]
+	assert.EqualValues(t, 2, inBytes.Count)
+	assert.EqualValues(t, 70, inBytes.Sum)
+
+	outBytes := report.Aggregators[appoptics.MetricWithTags("something.blah.out_bytes", client)]
+	assert.EqualValues(t, 12, outBytes.Sum)
+
+	ttfb := report.Aggregators[appoptics.MetricWithTags("something.blah.time_to_first_byte_ms", client)]
+	assert.EqualValues(t, 1, ttfb.Count)
+	assert.EqualValues(t, 5, ttfb.Sum)
+
+	latency := report.Aggregators[appoptics.MetricWithTags("something.blah.time_ms", client)]
+	assert.EqualValues(t, 10, latency.Sum)
+
+	result := map[string]interface{}{"role": "client", "status": "OK"}
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.blah.result", result)])
+}
+
+func TestStatsHandler_Server(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	h := NewStatsHandler(measures)
+	begin := time.Now()
+
+	ctx := h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/something/blah"})
+	h.HandleRPC(ctx, &stats.Begin{BeginTime: begin})
+	h.HandleRPC(ctx, &stats.InPayload{WireLength: 12, RecvTime: begin.Add(time.Millisecond)})
+	h.HandleRPC(ctx, &stats.OutPayload{WireLength: 30, SentTime: begin.Add(3 * time.Millisecond)})
+	h.HandleRPC(ctx, &stats.End{BeginTime: begin, EndTime: begin.Add(4 * time.Millisecond), Error: status.Error(codes.Unavailable, "")})
+
+	server := map[string]interface{}{"role": "server"}
+	report := measures.Reset()
+
+	ttfb := report.Aggregators[appoptics.MetricWithTags("something.blah.time_to_first_byte_ms", server)]
+	assert.EqualValues(t, 3, ttfb.Sum)
+
+	result := map[string]interface{}{"role": "server", "status": "Unavailable"}
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("something.blah.result", result)])
+}
+
+func TestStatsHandler_Conn(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	h := NewStatsHandler(measures)
+
+	ctx := h.TagConn(context.Background(), &stats.ConnTagInfo{})
+	h.HandleConn(ctx, &stats.ConnBegin{})
+	h.HandleConn(ctx, &stats.ConnBegin{})
+	h.HandleConn(ctx, &stats.ConnEnd{})
+	h.HandleConn(ctx, &stats.ConnBegin{Client: true})
+
+	report := measures.Reset()
+	assert.EqualValues(t, 2, report.Counts[appoptics.MetricWithTags("grpc.conn.opened", map[string]interface{}{"role": "server"})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("grpc.conn.closed", map[string]interface{}{"role": "server"})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("grpc.conn.opened", map[string]interface{}{"role": "client"})])
+}
@@ -0,0 +1,305 @@
+// Package otelmetrics provides an OpenTelemetry SDK metric Exporter which persists metrics to AppOptics
+// through a MeasurementsCommunicator.
+package otelmetrics
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var (
+	regexpIllegalNameChars     = regexp.MustCompile("[^A-Za-z0-9.:_-]")
+	regexpIllegalTagValueChars = regexp.MustCompile(`[^-.:_\\/\w ?]`)
+)
+
+// Option configures an Exporter
+type Option func(*Exporter)
+
+// WithPrefix sets a prefix prepended to all metric names
+func WithPrefix(prefix string) Option {
+	return func(e *Exporter) {
+		e.prefix = prefix
+	}
+}
+
+// WithTemporalitySelector sets the temporality requested from the SDK for each instrument kind. The
+// default is DeltaTemporalitySelector.
+func WithTemporalitySelector(selector sdkmetric.TemporalitySelector) Option {
+	return func(e *Exporter) {
+		e.temporality = selector
+	}
+}
+
+// WithAggregationSelector sets the aggregation requested from the SDK for each instrument kind. The default
+// is sdkmetric.DefaultAggregationSelector.
+func WithAggregationSelector(selector sdkmetric.AggregationSelector) Option {
+	return func(e *Exporter) {
+		e.aggregation = selector
+	}
+}
+
+// WithResourceAttributes adds the named resource attributes, such as service.name, as tags on every
+// Measurement
+func WithResourceAttributes(keys ...attribute.Key) Option {
+	return func(e *Exporter) {
+		e.resourceKeys = append(e.resourceKeys, keys...)
+	}
+}
+
+// DeltaTemporalitySelector requests delta temporality for counters and histograms, which map directly onto
+// AppOptics' per-period measurements, and cumulative temporality for up-down counters, whose current level
+// is reported as a gauge.
+func DeltaTemporalitySelector(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+		return metricdata.CumulativeTemporality
+	}
+	return metricdata.DeltaTemporality
+}
+
+// Exporter implements sdkmetric.Exporter. Data points are converted to Measurements, with attributes
+// becoming tags:
+//
+//   - Gauges and non-monotonic Sums report their value
+//   - monotonic Sums report the increase over the collection period
+//   - Histograms report count, sum and, for delta temporality, min and max as summary fields
+//
+// Cumulative monotonic Sums and cumulative Histograms are converted to deltas by remembering the previous
+// point of each series; the first point of a series only establishes the baseline.
+type Exporter struct {
+	comm         appoptics.MeasurementsCommunicator
+	prefix       string
+	temporality  sdkmetric.TemporalitySelector
+	aggregation  sdkmetric.AggregationSelector
+	resourceKeys []attribute.Key
+
+	mu       sync.Mutex
+	last     map[string]cumulativePoint
+	shutdown bool
+}
+
+// cumulativePoint is the previous state of a cumulative series
+type cumulativePoint struct {
+	start time.Time
+	value float64
+	count uint64
+}
+
+// NewExporter returns an Exporter persisting through comm, typically Client.MeasurementsService()
+func NewExporter(comm appoptics.MeasurementsCommunicator, opts ...Option) *Exporter {
+	e := &Exporter{
+		comm:        comm,
+		temporality: DeltaTemporalitySelector,
+		aggregation: sdkmetric.DefaultAggregationSelector,
+		last:        map[string]cumulativePoint{},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Temporality implements sdkmetric.Exporter
+func (e *Exporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return e.temporality(kind)
+}
+
+// Aggregation implements sdkmetric.Exporter
+func (e *Exporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return e.aggregation(kind)
+}
+
+// Export converts rm into Measurements and persists them in batches no larger than
+// appoptics.MeasurementPostMaxBatchSize
+func (e *Exporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	if e.shutdown {
+		e.mu.Unlock()
+		return sdkmetric.ErrExporterShutdown
+	}
+	measurements := e.convert(rm)
+	e.mu.Unlock()
+
+	batchTime := time.Now().Unix()
+	for len(measurements) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := len(measurements)
+		if n > appoptics.MeasurementPostMaxBatchSize {
+			n = appoptics.MeasurementPostMaxBatchSize
+		}
+		batch := &appoptics.MeasurementsBatch{
+			Measurements: measurements[:n],
+			Time:         batchTime,
+		}
+		if _, err := e.comm.Create(batch); err != nil {
+			return err
+		}
+		measurements = measurements[n:]
+	}
+	return nil
+}
+
+// ForceFlush implements sdkmetric.Exporter. The Exporter holds no data between exports.
+func (e *Exporter) ForceFlush(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Shutdown implements sdkmetric.Exporter. Subsequent calls to Export return sdkmetric.ErrExporterShutdown.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	e.shutdown = true
+	e.mu.Unlock()
+	return ctx.Err()
+}
+
+func (e *Exporter) convert(rm *metricdata.ResourceMetrics) []appoptics.Measurement {
+	resourceTags := map[string]string{}
+	if rm.Resource != nil {
+		for _, key := range e.resourceKeys {
+			if v, ok := rm.Resource.Set().Value(key); ok {
+				resourceTags[string(key)] = v.Emit()
+			}
+		}
+	}
+
+	measurements := []appoptics.Measurement{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			name := e.prefix + regexpIllegalNameChars.ReplaceAllString(m.Name, "_")
+			switch data := m.Data.(type) {
+			case metricdata.Gauge[int64]:
+				measurements = append(measurements, convertGauge(name, data.DataPoints, resourceTags)...)
+			case metricdata.Gauge[float64]:
+				measurements = append(measurements, convertGauge(name, data.DataPoints, resourceTags)...)
+			case metricdata.Sum[int64]:
+				measurements = append(measurements, convertSum(e, name, data.DataPoints, data.Temporality, data.IsMonotonic, resourceTags)...)
+			case metricdata.Sum[float64]:
+				measurements = append(measurements, convertSum(e, name, data.DataPoints, data.Temporality, data.IsMonotonic, resourceTags)...)
+			case metricdata.Histogram[int64]:
+				measurements = append(measurements, convertHistogram(e, name, data.DataPoints, data.Temporality, resourceTags)...)
+			case metricdata.Histogram[float64]:
+				measurements = append(measurements, convertHistogram(e, name, data.DataPoints, data.Temporality, resourceTags)...)
+			}
+		}
+	}
+	return measurements
+}
+
+func convertGauge[N int64 | float64](name string, points []metricdata.DataPoint[N], resourceTags map[string]string) []appoptics.Measurement {
+	measurements := make([]appoptics.Measurement, 0, len(points))
+	for _, p := range points {
+		measurements = append(measurements, appoptics.Measurement{
+			Name:  name,
+			Tags:  attributesToTags(p.Attributes, resourceTags),
+			Value: float64(p.Value),
+			Time:  p.Time.Unix(),
+		})
+	}
+	return measurements
+}
+
+func convertSum[N int64 | float64](e *Exporter, name string, points []metricdata.DataPoint[N], temporality metricdata.Temporality, monotonic bool, resourceTags map[string]string) []appoptics.Measurement {
+	measurements := make([]appoptics.Measurement, 0, len(points))
+	for _, p := range points {
+		tags := attributesToTags(p.Attributes, resourceTags)
+		value := float64(p.Value)
+		if monotonic && temporality == metricdata.CumulativeTemporality {
+			last, ok := e.swap(name, p.Attributes, cumulativePoint{start: p.StartTime, value: value})
+			if !ok {
+				continue
+			}
+			// a monotonic sum only goes down when the series was reset without a new start time
+			if value >= last.value {
+				value -= last.value
+			}
+		}
+		measurements = append(measurements, appoptics.Measurement{
+			Name:  name,
+			Tags:  tags,
+			Value: value,
+			Time:  p.Time.Unix(),
+		})
+	}
+	return measurements
+}
+
+func convertHistogram[N int64 | float64](e *Exporter, name string, points []metricdata.HistogramDataPoint[N], temporality metricdata.Temporality, resourceTags map[string]string) []appoptics.Measurement {
+	measurements := make([]appoptics.Measurement, 0, len(points))
+	for _, p := range points {
+		count, sum := p.Count, float64(p.Sum)
+		if temporality == metricdata.CumulativeTemporality {
+			last, ok := e.swap(name, p.Attributes, cumulativePoint{start: p.StartTime, value: sum, count: count})
+			if !ok {
+				continue
+			}
+			count -= last.count
+			sum -= last.value
+		}
+		if count == 0 {
+			continue
+		}
+
+		m := appoptics.Measurement{
+			Name:  name,
+			Tags:  attributesToTags(p.Attributes, resourceTags),
+			Count: int64(count),
+			Sum:   sum,
+			Time:  p.Time.Unix(),
+		}
+		// extrema of cumulative histograms cover the lifetime of the series rather than the period
+		if temporality == metricdata.DeltaTemporality {
+			if min, ok := p.Min.Value(); ok {
+				m.Min = float64(min)
+			}
+			if max, ok := p.Max.Value(); ok {
+				m.Max = float64(max)
+			}
+		}
+		measurements = append(measurements, m)
+	}
+	return measurements
+}
+
+// swap records the latest point of a cumulative series, returning the previous point. ok is false when the
+// series is new, in which case there is no delta to report. When the series has restarted, as indicated by a
+// new start time or a count lower than the last one, the zero point is returned so the whole of the new value
+// is reported.
+func (e *Exporter) swap(name string, attrs attribute.Set, point cumulativePoint) (cumulativePoint, bool) {
+	key := name + "\x00" + string(attrs.Encoded(attribute.DefaultEncoder()))
+	last, ok := e.last[key]
+	e.last[key] = point
+	if ok && (!last.start.Equal(point.start) || point.count < last.count) {
+		return cumulativePoint{}, true
+	}
+	return last, ok
+}
+
+// attributesToTags converts attributes to AppOptics tags, replacing characters AppOptics does not allow
+func attributesToTags(attrs attribute.Set, resourceTags map[string]string) map[string]string {
+	if attrs.Len() == 0 && len(resourceTags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, attrs.Len()+len(resourceTags))
+	for k, v := range resourceTags {
+		tags[regexpIllegalNameChars.ReplaceAllString(k, "_")] = regexpIllegalTagValueChars.ReplaceAllString(v, "_")
+	}
+	iter := attrs.Iter()
+	for iter.Next() {
+		kv := iter.Attribute()
+		value := kv.Value.Emit()
+		if value == "" {
+			continue
+		}
+		tags[regexpIllegalNameChars.ReplaceAllString(string(kv.Key), "_")] = regexpIllegalTagValueChars.ReplaceAllString(value, "_")
+	}
+	return tags
+}
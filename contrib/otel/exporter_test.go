@@ -0,0 +1,226 @@
+package otelmetrics
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// fakeEndpoint records the MeasurementsBatches POSTed to the AppOptics measurements API
+type fakeEndpoint struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches []*appoptics.MeasurementsBatch
+}
+
+func newFakeEndpoint(t *testing.T) *fakeEndpoint {
+	f := &fakeEndpoint{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/measurements", r.URL.Path)
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+
+		body, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		batch := &appoptics.MeasurementsBatch{}
+		require.NoError(t, json.NewDecoder(body).Decode(batch))
+
+		f.mu.Lock()
+		f.batches = append(f.batches, batch)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	return f
+}
+
+// measurements returns the measurements received since the last call, keyed by name and tags
+func (f *fakeEndpoint) measurements() map[string]appoptics.Measurement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	received := map[string]appoptics.Measurement{}
+	for _, batch := range f.batches {
+		for _, m := range batch.Measurements {
+			received[measurementKey(m.Name, m.Tags)] = m
+		}
+	}
+	f.batches = nil
+	return received
+}
+
+func measurementKey(name string, tags map[string]string) string {
+	tagsMap := map[string]interface{}{}
+	for k, v := range tags {
+		tagsMap[k] = v
+	}
+	return appoptics.MetricWithTags(name, tagsMap)
+}
+
+func newProvider(t *testing.T, opts ...Option) (*fakeEndpoint, *sdkmetric.MeterProvider, *sdkmetric.ManualReader, *Exporter) {
+	endpoint := newFakeEndpoint(t)
+	client := appoptics.NewClient("deadbeef", appoptics.BaseURLClientOption(fmt.Sprintf("%s/v1/", endpoint.URL)))
+	exporter := NewExporter(client.MeasurementsService(), opts...)
+	reader := sdkmetric.NewManualReader(
+		sdkmetric.WithTemporalitySelector(exporter.Temporality),
+		sdkmetric.WithAggregationSelector(exporter.Aggregation),
+	)
+	res := resource.NewSchemaless(attribute.String("service.name", "checkout"))
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader), sdkmetric.WithResource(res))
+	return endpoint, provider, reader, exporter
+}
+
+// export collects from reader and exports the result, as a PeriodicReader would
+func export(t *testing.T, reader *sdkmetric.ManualReader, exporter *Exporter) {
+	rm := &metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), rm))
+	require.NoError(t, exporter.Export(context.Background(), rm))
+}
+
+func TestExporter_Delta(t *testing.T) {
+	endpoint, provider, reader, exporter := newProvider(t, WithPrefix("shop."), WithResourceAttributes("service.name"))
+	defer endpoint.Close()
+	meter := provider.Meter("test")
+
+	requests, _ := meter.Int64Counter("http.requests")
+	latency, _ := meter.Float64Histogram("http.latency", metric.WithExplicitBucketBoundaries(10, 100))
+	queue, _ := meter.Int64UpDownCounter("queue.depth")
+	ctx := context.Background()
+	ok := metric.WithAttributes(attribute.String("code", "200"))
+
+	requests.Add(ctx, 3, ok)
+	latency.Record(ctx, 5, ok)
+	latency.Record(ctx, 50, ok)
+	queue.Add(ctx, 7)
+	export(t, reader, exporter)
+
+	received := endpoint.measurements()
+	okTags := map[string]string{"code": "200", "service.name": "checkout"}
+	serviceTags := map[string]string{"service.name": "checkout"}
+	require.Len(t, received, 3)
+	assert.EqualValues(t, 3, received[measurementKey("shop.http.requests", okTags)].Value)
+	assert.EqualValues(t, 7, received[measurementKey("shop.queue.depth", serviceTags)].Value)
+
+	hist := received[measurementKey("shop.http.latency", okTags)]
+	assert.EqualValues(t, 2, hist.Count)
+	assert.EqualValues(t, 55, hist.Sum)
+	assert.EqualValues(t, 5, hist.Min)
+	assert.EqualValues(t, 50, hist.Max)
+
+	requests.Add(ctx, 2, ok)
+	queue.Add(ctx, -2)
+	export(t, reader, exporter)
+
+	received = endpoint.measurements()
+	assert.EqualValues(t, 2, received[measurementKey("shop.http.requests", okTags)].Value, "delta sums report the period's increase")
+	assert.EqualValues(t, 5, received[measurementKey("shop.queue.depth", serviceTags)].Value, "up-down counters report their level")
+	assert.NotContains(t, received, measurementKey("shop.http.latency", okTags), "empty histograms are skipped")
+}
+
+func TestExporter_Cumulative(t *testing.T) {
+	cumulative := func(sdkmetric.InstrumentKind) metricdata.Temporality {
+		return metricdata.CumulativeTemporality
+	}
+	endpoint, provider, reader, exporter := newProvider(t, WithTemporalitySelector(cumulative))
+	defer endpoint.Close()
+	meter := provider.Meter("test")
+
+	requests, _ := meter.Float64Counter("requests")
+	latency, _ := meter.Int64Histogram("latency")
+	meter.Float64ObservableGauge("temperature", metric.WithFloat64Callback(func(_ context.Context, o metric.Float64Observer) error {
+		o.Observe(21.5, metric.WithAttributes(attribute.String("room", "lab")))
+		return nil
+	}))
+	ctx := context.Background()
+
+	requests.Add(ctx, 10)
+	latency.Record(ctx, 4)
+	export(t, reader, exporter)
+
+	received := endpoint.measurements()
+	require.Len(t, received, 1, "cumulative series only establish a baseline on the first export")
+	assert.EqualValues(t, 21.5, received[measurementKey("temperature", map[string]string{"room": "lab"})].Value)
+
+	requests.Add(ctx, 4)
+	latency.Record(ctx, 6)
+	latency.Record(ctx, 8)
+	export(t, reader, exporter)
+
+	received = endpoint.measurements()
+	assert.EqualValues(t, 4, received[measurementKey("requests", nil)].Value)
+
+	hist := received[measurementKey("latency", nil)]
+	assert.EqualValues(t, 2, hist.Count)
+	assert.EqualValues(t, 14, hist.Sum)
+	assert.Nil(t, hist.Min, "cumulative extrema are not reported")
+}
+
+func TestExporter_CumulativeReset(t *testing.T) {
+	e := NewExporter(nil)
+	start := time.Unix(1700000000, 0)
+	histogram := func(count uint64, sum int64) []metricdata.HistogramDataPoint[int64] {
+		return []metricdata.HistogramDataPoint[int64]{{StartTime: start, Time: start.Add(time.Minute), Count: count, Sum: sum}}
+	}
+	sum := func(value float64) []metricdata.DataPoint[float64] {
+		return []metricdata.DataPoint[float64]{{StartTime: start, Time: start.Add(time.Minute), Value: value}}
+	}
+
+	assert.Empty(t, convertHistogram(e, "latency", histogram(5, 50), metricdata.CumulativeTemporality, nil))
+	assert.Empty(t, convertSum(e, "requests", sum(10), metricdata.CumulativeTemporality, true, nil))
+
+	// both series went back down without a new start time, so their whole values are reported
+	measurements := convertHistogram(e, "latency", histogram(2, 12), metricdata.CumulativeTemporality, nil)
+	require.Len(t, measurements, 1)
+	assert.EqualValues(t, 2, measurements[0].Count)
+	assert.EqualValues(t, 12, measurements[0].Sum)
+
+	measurements = convertSum(e, "requests", sum(3), metricdata.CumulativeTemporality, true, nil)
+	require.Len(t, measurements, 1)
+	assert.EqualValues(t, 3, measurements[0].Value)
+}
+
+func TestExporter_SwapKeys(t *testing.T) {
+	e := NewExporter(nil)
+	_, ok := e.swap("x", attribute.NewSet(attribute.String("a", "1")), cumulativePoint{value: 1})
+	assert.False(t, ok)
+	_, ok = e.swap("xa=1", *attribute.EmptySet(), cumulativePoint{value: 2})
+	assert.False(t, ok, "series are keyed apart even when name and attributes concatenate alike")
+}
+
+func TestExporter_Shutdown(t *testing.T) {
+	endpoint, _, _, exporter := newProvider(t)
+	defer endpoint.Close()
+
+	require.NoError(t, exporter.ForceFlush(context.Background()))
+	require.NoError(t, exporter.Shutdown(context.Background()))
+	err := exporter.Export(context.Background(), &metricdata.ResourceMetrics{})
+	assert.Equal(t, sdkmetric.ErrExporterShutdown, err)
+}
+
+func TestExporter_PeriodicReader(t *testing.T) {
+	endpoint := newFakeEndpoint(t)
+	defer endpoint.Close()
+	client := appoptics.NewClient("deadbeef", appoptics.BaseURLClientOption(fmt.Sprintf("%s/v1/", endpoint.URL)))
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(NewExporter(client.MeasurementsService()))))
+
+	counter, _ := provider.Meter("test").Int64Counter("jobs")
+	counter.Add(context.Background(), 1)
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	received := endpoint.measurements()
+	assert.EqualValues(t, 1, received[measurementKey("jobs", nil)].Value)
+}
@@ -0,0 +1,219 @@
+package prommetrics
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+const maxTagValueLength = 255
+
+var (
+	regexpIllegalNameChars     = regexp.MustCompile("[^A-Za-z0-9.:_-]")
+	regexpIllegalTagValueChars = regexp.MustCompile(`[^-.:_\\/\w ?]`)
+)
+
+// Converter turns parsed MetricFamilies into Measurements. Prometheus counters, and the _sum, _count and
+// _bucket series of histograms and summaries, are cumulative; Converter remembers the previous value of each
+// series so it can report the change between successive calls to Convert. A series seen for the first time
+// only establishes the baseline, and a value lower than the previous one is treated as a counter reset.
+//
+// Families are converted as follows, with labels becoming tags:
+//
+//   - counter: <sample name> with the delta as the value
+//   - gauge and untyped: <sample name> with the current value
+//   - histogram: <name> with the count and sum deltas as summary fields, and <name>.bucket tagged with le
+//     with the delta of each bucket
+//   - summary: <name> with the count and sum deltas as summary fields, and <name>.quantile tagged with
+//     quantile with the current value of each quantile
+//
+// NaN and infinite values are skipped.
+type Converter struct {
+	mu   sync.Mutex
+	last map[string]float64
+}
+
+// NewConverter returns a Converter with no previous values
+func NewConverter() *Converter {
+	return &Converter{last: map[string]float64{}}
+}
+
+// Convert returns the Measurements for families, merging extraTags into the tags of each one
+func (c *Converter) Convert(families []*MetricFamily, extraTags map[string]string) []appoptics.Measurement {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	measurements := []appoptics.Measurement{}
+	for _, f := range families {
+		switch f.Type {
+		case Counter:
+			measurements = append(measurements, c.convertCounter(f, extraTags)...)
+		case Histogram, Summary:
+			measurements = append(measurements, c.convertDistribution(f, extraTags)...)
+		default:
+			for _, s := range f.Samples {
+				if !isFinite(s.Value) {
+					continue
+				}
+				measurements = append(measurements, newMeasurement(s.Name, s.Labels, extraTags, s.Timestamp, s.Value))
+			}
+		}
+	}
+	return measurements
+}
+
+func (c *Converter) convertCounter(f *MetricFamily, extraTags map[string]string) []appoptics.Measurement {
+	measurements := []appoptics.Measurement{}
+	for _, s := range f.Samples {
+		if strings.HasSuffix(s.Name, "_created") {
+			continue
+		}
+		delta, ok := c.delta(s.Name, s.Labels, s.Value)
+		if !ok {
+			continue
+		}
+		measurements = append(measurements, newMeasurement(s.Name, s.Labels, extraTags, s.Timestamp, delta))
+	}
+	return measurements
+}
+
+// distribution accumulates the _sum and _count of one label set of a histogram or summary
+type distribution struct {
+	labels    map[string]string
+	timestamp int64
+	sum       float64
+	count     float64
+	hasSum    bool
+	hasCount  bool
+}
+
+func (c *Converter) convertDistribution(f *MetricFamily, extraTags map[string]string) []appoptics.Measurement {
+	var (
+		measurements  = []appoptics.Measurement{}
+		distributions = map[string]*distribution{}
+		order         []string
+	)
+
+	for _, s := range f.Samples {
+		switch {
+		case s.Name == f.Name+"_bucket":
+			delta, ok := c.delta(s.Name, s.Labels, s.Value)
+			if !ok {
+				continue
+			}
+			measurements = append(measurements, newMeasurement(f.Name+".bucket", bucketLabels(s.Labels), extraTags, s.Timestamp, delta))
+		case s.Name == f.Name && f.Type == Summary:
+			if !isFinite(s.Value) {
+				continue
+			}
+			measurements = append(measurements, newMeasurement(f.Name+".quantile", s.Labels, extraTags, s.Timestamp, s.Value))
+		case s.Name == f.Name+"_sum" || s.Name == f.Name+"_count":
+			key := seriesKey("", s.Labels)
+			d, ok := distributions[key]
+			if !ok {
+				d = &distribution{labels: s.Labels, timestamp: s.Timestamp}
+				distributions[key] = d
+				order = append(order, key)
+			}
+			delta, ok := c.delta(s.Name, s.Labels, s.Value)
+			if strings.HasSuffix(s.Name, "_sum") {
+				d.sum, d.hasSum = delta, ok
+			} else {
+				d.count, d.hasCount = delta, ok
+			}
+		}
+	}
+
+	for _, key := range order {
+		d := distributions[key]
+		if !d.hasSum || !d.hasCount || d.count <= 0 {
+			continue
+		}
+		m := newMeasurement(f.Name, d.labels, extraTags, d.timestamp, nil)
+		m.Count = int64(d.count)
+		m.Sum = d.sum
+		measurements = append(measurements, m)
+	}
+	return measurements
+}
+
+// delta records value as the latest for the series and returns the change since the previous value
+func (c *Converter) delta(name string, labels map[string]string, value float64) (float64, bool) {
+	if !isFinite(value) {
+		return 0, false
+	}
+	key := seriesKey(name, labels)
+	last, seen := c.last[key]
+	c.last[key] = value
+	if !seen {
+		return 0, false
+	}
+	if value < last {
+		return value, true
+	}
+	return value - last, true
+}
+
+// bucketLabels renames the +Inf bucket, as "+" is not allowed in tag values
+func bucketLabels(labels map[string]string) map[string]string {
+	if labels["le"] != "+Inf" {
+		return labels
+	}
+	renamed := make(map[string]string, len(labels))
+	for k, v := range labels {
+		renamed[k] = v
+	}
+	renamed["le"] = "Inf"
+	return renamed
+}
+
+func seriesKey(name string, labels map[string]string) string {
+	tags := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		tags[k] = v
+	}
+	return appoptics.MetricWithTags(name, tags)
+}
+
+func newMeasurement(name string, labels, extraTags map[string]string, timestampMs int64, value interface{}) appoptics.Measurement {
+	m := appoptics.Measurement{
+		Name:  regexpIllegalNameChars.ReplaceAllString(name, "_"),
+		Tags:  LabelsToTags(labels, extraTags),
+		Value: value,
+	}
+	if timestampMs > 0 {
+		m.Time = timestampMs / 1000
+	}
+	return m
+}
+
+// LabelsToTags converts Prometheus labels to AppOptics tags, replacing characters AppOptics does not allow
+// and dropping labels with empty values, which Prometheus treats as absent. extraTags are merged in, taking
+// precedence over labels of the same name.
+func LabelsToTags(labels, extraTags map[string]string) map[string]string {
+	if len(labels) == 0 && len(extraTags) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(labels)+len(extraTags))
+	for k, v := range labels {
+		if v == "" {
+			continue
+		}
+		v = regexpIllegalTagValueChars.ReplaceAllString(v, "_")
+		if len(v) > maxTagValueLength {
+			v = v[:maxTagValueLength]
+		}
+		tags[regexpIllegalNameChars.ReplaceAllString(k, "_")] = v
+	}
+	for k, v := range extraTags {
+		tags[k] = v
+	}
+	return tags
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,77 @@
+package prommetrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func convertText(t *testing.T, c *Converter, exposition string) map[string]appoptics.Measurement {
+	families, err := Parse(strings.NewReader(exposition))
+	require.NoError(t, err)
+
+	byKey := map[string]appoptics.Measurement{}
+	for _, m := range c.Convert(families, map[string]string{"host": "h1"}) {
+		tags := map[string]interface{}{}
+		for k, v := range m.Tags {
+			tags[k] = v
+		}
+		byKey[appoptics.MetricWithTags(m.Name, tags)] = m
+	}
+	return byKey
+}
+
+func key(name string, tags map[string]interface{}) string {
+	tags["host"] = "h1"
+	return appoptics.MetricWithTags(name, tags)
+}
+
+func TestConverter_Convert(t *testing.T) {
+	c := NewConverter()
+	first := convertText(t, c, textExposition)
+
+	// counters and distributions only establish a baseline on the first scrape
+	assert.Len(t, first, 3)
+	assert.Equal(t, 12.5, first[key("queue_depth", map[string]interface{}{"queue": `a _quoted_ \ name`})].Value)
+	assert.Equal(t, float64(4773), first[key("rpc_duration_seconds.quantile", map[string]interface{}{"quantile": "0.5"})].Value)
+	assert.Equal(t, float64(76656), first[key("rpc_duration_seconds.quantile", map[string]interface{}{"quantile": "0.99"})].Value)
+
+	next := strings.NewReplacer(
+		`code="200"} 1027`, `code="200"} 1030`,
+		`code="400"}    3`, `code="400"}    1`,
+		`le="+Inf"} 144320`, `le="+Inf"} 144330`,
+		"request_duration_seconds_sum 53423", "request_duration_seconds_sum 53433",
+		"request_duration_seconds_count 144320", "request_duration_seconds_count 144330",
+	).Replace(textExposition)
+	second := convertText(t, c, next)
+
+	ok := second[key("http_requests_total", map[string]interface{}{"method": "post", "code": "200"})]
+	assert.Equal(t, float64(3), ok.Value)
+	assert.Equal(t, int64(1395066363), ok.Time)
+
+	reset := second[key("http_requests_total", map[string]interface{}{"method": "post", "code": "400"})]
+	assert.Equal(t, float64(1), reset.Value, "a decrease is treated as a counter reset")
+
+	assert.Equal(t, float64(0), second[key("request_duration_seconds.bucket", map[string]interface{}{"le": "0.05"})].Value)
+	assert.Equal(t, float64(10), second[key("request_duration_seconds.bucket", map[string]interface{}{"le": "Inf"})].Value)
+
+	histogram := second[key("request_duration_seconds", map[string]interface{}{})]
+	assert.Nil(t, histogram.Value)
+	assert.Equal(t, int64(10), histogram.Count)
+	assert.Equal(t, float64(10), histogram.Sum)
+
+	assert.NotContains(t, second, key("rpc_duration_seconds", map[string]interface{}{}), "summaries without new observations are skipped")
+	assert.NotContains(t, second, key("untyped_thing", map[string]interface{}{}), "infinite values are skipped")
+}
+
+func TestLabelsToTags(t *testing.T) {
+	tags := LabelsToTags(
+		map[string]string{"path": "/api/{id}", "empty": "", "bad name": "x", "host": "label"},
+		map[string]string{"host": "extra"},
+	)
+	assert.Equal(t, map[string]string{"path": "/api/_id_", "bad_name": "x", "host": "extra"}, tags)
+	assert.Nil(t, LabelsToTags(nil, nil))
+}
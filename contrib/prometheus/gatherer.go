@@ -0,0 +1,205 @@
+package prommetrics
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	log "github.com/sirupsen/logrus"
+)
+
+// GathererReporterOption configures a GathererReporter
+type GathererReporterOption func(*GathererReporter)
+
+// WithBuckets reports each histogram bucket as a <name>.bucket series tagged with le, in addition to the
+// count and sum summary fields
+func WithBuckets() GathererReporterOption {
+	return func(r *GathererReporter) {
+		r.buckets = true
+	}
+}
+
+// WithGlobalTags sets tags applied to all Measurements
+func WithGlobalTags(tags map[string]string) GathererReporterOption {
+	return func(r *GathererReporter) {
+		r.tags = tags
+	}
+}
+
+// GathererReporter periodically gathers the metrics registered with a client_golang prometheus.Gatherer, such
+// as prometheus.DefaultGatherer, and persists them to AppOptics. Like appoptics.Reporter it flushes every
+// appoptics.ReportInterval, with batch times aligned by appoptics.ReportBatchTime. MetricFamilies are
+// converted as described by Converter; histogram buckets are folded into the count and sum summary fields
+// unless WithBuckets is set.
+type GathererReporter struct {
+	gatherer  prometheus.Gatherer
+	comm      appoptics.MeasurementsCommunicator
+	prefix    string
+	tags      map[string]string
+	buckets   bool
+	converter *Converter
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// NewGathererReporter returns a GathererReporter for g, prepending prefix to all metric names
+func NewGathererReporter(g prometheus.Gatherer, comm appoptics.MeasurementsCommunicator, prefix string, opts ...GathererReporterOption) *GathererReporter {
+	r := &GathererReporter{
+		gatherer:  g,
+		comm:      comm,
+		prefix:    prefix,
+		converter: NewConverter(),
+		stop:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start kicks off a goroutine that reports to AppOptics every appoptics.ReportInterval until Stop is called
+func (r *GathererReporter) Start() {
+	go r.reportForever()
+}
+
+// Stop ends reporting
+func (r *GathererReporter) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+// Measurements gathers and converts the registered metrics. Gathering errors are returned alongside whatever
+// metrics could be gathered, as with prometheus.Gatherer.
+func (r *GathererReporter) Measurements() ([]appoptics.Measurement, error) {
+	gathered, err := r.gatherer.Gather()
+	measurements := r.converter.Convert(FamiliesFromProto(gathered, r.buckets), r.tags)
+	if r.prefix != "" {
+		for i := range measurements {
+			measurements[i].Name = r.prefix + measurements[i].Name
+		}
+	}
+	return measurements, err
+}
+
+// Report gathers the registered metrics and persists them to AppOptics. Metrics are persisted even if
+// gathering partially failed, in which case the gathering error is returned.
+func (r *GathererReporter) Report() error {
+	measurements, gatherErr := r.Measurements()
+	period := int64(appoptics.ReportInterval / time.Second)
+	if err := persist(r.comm, measurements, appoptics.ReportBatchTime(time.Now()), period); err != nil {
+		return err
+	}
+	return gatherErr
+}
+
+func (r *GathererReporter) reportForever() {
+	// Sleep for a random duration in order to randomize the output cycle, as appoptics.Reporter does
+	select {
+	case <-time.After(time.Duration(rand.Int63n(int64(appoptics.ReportInterval)))):
+	case <-r.stop:
+		return
+	}
+
+	ticker := time.NewTicker(appoptics.ReportInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Report(); err != nil {
+			log.Error("Error reporting Prometheus metrics to AppOptics", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// FamiliesFromProto converts gathered MetricFamily protobufs into the MetricFamily model produced by Parse.
+// Histogram bucket samples are only included when buckets is true.
+func FamiliesFromProto(gathered []*dto.MetricFamily, buckets bool) []*MetricFamily {
+	families := make([]*MetricFamily, 0, len(gathered))
+	for _, pf := range gathered {
+		f := &MetricFamily{
+			Name: pf.GetName(),
+			Help: pf.GetHelp(),
+		}
+
+		for _, pm := range pf.GetMetric() {
+			labels := make(map[string]string, len(pm.GetLabel()))
+			for _, lp := range pm.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			sample := func(name string, extra map[string]string, value float64) Sample {
+				s := Sample{Name: name, Labels: labels, Value: value, Timestamp: pm.GetTimestampMs()}
+				if len(extra) > 0 {
+					s.Labels = make(map[string]string, len(labels)+len(extra))
+					for k, v := range labels {
+						s.Labels[k] = v
+					}
+					for k, v := range extra {
+						s.Labels[k] = v
+					}
+				}
+				return s
+			}
+
+			switch pf.GetType() {
+			case dto.MetricType_COUNTER:
+				f.Type = Counter
+				f.Samples = append(f.Samples, sample(f.Name, nil, pm.GetCounter().GetValue()))
+			case dto.MetricType_GAUGE:
+				f.Type = Gauge
+				f.Samples = append(f.Samples, sample(f.Name, nil, pm.GetGauge().GetValue()))
+			case dto.MetricType_UNTYPED:
+				f.Type = Untyped
+				f.Samples = append(f.Samples, sample(f.Name, nil, pm.GetUntyped().GetValue()))
+			case dto.MetricType_SUMMARY:
+				f.Type = Summary
+				s := pm.GetSummary()
+				for _, q := range s.GetQuantile() {
+					f.Samples = append(f.Samples, sample(f.Name, map[string]string{"quantile": formatFloat(q.GetQuantile())}, q.GetValue()))
+				}
+				f.Samples = append(f.Samples,
+					sample(f.Name+"_sum", nil, s.GetSampleSum()),
+					sample(f.Name+"_count", nil, float64(s.GetSampleCount())),
+				)
+			case dto.MetricType_HISTOGRAM:
+				f.Type = Histogram
+				h := pm.GetHistogram()
+				if buckets {
+					sawInf := false
+					for _, b := range h.GetBucket() {
+						sawInf = sawInf || math.IsInf(b.GetUpperBound(), 1)
+						f.Samples = append(f.Samples, sample(f.Name+"_bucket", map[string]string{"le": formatFloat(b.GetUpperBound())}, float64(b.GetCumulativeCount())))
+					}
+					if !sawInf {
+						f.Samples = append(f.Samples, sample(f.Name+"_bucket", map[string]string{"le": "+Inf"}, float64(h.GetSampleCount())))
+					}
+				}
+				f.Samples = append(f.Samples,
+					sample(f.Name+"_sum", nil, h.GetSampleSum()),
+					sample(f.Name+"_count", nil, float64(h.GetSampleCount())),
+				)
+			}
+		}
+
+		if f.Type != "" {
+			families = append(families, f)
+		}
+	}
+	return families
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
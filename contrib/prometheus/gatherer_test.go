@@ -0,0 +1,92 @@
+package prommetrics
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry() (*prometheus.Registry, *prometheus.CounterVec, prometheus.Histogram, prometheus.Gauge) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"code"})
+	latency := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{0.1, 1}})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "inflight"})
+	registry.MustRegister(requests, latency, inflight)
+	return registry, requests, latency, inflight
+}
+
+func TestGathererReporter_Measurements(t *testing.T) {
+	registry, requests, latency, inflight := newTestRegistry()
+	r := NewGathererReporter(registry, nil, "svc.", WithGlobalTags(map[string]string{"host": "h1"}))
+
+	requests.WithLabelValues("200").Add(3)
+	latency.Observe(0.05)
+	inflight.Set(4)
+
+	first, err := r.Measurements()
+	require.NoError(t, err)
+	require.Len(t, first, 1, "cumulative series only establish a baseline on the first gather")
+	assert.Equal(t, "svc.inflight", first[0].Name)
+	assert.Equal(t, float64(4), first[0].Value)
+	assert.Equal(t, map[string]string{"host": "h1"}, first[0].Tags)
+
+	requests.WithLabelValues("200").Add(2)
+	latency.Observe(0.5)
+	latency.Observe(1.5)
+
+	second, err := r.Measurements()
+	require.NoError(t, err)
+	byName := map[string]appoptics.Measurement{}
+	for _, m := range second {
+		byName[m.Name] = m
+	}
+
+	assert.Len(t, byName, 3)
+	assert.Equal(t, float64(2), byName["svc.requests_total"].Value)
+	assert.Equal(t, map[string]string{"code": "200", "host": "h1"}, byName["svc.requests_total"].Tags)
+	assert.Equal(t, int64(2), byName["svc.latency_seconds"].Count)
+	assert.InDelta(t, 2, byName["svc.latency_seconds"].Sum, 1e-9)
+	assert.NotContains(t, byName, "svc.latency_seconds.bucket")
+}
+
+func TestGathererReporter_Buckets(t *testing.T) {
+	registry, _, latency, _ := newTestRegistry()
+	r := NewGathererReporter(registry, nil, "", WithBuckets())
+
+	r.Measurements()
+	latency.Observe(0.5)
+	latency.Observe(1.5)
+	measurements, err := r.Measurements()
+	require.NoError(t, err)
+
+	buckets := map[string]interface{}{}
+	for _, m := range measurements {
+		if m.Name == "latency_seconds.bucket" {
+			buckets[m.Tags["le"]] = m.Value
+		}
+	}
+	assert.Equal(t, map[string]interface{}{"0.1": float64(0), "1": float64(1), "Inf": float64(2)}, buckets)
+}
+
+func TestGathererReporter_Report(t *testing.T) {
+	registry, _, _, inflight := newTestRegistry()
+	inflight.Set(1)
+
+	var batches []*appoptics.MeasurementsBatch
+	mms := &appoptics.MockMeasurementsService{
+		OnCreate: func(batch *appoptics.MeasurementsBatch) (*http.Response, error) {
+			batches = append(batches, batch)
+			return nil, nil
+		},
+	}
+
+	require.NoError(t, NewGathererReporter(registry, mms, "").Report())
+	require.Len(t, batches, 1)
+	assert.EqualValues(t, 15, batches[0].Period)
+	assert.Zero(t, batches[0].Time%15, "batch times are aligned like appoptics.Reporter")
+	assert.Equal(t, "inflight", batches[0].Measurements[0].Name)
+}
@@ -0,0 +1,293 @@
+// Package prommetrics bridges Prometheus instrumentation and AppOptics. It parses the Prometheus text and
+// OpenMetrics exposition formats, converts the parsed families into AppOptics Measurements and scrapes local
+// /metrics endpoints, shipping the results through a MeasurementsCommunicator.
+package prommetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MetricType is the type of a MetricFamily as declared by its TYPE line
+type MetricType string
+
+const (
+	Counter   MetricType = "counter"
+	Gauge     MetricType = "gauge"
+	Histogram MetricType = "histogram"
+	Summary   MetricType = "summary"
+	Untyped   MetricType = "untyped"
+)
+
+// Sample is a single line of an exposition
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	// Timestamp is the sample time in milliseconds since the Unix epoch, or 0 when not exposed
+	Timestamp int64
+}
+
+// MetricFamily is a group of Samples sharing a name, type and help text. The Samples of a histogram or
+// summary include its _bucket, _sum and _count series.
+type MetricFamily struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Samples []Sample
+}
+
+// ParseError describes a malformed line of an exposition
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// suffixes maps each MetricType to the sample name suffixes belonging to its family
+var suffixes = map[MetricType][]string{
+	Counter:   {"_total", "_created"},
+	Histogram: {"_bucket", "_sum", "_count", "_created"},
+	Summary:   {"_sum", "_count", "_created"},
+}
+
+// Parse reads the Prometheus text exposition format (version 0.0.4), in which timestamps are integer
+// milliseconds
+func Parse(r io.Reader) ([]*MetricFamily, error) {
+	return parse(r, false)
+}
+
+// ParseOpenMetrics reads the OpenMetrics text exposition format, in which timestamps are float seconds and
+// the exposition ends with "# EOF"
+func ParseOpenMetrics(r io.Reader) ([]*MetricFamily, error) {
+	return parse(r, true)
+}
+
+func parse(r io.Reader, openMetrics bool) ([]*MetricFamily, error) {
+	var (
+		families []*MetricFamily
+		byName   = map[string]*MetricFamily{}
+		scanner  = bufio.NewScanner(r)
+		lineNum  = 0
+	)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	family := func(name string) *MetricFamily {
+		f, ok := byName[name]
+		if !ok {
+			f = &MetricFamily{Name: name, Type: Untyped}
+			byName[name] = f
+			families = append(families, f)
+		}
+		return f
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(strings.TrimSpace(line[1:]), " ", 3)
+			switch fields[0] {
+			case "EOF":
+				return families, nil
+			case "HELP":
+				if len(fields) < 2 {
+					return nil, &ParseError{lineNum, "HELP without metric name"}
+				}
+				f := family(fields[1])
+				if len(fields) == 3 {
+					f.Help = unescapeHelp(fields[2])
+				}
+			case "TYPE":
+				if len(fields) < 3 {
+					return nil, &ParseError{lineNum, "TYPE without metric name and type"}
+				}
+				f := family(fields[1])
+				switch t := MetricType(strings.TrimSpace(fields[2])); t {
+				case Counter, Gauge, Histogram, Summary, Untyped:
+					f.Type = t
+				default:
+					// OpenMetrics types without a Prometheus equivalent are treated as untyped
+					f.Type = Untyped
+				}
+			}
+			// other comments, including UNIT, are ignored
+			continue
+		}
+
+		sample, err := parseSample(line, openMetrics)
+		if err != nil {
+			return nil, &ParseError{lineNum, err.Error()}
+		}
+		f := familyFor(sample.Name, byName)
+		if f == nil {
+			f = family(sample.Name)
+		}
+		f.Samples = append(f.Samples, sample)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return families, nil
+}
+
+// familyFor finds the previously declared family a sample name belongs to
+func familyFor(sampleName string, byName map[string]*MetricFamily) *MetricFamily {
+	if f, ok := byName[sampleName]; ok {
+		return f
+	}
+	for t, typeSuffixes := range suffixes {
+		for _, suffix := range typeSuffixes {
+			if !strings.HasSuffix(sampleName, suffix) {
+				continue
+			}
+			if f, ok := byName[strings.TrimSuffix(sampleName, suffix)]; ok && f.Type == t {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func parseSample(line string, openMetrics bool) (Sample, error) {
+	sample := Sample{}
+
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd <= 0 {
+		return sample, fmt.Errorf("invalid sample %q", line)
+	}
+	sample.Name = line[:nameEnd]
+	rest := line[nameEnd:]
+
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parseLabels(rest[1:])
+		if err != nil {
+			return sample, err
+		}
+		sample.Labels = labels
+		rest = remaining
+	}
+
+	// drop OpenMetrics exemplars
+	if i := strings.Index(rest, "#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return sample, fmt.Errorf("invalid value for sample %q", sample.Name)
+	}
+
+	value, err := parseFloat(fields[0])
+	if err != nil {
+		return sample, fmt.Errorf("invalid value for sample %q: %v", sample.Name, err)
+	}
+	sample.Value = value
+
+	if len(fields) == 2 {
+		if openMetrics {
+			seconds, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return sample, fmt.Errorf("invalid timestamp for sample %q: %v", sample.Name, err)
+			}
+			sample.Timestamp = int64(seconds * 1000)
+		} else {
+			ms, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return sample, fmt.Errorf("invalid timestamp for sample %q: %v", sample.Name, err)
+			}
+			sample.Timestamp = ms
+		}
+	}
+
+	return sample, nil
+}
+
+// parseLabels parses the label set following an opening brace, returning the labels and the remainder of
+// the line after the closing brace
+func parseLabels(s string) (map[string]string, string, error) {
+	labels := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("invalid label in %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return nil, "", fmt.Errorf("unquoted value for label %q", name)
+		}
+
+		var (
+			value   strings.Builder
+			escaped bool
+			end     = -1
+		)
+		for i := 1; i < len(s); i++ {
+			c := s[i]
+			if escaped {
+				switch c {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(c)
+				}
+				escaped = false
+				continue
+			}
+			if c == '\\' {
+				escaped = true
+				continue
+			}
+			if c == '"' {
+				end = i
+				break
+			}
+			value.WriteByte(c)
+		}
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated value for label %q", name)
+		}
+		labels[name] = value.String()
+
+		s = strings.TrimLeft(s[end+1:], " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return nil, "", fmt.Errorf("expected ',' or '}' after label %q", name)
+		}
+	}
+}
+
+func parseFloat(s string) (float64, error) {
+	switch s {
+	case "+Inf", "Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func unescapeHelp(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(s)
+}
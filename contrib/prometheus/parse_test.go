@@ -0,0 +1,113 @@
+package prommetrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const textExposition = `# HELP http_requests_total The total number of HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{method="post",code="200"} 1027 1395066363000
+http_requests_total{method="post",code="400"}    3 1395066363000
+
+# A normal comment
+# HELP queue_depth Items waiting.\nEscaped.
+# TYPE queue_depth gauge
+queue_depth{queue="a \"quoted\" \\ name",} 12.5
+queue_depth{queue="b"} NaN
+
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.05"} 24054
+request_duration_seconds_bucket{le="0.1"} 33444
+request_duration_seconds_bucket{le="+Inf"} 144320
+request_duration_seconds_sum 53423
+request_duration_seconds_count 144320
+
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 4773
+rpc_duration_seconds{quantile="0.99"} 76656
+rpc_duration_seconds_sum 1.7560473e+07
+rpc_duration_seconds_count 2693
+untyped_thing -Inf
+`
+
+func TestParse(t *testing.T) {
+	families, err := Parse(strings.NewReader(textExposition))
+	require.NoError(t, err)
+	require.Len(t, families, 5)
+
+	requests := families[0]
+	assert.Equal(t, "http_requests_total", requests.Name)
+	assert.Equal(t, "The total number of HTTP requests.", requests.Help)
+	assert.Equal(t, Counter, requests.Type)
+	require.Len(t, requests.Samples, 2)
+	assert.Equal(t, map[string]string{"method": "post", "code": "400"}, requests.Samples[1].Labels)
+	assert.Equal(t, float64(3), requests.Samples[1].Value)
+	assert.Equal(t, int64(1395066363000), requests.Samples[1].Timestamp)
+
+	queue := families[1]
+	assert.Equal(t, "Items waiting.\nEscaped.", queue.Help)
+	assert.Equal(t, Gauge, queue.Type)
+	assert.Equal(t, `a "quoted" \ name`, queue.Samples[0].Labels["queue"])
+	assert.True(t, math.IsNaN(queue.Samples[1].Value))
+
+	histogram := families[2]
+	assert.Equal(t, Histogram, histogram.Type)
+	assert.Len(t, histogram.Samples, 5)
+	assert.Equal(t, "+Inf", histogram.Samples[2].Labels["le"])
+
+	summary := families[3]
+	assert.Equal(t, Summary, summary.Type)
+	assert.Len(t, summary.Samples, 4)
+	assert.Equal(t, 1.7560473e+07, summary.Samples[2].Value)
+
+	untyped := families[4]
+	assert.Equal(t, "untyped_thing", untyped.Name)
+	assert.Equal(t, Untyped, untyped.Type)
+	assert.True(t, math.IsInf(untyped.Samples[0].Value, -1))
+}
+
+func TestParseOpenMetrics(t *testing.T) {
+	exposition := `# TYPE acme_http_router_request_seconds summary
+# UNIT acme_http_router_request_seconds seconds
+acme_http_router_request_seconds_sum{path="/api/v1",method="GET"} 9036.32
+acme_http_router_request_seconds_count{path="/api/v1",method="GET"} 807283.0
+acme_http_router_request_seconds_created{path="/api/v1",method="GET"} 1605281325.0
+# TYPE foo counter
+foo_total 17.0 1520879607.789 # {id="counter-test"} 5
+foo_created 1520872607.123
+# EOF
+ignored 1
+`
+	families, err := ParseOpenMetrics(strings.NewReader(exposition))
+	require.NoError(t, err)
+	require.Len(t, families, 2)
+
+	assert.Len(t, families[0].Samples, 3)
+
+	foo := families[1]
+	assert.Equal(t, Counter, foo.Type)
+	require.Len(t, foo.Samples, 2)
+	assert.Equal(t, "foo_total", foo.Samples[0].Name)
+	assert.Equal(t, float64(17), foo.Samples[0].Value)
+	assert.Equal(t, int64(1520879607789), foo.Samples[0].Timestamp)
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, exposition := range []string{
+		"metric{label=unquoted} 1",
+		`metric{label="unterminated} 1`,
+		"metric not_a_number",
+		"metric 1 2 3",
+		`metric{a="b" c="d"} 1`,
+		"# TYPE metric",
+	} {
+		_, err := Parse(strings.NewReader(exposition))
+		assert.Error(t, err, exposition)
+		assert.IsType(t, &ParseError{}, err, exposition)
+	}
+}
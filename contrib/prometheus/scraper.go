@@ -0,0 +1,168 @@
+package prommetrics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultScrapeInterval is the interval at which a Scraper pulls its targets
+	DefaultScrapeInterval = 15 * time.Second
+	acceptHeader          = "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5,*/*;q=0.1"
+)
+
+// Target is an endpoint exposing Prometheus metrics
+type Target struct {
+	// URL is the address of the endpoint, e.g. http://localhost:9100/metrics
+	URL string
+	// Tags are applied to all Measurements scraped from the endpoint
+	Tags map[string]string
+}
+
+// ScraperOption configures a Scraper
+type ScraperOption func(*Scraper)
+
+// ScrapeInterval sets the interval at which targets are scraped. The default is DefaultScrapeInterval.
+func ScrapeInterval(interval time.Duration) ScraperOption {
+	return func(s *Scraper) {
+		s.interval = interval
+	}
+}
+
+// ScrapeHTTPClient sets the http.Client used to scrape targets
+func ScrapeHTTPClient(client *http.Client) ScraperOption {
+	return func(s *Scraper) {
+		s.httpClient = client
+	}
+}
+
+// Scraper periodically pulls Prometheus metrics from its targets, converts them with a Converter per target
+// and persists the results to AppOptics through a MeasurementsCommunicator.
+type Scraper struct {
+	targets    []Target
+	converters []*Converter
+	comm       appoptics.MeasurementsCommunicator
+	interval   time.Duration
+	httpClient *http.Client
+	stop       chan struct{}
+	stopOnce   sync.Once
+}
+
+// NewScraper returns a Scraper for the given targets
+func NewScraper(comm appoptics.MeasurementsCommunicator, targets []Target, opts ...ScraperOption) *Scraper {
+	s := &Scraper{
+		targets:    targets,
+		comm:       comm,
+		interval:   DefaultScrapeInterval,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		stop:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	for range targets {
+		s.converters = append(s.converters, NewConverter())
+	}
+	return s
+}
+
+// Start kicks off a goroutine scraping the targets on the configured interval until Stop is called
+func (s *Scraper) Start() {
+	go s.scrapeForever()
+}
+
+// Stop ends scraping
+func (s *Scraper) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// ScrapeOnce scrapes every target and persists the results, returning the first error encountered. A
+// failing target does not prevent the others from being scraped.
+func (s *Scraper) ScrapeOnce() error {
+	var (
+		firstErr     error
+		measurements []appoptics.Measurement
+	)
+	for i, target := range s.targets {
+		families, err := s.fetch(target)
+		if err != nil {
+			log.Error("Error scraping Prometheus target", "url", target.URL, "err", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		measurements = append(measurements, s.converters[i].Convert(families, target.Tags)...)
+	}
+
+	batchTime := time.Now().Unix()
+	if err := persist(s.comm, measurements, batchTime, int64(s.interval/time.Second)); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+func (s *Scraper) fetch(target Target) ([]*MetricFamily, error) {
+	req, err := http.NewRequest("GET", target.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", acceptHeader)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %s: %s", target.URL, resp.Status)
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/openmetrics-text") {
+		return ParseOpenMetrics(resp.Body)
+	}
+	return Parse(resp.Body)
+}
+
+func (s *Scraper) scrapeForever() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.ScrapeOnce()
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// persist sends measurements in batches no larger than appoptics.MeasurementPostMaxBatchSize
+func persist(comm appoptics.MeasurementsCommunicator, measurements []appoptics.Measurement, batchTime, period int64) error {
+	for len(measurements) > 0 {
+		n := len(measurements)
+		if n > appoptics.MeasurementPostMaxBatchSize {
+			n = appoptics.MeasurementPostMaxBatchSize
+		}
+		batch := &appoptics.MeasurementsBatch{
+			Measurements: measurements[:n],
+			Time:         batchTime,
+			Period:       period,
+		}
+		if _, err := comm.Create(batch); err != nil {
+			return err
+		}
+		measurements = measurements[n:]
+	}
+	return nil
+}
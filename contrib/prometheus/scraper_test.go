@@ -0,0 +1,64 @@
+package prommetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScraper_ScrapeOnce(t *testing.T) {
+	jobs := []string{"5", "7"}
+	text := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.Header.Get("Accept"), "text/plain")
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte("# TYPE jobs_total counter\njobs_total " + jobs[0] + "\nworkers 3 1395066363000\n"))
+		jobs = jobs[1:]
+	}))
+	defer text.Close()
+
+	openMetrics := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		w.Write([]byte("# TYPE temperature gauge\ntemperature 21.5 1395066363.5\n# EOF\n"))
+	}))
+	defer openMetrics.Close()
+
+	failing := httptest.NewServer(http.NotFoundHandler())
+	defer failing.Close()
+
+	var batches []*appoptics.MeasurementsBatch
+	mms := &appoptics.MockMeasurementsService{
+		OnCreate: func(batch *appoptics.MeasurementsBatch) (*http.Response, error) {
+			batches = append(batches, batch)
+			return nil, nil
+		},
+	}
+
+	s := NewScraper(mms, []Target{
+		{URL: text.URL, Tags: map[string]string{"sidecar": "jobs"}},
+		{URL: openMetrics.URL},
+		{URL: failing.URL},
+	})
+
+	assert.Error(t, s.ScrapeOnce(), "the failing target should be reported")
+	require.Len(t, batches, 1)
+	first := batches[0]
+	assert.EqualValues(t, 15, first.Period)
+	require.Len(t, first.Measurements, 2)
+	assert.Equal(t, "workers", first.Measurements[0].Name)
+	assert.Equal(t, map[string]string{"sidecar": "jobs"}, first.Measurements[0].Tags)
+	assert.Equal(t, int64(1395066363), first.Measurements[0].Time)
+	assert.Equal(t, "temperature", first.Measurements[1].Name)
+	assert.Equal(t, 21.5, first.Measurements[1].Value)
+	assert.Equal(t, int64(1395066363), first.Measurements[1].Time)
+
+	s.ScrapeOnce()
+	require.Len(t, batches, 2)
+	second := batches[1]
+	require.Len(t, second.Measurements, 3)
+	assert.Equal(t, "jobs_total", second.Measurements[0].Name)
+	assert.Equal(t, float64(2), second.Measurements[0].Value)
+}
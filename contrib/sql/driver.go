@@ -0,0 +1,397 @@
+// Package sqlmetrics instruments database/sql drivers, recording query counts, latencies and errors into an
+// appoptics.MeasurementSet, and periodically reports sql.DBStats for a *sql.DB.
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+const defaultPrefix = "sql"
+
+// Operation types used in the "op" tag
+const (
+	OpQuery    = "query"
+	OpExec     = "exec"
+	OpPrepare  = "prepare"
+	OpBegin    = "begin"
+	OpCommit   = "commit"
+	OpRollback = "rollback"
+)
+
+type labelCtxMarker struct{}
+
+var labelCtxMarkerKey = &labelCtxMarker{}
+
+// ContextWithLabel wraps the specified context with a statement label. Operations performed with the
+// returned context are tagged with label, allowing metrics to be broken down per statement.
+func ContextWithLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelCtxMarkerKey, label)
+}
+
+func labelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(labelCtxMarkerKey).(string)
+	return label
+}
+
+// Option configures the instrumentation
+type Option func(*options)
+
+type options struct {
+	prefix string
+	tags   map[string]interface{}
+}
+
+// WithPrefix sets the prefix of all metric names. The default is "sql".
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithTags sets tags applied to all metrics, e.g. the name of the database
+func WithTags(tags map[string]interface{}) Option {
+	return func(o *options) {
+		o.tags = tags
+	}
+}
+
+func evaluateOptions(opts []Option) *options {
+	o := &options{prefix: defaultPrefix}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// instrument records measurements for driver operations. For each operation it emits:
+//
+//   - <prefix>.operations: count of operations
+//   - <prefix>.time_ms: operation latency
+//   - <prefix>.errors: count of operations that returned an error
+//
+// each tagged with op and, when set with ContextWithLabel, label.
+type instrument struct {
+	m    *appoptics.MeasurementSet
+	opts *options
+}
+
+func (in *instrument) record(ctx context.Context, op string, start time.Time, err error) {
+	// ErrSkip asks database/sql to retry by another route, which will be recorded in turn
+	if err == driver.ErrSkip {
+		return
+	}
+
+	tags := make(map[string]interface{}, len(in.opts.tags)+2)
+	for k, v := range in.opts.tags {
+		tags[k] = v
+	}
+	tags["op"] = op
+	if label := labelFromContext(ctx); label != "" {
+		tags["label"] = label
+	}
+
+	in.m.Incr(appoptics.MetricWithTags(in.opts.prefix+".operations", tags))
+	in.m.UpdateAggregatorValue(appoptics.MetricWithTags(in.opts.prefix+".time_ms", tags), milliseconds(time.Since(start)))
+	if err != nil {
+		in.m.Incr(appoptics.MetricWithTags(in.opts.prefix+".errors", tags))
+	}
+}
+
+// Wrap returns a driver.Driver which records metrics for all connections opened by d
+func Wrap(d driver.Driver, m *appoptics.MeasurementSet, opts ...Option) driver.Driver {
+	return &instrumentedDriver{
+		parent: d,
+		in:     &instrument{m: m, opts: evaluateOptions(opts)},
+	}
+}
+
+// WrapConnector returns a driver.Connector which records metrics for all connections created by c. The
+// result can be passed to sql.OpenDB.
+func WrapConnector(c driver.Connector, m *appoptics.MeasurementSet, opts ...Option) driver.Connector {
+	in := &instrument{m: m, opts: evaluateOptions(opts)}
+	return &instrumentedConnector{
+		parent: c,
+		driver: &instrumentedDriver{parent: c.Driver(), in: in},
+		in:     in,
+	}
+}
+
+// Register wraps d with Wrap and registers it with database/sql under name
+func Register(name string, d driver.Driver, m *appoptics.MeasurementSet, opts ...Option) {
+	sql.Register(name, Wrap(d, m, opts...))
+}
+
+type instrumentedDriver struct {
+	parent driver.Driver
+	in     *instrument
+}
+
+func (d *instrumentedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.parent.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{parent: c, in: d.in}, nil
+}
+
+// OpenConnector implements driver.DriverContext, deferring to the wrapped driver when it does too
+func (d *instrumentedDriver) OpenConnector(name string) (driver.Connector, error) {
+	if dc, ok := d.parent.(driver.DriverContext); ok {
+		c, err := dc.OpenConnector(name)
+		if err != nil {
+			return nil, err
+		}
+		return &instrumentedConnector{parent: c, driver: d, in: d.in}, nil
+	}
+	return &dsnConnector{name: name, driver: d}, nil
+}
+
+type dsnConnector struct {
+	name   string
+	driver *instrumentedDriver
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.name)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConnector struct {
+	parent driver.Connector
+	driver *instrumentedDriver
+	in     *instrument
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.parent.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{parent: conn, in: c.in}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConn struct {
+	parent driver.Conn
+	in     *instrument
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (stmt driver.Stmt, err error) {
+	start := time.Now()
+	if pc, ok := c.parent.(driver.ConnPrepareContext); ok {
+		stmt, err = pc.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.parent.Prepare(query)
+	}
+	c.in.record(ctx, OpPrepare, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{parent: stmt, in: c.in}, nil
+}
+
+func (c *instrumentedConn) Close() error {
+	return c.parent.Close()
+}
+
+func (c *instrumentedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	start := time.Now()
+	if bc, ok := c.parent.(driver.ConnBeginTx); ok {
+		tx, err = bc.BeginTx(ctx, opts)
+	} else if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		err = errors.New("sqlmetrics: driver does not support non-default isolation level or read-only transactions")
+	} else {
+		tx, err = c.parent.Begin()
+	}
+	c.in.record(ctx, OpBegin, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedTx{parent: tx, ctx: ctx, in: c.in}, nil
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	if qc, ok := c.parent.(driver.QueryerContext); ok {
+		rows, err = qc.QueryContext(ctx, query, args)
+	} else if q, ok := c.parent.(driver.Queryer); ok {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = q.Query(query, values)
+		}
+	} else {
+		return nil, driver.ErrSkip
+	}
+	c.in.record(ctx, OpQuery, start, err)
+	return rows, err
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (result driver.Result, err error) {
+	start := time.Now()
+	if ec, ok := c.parent.(driver.ExecerContext); ok {
+		result, err = ec.ExecContext(ctx, query, args)
+	} else if e, ok := c.parent.(driver.Execer); ok {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			result, err = e.Exec(query, values)
+		}
+	} else {
+		return nil, driver.ErrSkip
+	}
+	c.in.record(ctx, OpExec, start, err)
+	return result, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.parent.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.parent.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if v, ok := c.parent.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := c.parent.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type instrumentedStmt struct {
+	parent driver.Stmt
+	in     *instrument
+}
+
+func (s *instrumentedStmt) Close() error {
+	return s.parent.Close()
+}
+
+func (s *instrumentedStmt) NumInput() int {
+	return s.parent.NumInput()
+}
+
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, err := s.parent.Exec(args)
+	s.in.record(context.Background(), OpExec, start, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.parent.Query(args)
+	s.in.record(context.Background(), OpQuery, start, err)
+	return rows, err
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	start := time.Now()
+	if ec, ok := s.parent.(driver.StmtExecContext); ok {
+		result, err = ec.ExecContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			result, err = s.parent.Exec(values)
+		}
+	}
+	s.in.record(ctx, OpExec, start, err)
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	if qc, ok := s.parent.(driver.StmtQueryContext); ok {
+		rows, err = qc.QueryContext(ctx, args)
+	} else {
+		var values []driver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			rows, err = s.parent.Query(values)
+		}
+	}
+	s.in.record(ctx, OpQuery, start, err)
+	return rows, err
+}
+
+func (s *instrumentedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if nvc, ok := s.parent.(driver.NamedValueChecker); ok {
+		return nvc.CheckNamedValue(nv)
+	}
+	if cc, ok := s.parent.(driver.ColumnConverter); ok {
+		value, err := cc.ColumnConverter(nv.Ordinal - 1).ConvertValue(nv.Value)
+		if err != nil {
+			return err
+		}
+		nv.Value = value
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+type instrumentedTx struct {
+	parent driver.Tx
+	ctx    context.Context
+	in     *instrument
+}
+
+func (t *instrumentedTx) Commit() error {
+	start := time.Now()
+	err := t.parent.Commit()
+	t.in.record(t.ctx, OpCommit, start, err)
+	return err
+}
+
+func (t *instrumentedTx) Rollback() error {
+	start := time.Now()
+	err := t.parent.Rollback()
+	t.in.record(t.ctx, OpRollback, start, err)
+	return err
+}
+
+// namedValuesToValues converts arguments for drivers which predate driver.NamedValue
+func namedValuesToValues(named []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(named))
+	for i, nv := range named {
+		if nv.Name != "" {
+			return nil, errors.New("sqlmetrics: driver does not support the use of named parameters")
+		}
+		values[i] = nv.Value
+	}
+	return values, nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
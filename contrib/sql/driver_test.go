@@ -0,0 +1,82 @@
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	Register("fake-instrumented", &fakeDriver{}, measures, WithTags(map[string]interface{}{"db": "test"}))
+
+	db, err := sql.Open("fake-instrumented", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := ContextWithLabel(context.Background(), "insert_value")
+	_, err = db.ExecContext(ctx, "INSERT", 42)
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT")
+	require.NoError(t, err)
+	var values []int64
+	for rows.Next() {
+		var v int64
+		require.NoError(t, rows.Scan(&v))
+		values = append(values, v)
+	}
+	rows.Close()
+	assert.Equal(t, []int64{42}, values)
+
+	_, err = db.Exec("FAIL")
+	assert.Error(t, err)
+
+	report := measures.Reset()
+
+	exec := map[string]interface{}{"db": "test", "op": OpExec, "label": "insert_value"}
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("sql.operations", exec)])
+	assert.EqualValues(t, 1, report.Aggregators[appoptics.MetricWithTags("sql.time_ms", exec)].Count)
+	assert.Zero(t, report.Counts[appoptics.MetricWithTags("sql.errors", exec)])
+
+	query := map[string]interface{}{"db": "test", "op": OpQuery}
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("sql.operations", query)])
+
+	failed := map[string]interface{}{"db": "test", "op": OpExec}
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("sql.operations", failed)])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("sql.errors", failed)])
+
+	// the fake driver has no Execer or Queryer, so database/sql prepares the SELECT and FAIL statements too
+	prepare := map[string]interface{}{"db": "test", "op": OpPrepare}
+	assert.EqualValues(t, 2, report.Counts[appoptics.MetricWithTags("sql.operations", prepare)])
+}
+
+func TestWrapConnector_Transactions(t *testing.T) {
+	measures := appoptics.NewMeasurementSet()
+	db := sql.OpenDB(WrapConnector(&fakeConnector{d: &fakeDriver{}}, measures, WithPrefix("db")))
+	defer db.Close()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	_, err = tx.Exec("INSERT", 1)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	tx, err = db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	_, err = db.Prepare("FAIL PREPARE")
+	assert.Error(t, err)
+
+	report := measures.Reset()
+	assert.EqualValues(t, 2, report.Counts[appoptics.MetricWithTags("db.operations", map[string]interface{}{"op": OpBegin})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("db.operations", map[string]interface{}{"op": OpCommit})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("db.operations", map[string]interface{}{"op": OpRollback})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("db.operations", map[string]interface{}{"op": OpExec})])
+	assert.EqualValues(t, 1, report.Counts[appoptics.MetricWithTags("db.errors", map[string]interface{}{"op": OpPrepare})])
+}
@@ -0,0 +1,117 @@
+package sqlmetrics
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+var errFakeFailure = errors.New("fake failure")
+
+// fakeDriver is a minimal in-memory driver. Statements beginning with FAIL return an error, INSERT appends
+// its first argument to the table and SELECT returns the table's contents.
+type fakeDriver struct {
+	mu    sync.Mutex
+	table []driver.Value
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d}, nil
+}
+
+type fakeConnector struct {
+	d *fakeDriver
+}
+
+func (c *fakeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.d.Open("")
+}
+
+func (c *fakeConnector) Driver() driver.Driver {
+	return c.d
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if strings.HasPrefix(query, "FAIL PREPARE") {
+		return nil, errFakeFailure
+	}
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (t *fakeTx) Commit() error {
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "FAIL") {
+		return nil, errFakeFailure
+	}
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	s.c.d.table = append(s.c.d.table, args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if strings.HasPrefix(s.query, "FAIL") {
+		return nil, errFakeFailure
+	}
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	return &fakeRows{values: append([]driver.Value(nil), s.c.d.table...)}, nil
+}
+
+type fakeRows struct {
+	values []driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+	return nil
+}
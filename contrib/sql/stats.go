@@ -0,0 +1,87 @@
+package sqlmetrics
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+)
+
+// DefaultStatsInterval is the interval at which a DBStatsCollector samples sql.DBStats
+const DefaultStatsInterval = 10 * time.Second
+
+// DBStatsCollector periodically samples the sql.DBStats of a *sql.DB and records them as gauges:
+//
+//   - <prefix>.db.max_open_connections
+//   - <prefix>.db.open_connections
+//   - <prefix>.db.in_use
+//   - <prefix>.db.idle
+//   - <prefix>.db.wait_count
+//   - <prefix>.db.wait_duration_ms
+type DBStatsCollector struct {
+	db       *sql.DB
+	m        *appoptics.MeasurementSet
+	opts     *options
+	interval time.Duration
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDBStatsCollector returns a DBStatsCollector for db recording into m
+func NewDBStatsCollector(db *sql.DB, m *appoptics.MeasurementSet, opts ...Option) *DBStatsCollector {
+	return &DBStatsCollector{
+		db:       db,
+		m:        m,
+		opts:     evaluateOptions(opts),
+		interval: DefaultStatsInterval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// SetInterval sets the sampling interval; it must be called before Start
+func (c *DBStatsCollector) SetInterval(interval time.Duration) {
+	c.interval = interval
+}
+
+// Start kicks off a goroutine sampling the DBStats until Stop is called
+func (c *DBStatsCollector) Start() {
+	go c.collectForever()
+}
+
+// Stop ends sampling
+func (c *DBStatsCollector) Stop() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+// Collect samples the DBStats once
+func (c *DBStatsCollector) Collect() {
+	stats := c.db.Stats()
+
+	c.gauge("max_open_connections", float64(stats.MaxOpenConnections))
+	c.gauge("open_connections", float64(stats.OpenConnections))
+	c.gauge("in_use", float64(stats.InUse))
+	c.gauge("idle", float64(stats.Idle))
+	c.gauge("wait_count", float64(stats.WaitCount))
+	c.gauge("wait_duration_ms", milliseconds(stats.WaitDuration))
+}
+
+func (c *DBStatsCollector) gauge(name string, val float64) {
+	c.m.UpdateAggregatorValue(appoptics.MetricWithTags(c.opts.prefix+".db."+name, c.opts.tags), val)
+}
+
+func (c *DBStatsCollector) collectForever() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Collect()
+		select {
+		case <-ticker.C:
+		case <-c.stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package sqlmetrics
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBStatsCollector_Collect(t *testing.T) {
+	db := sql.OpenDB(&fakeConnector{d: &fakeDriver{}})
+	defer db.Close()
+	db.SetMaxOpenConns(3)
+	require.NoError(t, db.Ping())
+
+	measures := appoptics.NewMeasurementSet()
+	tags := map[string]interface{}{"db": "test"}
+	NewDBStatsCollector(db, measures, WithTags(tags)).Collect()
+
+	report := measures.Reset()
+	assert.EqualValues(t, 3, report.Aggregators[appoptics.MetricWithTags("sql.db.max_open_connections", tags)].Last)
+	assert.EqualValues(t, 1, report.Aggregators[appoptics.MetricWithTags("sql.db.open_connections", tags)].Last)
+	assert.EqualValues(t, 1, report.Aggregators[appoptics.MetricWithTags("sql.db.idle", tags)].Last)
+	assert.Contains(t, report.Aggregators, appoptics.MetricWithTags("sql.db.in_use", tags))
+	assert.Contains(t, report.Aggregators, appoptics.MetricWithTags("sql.db.wait_count", tags))
+	assert.Contains(t, report.Aggregators, appoptics.MetricWithTags("sql.db.wait_duration_ms", tags))
+}
@@ -0,0 +1,108 @@
+// Package statsd provides a StatsD server which listens on UDP or unix datagram sockets and aggregates the
+// received metrics into an appoptics.MeasurementSet, so they can be shipped with a Reporter. Both the Etsy
+// StatsD line protocol and the DogStatsD extensions for tags are supported.
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MetricType is the type of a StatsD metric
+type MetricType string
+
+const (
+	Counter      MetricType = "c"
+	Gauge        MetricType = "g"
+	Timer        MetricType = "ms"
+	Histogram    MetricType = "h"
+	Distribution MetricType = "d"
+	Set          MetricType = "s"
+)
+
+// Metric is a single parsed StatsD line
+type Metric struct {
+	Name string
+	Type MetricType
+	// Value is the numeric value; for sets it is unused and RawValue holds the member
+	Value    float64
+	RawValue string
+	// Relative is true for gauges sent with an explicit sign, which adjust rather than set the gauge
+	Relative   bool
+	SampleRate float64
+	Tags       map[string]string
+}
+
+var errEmptyLine = errors.New("empty line")
+
+// Parse parses a single line of the form <name>:<value>|<type>[|@<sample rate>][|#<tag>:<value>,...]
+func Parse(line string) (Metric, error) {
+	m := Metric{SampleRate: 1}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return m, errEmptyLine
+	}
+
+	colon := strings.Index(line, ":")
+	if colon <= 0 {
+		return m, fmt.Errorf("missing name in %q", line)
+	}
+	m.Name = line[:colon]
+
+	sections := strings.Split(line[colon+1:], "|")
+	if len(sections) < 2 {
+		return m, fmt.Errorf("missing type in %q", line)
+	}
+
+	m.Type = MetricType(sections[1])
+	switch m.Type {
+	case Counter, Gauge, Timer, Histogram, Distribution, Set:
+	default:
+		return m, fmt.Errorf("unknown type %q in %q", sections[1], line)
+	}
+
+	m.RawValue = sections[0]
+	if m.Type != Set {
+		value, err := strconv.ParseFloat(m.RawValue, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid value in %q", line)
+		}
+		m.Value = value
+		m.Relative = m.Type == Gauge && (strings.HasPrefix(m.RawValue, "+") || strings.HasPrefix(m.RawValue, "-"))
+	}
+
+	for _, section := range sections[2:] {
+		switch {
+		case strings.HasPrefix(section, "@"):
+			rate, err := strconv.ParseFloat(section[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return m, fmt.Errorf("invalid sample rate in %q", line)
+			}
+			m.SampleRate = rate
+		case strings.HasPrefix(section, "#"):
+			m.Tags = parseTags(section[1:])
+		}
+		// other DogStatsD extensions, such as container IDs and timestamps, are ignored
+	}
+
+	return m, nil
+}
+
+// parseTags parses DogStatsD tags. Tags without a value are given the value "true".
+func parseTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, tag := range strings.Split(s, ",") {
+		if tag == "" {
+			continue
+		}
+		if i := strings.Index(tag, ":"); i >= 0 {
+			tags[tag[:i]] = tag[i+1:]
+		} else {
+			tags[tag] = "true"
+		}
+	}
+	return tags
+}
@@ -0,0 +1,64 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("counter", func(t *testing.T) {
+		m, err := Parse("page.views:3|c")
+		require.NoError(t, err)
+		assert.Equal(t, Metric{Name: "page.views", Type: Counter, Value: 3, RawValue: "3", SampleRate: 1}, m)
+	})
+
+	t.Run("sample rate and tags", func(t *testing.T) {
+		m, err := Parse("requests:1|c|@0.1|#host:a,canary")
+		require.NoError(t, err)
+		assert.Equal(t, 0.1, m.SampleRate)
+		assert.Equal(t, map[string]string{"host": "a", "canary": "true"}, m.Tags)
+	})
+
+	t.Run("relative gauge", func(t *testing.T) {
+		m, err := Parse("queue.depth:-4|g")
+		require.NoError(t, err)
+		assert.True(t, m.Relative)
+		assert.Equal(t, -4.0, m.Value)
+
+		m, err = Parse("queue.depth:4|g")
+		require.NoError(t, err)
+		assert.False(t, m.Relative)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		m, err := Parse("users:alice|s")
+		require.NoError(t, err)
+		assert.Equal(t, Set, m.Type)
+		assert.Equal(t, "alice", m.RawValue)
+	})
+
+	t.Run("unknown extensions are ignored", func(t *testing.T) {
+		m, err := Parse("latency:12.5|ms|#env:prod|c:abc123")
+		require.NoError(t, err)
+		assert.Equal(t, Timer, m.Type)
+		assert.Equal(t, 12.5, m.Value)
+		assert.Equal(t, map[string]string{"env": "prod"}, m.Tags)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, line := range []string{
+			"no-colon",
+			":1|c",
+			"name:1",
+			"name:1|x",
+			"name:abc|c",
+			"name:1|c|@2",
+			"name:1|c|@zero",
+		} {
+			_, err := Parse(line)
+			assert.Error(t, err, line)
+		}
+	})
+}
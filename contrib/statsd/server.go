@@ -0,0 +1,287 @@
+package statsd
+
+import (
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	log "github.com/sirupsen/logrus"
+)
+
+const maxPacketSize = 65535
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithGlobalTags sets tags applied to every metric, in addition to any DogStatsD tags
+func WithGlobalTags(tags map[string]string) Option {
+	return func(s *Server) {
+		s.globalTags = tags
+	}
+}
+
+// WithSetInterval sets the window over which unique set members are counted. It should match the interval of the
+// Reporter flushing the MeasurementSet; the default is appoptics.ReportInterval.
+func WithSetInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.setInterval = interval
+	}
+}
+
+// WithGaugeExpiry sets how long the value of a gauge is kept after its last update. A signed value adjusts the
+// kept value, or starts from zero once the gauge has expired. The default is twice appoptics.ReportInterval.
+func WithGaugeExpiry(expiry time.Duration) Option {
+	return func(s *Server) {
+		s.gaugeExpiry = expiry
+	}
+}
+
+// Server receives StatsD metrics and updates a MeasurementSet, keyed by appoptics.MetricWithTags:
+//
+//   - counters add their value, scaled by the inverse of the sample rate, to a Counter
+//   - gauges record their value in an Aggregator; signed values adjust the previous value until it expires
+//   - timers, histograms and distributions record their value in an Aggregator, weighted by the inverse of
+//     the sample rate
+//   - sets increment a Counter the first time each member is seen in the set interval
+type Server struct {
+	m           *appoptics.MeasurementSet
+	globalTags  map[string]string
+	setInterval time.Duration
+	gaugeExpiry time.Duration
+
+	mu              sync.Mutex
+	gauges          map[string]gauge
+	gaugesExpiredAt time.Time
+	sets            map[string]map[string]struct{}
+	intervalStart   time.Time
+	conns           []net.PacketConn
+	unixPaths       []string
+	wg              sync.WaitGroup
+	closed          bool
+}
+
+// gauge is the last value of a gauge and when it was set
+type gauge struct {
+	value   float64
+	updated time.Time
+}
+
+// NewServer returns a Server updating m
+func NewServer(m *appoptics.MeasurementSet, opts ...Option) *Server {
+	s := &Server{
+		m:               m,
+		setInterval:     appoptics.ReportInterval,
+		gaugeExpiry:     2 * appoptics.ReportInterval,
+		gauges:          map[string]gauge{},
+		gaugesExpiredAt: time.Now(),
+		sets:            map[string]map[string]struct{}{},
+		intervalStart:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListenAndServe listens on the given network, "udp", "udp4", "udp6" or "unixgram", and address and serves
+// in a background goroutine until Close is called. It returns the bound address, which is useful when
+// listening on port 0.
+func (s *Server) ListenAndServe(network, address string) (net.Addr, error) {
+	if network == "unixgram" {
+		// remove a socket left behind by a previous process
+		if _, err := os.Stat(address); err == nil {
+			os.Remove(address)
+		}
+	}
+
+	conn, err := net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if network == "unixgram" {
+		s.unixPaths = append(s.unixPaths, address)
+	}
+	s.mu.Unlock()
+
+	s.Serve(conn)
+	return conn.LocalAddr(), nil
+}
+
+// Serve reads packets from conn in a background goroutine until Close is called or reading fails with an error
+// which is neither temporary nor a timeout
+func (s *Server) Serve(conn net.PacketConn) {
+	s.mu.Lock()
+	s.conns = append(s.conns, conn)
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		buf := make([]byte, maxPacketSize)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				s.mu.Lock()
+				closed := s.closed
+				s.mu.Unlock()
+				if closed {
+					return
+				}
+				if ne, ok := err.(net.Error); ok && (ne.Timeout() || isTemporary(ne)) {
+					log.Debug("Error reading StatsD packet", "err", err)
+					continue
+				}
+				log.Error("Error reading StatsD packet, no longer serving", "err", err)
+				return
+			}
+			s.HandlePacket(buf[:n])
+		}
+	}()
+}
+
+// Close stops all listeners and waits for them to finish
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	conns := s.conns
+	paths := s.unixPaths
+	s.mu.Unlock()
+
+	var firstErr error
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	s.wg.Wait()
+
+	for _, path := range paths {
+		os.Remove(path)
+	}
+	return firstErr
+}
+
+// HandlePacket processes a packet containing one or more newline separated StatsD lines. Malformed lines
+// are skipped.
+func (s *Server) HandlePacket(packet []byte) {
+	for _, line := range strings.Split(string(packet), "\n") {
+		m, err := Parse(line)
+		if err == errEmptyLine {
+			continue
+		}
+		if err != nil {
+			log.Debug("Skipping malformed StatsD line", "err", err)
+			continue
+		}
+		s.Handle(m)
+	}
+}
+
+// Handle applies a parsed Metric to the MeasurementSet
+func (s *Server) Handle(metric Metric) {
+	key := s.key(metric)
+	weight := 1 / metric.SampleRate
+
+	switch metric.Type {
+	case Counter:
+		s.m.Add(key, int64(math.Round(metric.Value*weight)))
+	case Gauge:
+		s.mu.Lock()
+		now := time.Now()
+		s.expireGauges(now)
+		value := metric.Value
+		if metric.Relative {
+			if previous, ok := s.gauges[key]; ok && now.Sub(previous.updated) < s.gaugeExpiry {
+				value += previous.value
+			}
+		}
+		s.gauges[key] = gauge{value: value, updated: now}
+		s.mu.Unlock()
+		s.m.UpdateAggregatorValue(key, value)
+	case Timer, Histogram, Distribution:
+		count := int64(math.Round(weight))
+		if count < 1 {
+			count = 1
+		}
+		s.m.UpdateAggregator(key, appoptics.Aggregator{
+			Count: count,
+			Sum:   metric.Value * float64(count),
+			Min:   metric.Value,
+			Max:   metric.Value,
+			Last:  metric.Value,
+		})
+	case Set:
+		if s.firstInSet(key, metric.RawValue) {
+			s.m.Incr(key)
+		}
+	}
+}
+
+// firstInSet reports whether member has not been seen in the set identified by key during the current set
+// interval
+func (s *Server) firstInSet(key, member string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollInterval()
+
+	members, ok := s.sets[key]
+	if !ok {
+		members = map[string]struct{}{}
+		s.sets[key] = members
+	}
+	if _, seen := members[member]; seen {
+		return false
+	}
+	members[member] = struct{}{}
+	return true
+}
+
+// rollInterval starts a new set interval once the current one has passed, forgetting the set members. s.mu must
+// be held.
+func (s *Server) rollInterval() {
+	if time.Since(s.intervalStart) < s.setInterval {
+		return
+	}
+	s.sets = map[string]map[string]struct{}{}
+	s.intervalStart = time.Now()
+}
+
+// expireGauges forgets the gauges which have expired, checking at most once per expiry period. s.mu must be held.
+func (s *Server) expireGauges(now time.Time) {
+	if now.Sub(s.gaugesExpiredAt) < s.gaugeExpiry {
+		return
+	}
+	for key, g := range s.gauges {
+		if now.Sub(g.updated) >= s.gaugeExpiry {
+			delete(s.gauges, key)
+		}
+	}
+	s.gaugesExpiredAt = now
+}
+
+// isTemporary reports whether err says it is temporary. net.Error's Temporary is deprecated, but some
+// platforms still report transient read failures, such as ECONNREFUSED from a UDP peer, only through it.
+func isTemporary(err net.Error) bool {
+	t, ok := err.(interface{ Temporary() bool })
+	return ok && t.Temporary()
+}
+
+func (s *Server) key(metric Metric) string {
+	if len(metric.Tags) == 0 && len(s.globalTags) == 0 {
+		return metric.Name
+	}
+	tags := make(map[string]interface{}, len(metric.Tags)+len(s.globalTags))
+	for k, v := range s.globalTags {
+		tags[k] = v
+	}
+	for k, v := range metric.Tags {
+		tags[k] = v
+	}
+	return appoptics.MetricWithTags(metric.Name, tags)
+}
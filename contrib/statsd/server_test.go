@@ -0,0 +1,149 @@
+package statsd
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerHandlePacket(t *testing.T) {
+	m := appoptics.NewMeasurementSet()
+	s := NewServer(m, WithGlobalTags(map[string]string{"region": "us-east"}))
+
+	s.HandlePacket([]byte("hits:2|c|#route:home\nhits:1|c|@0.5|#route:home\n\nbogus\n" +
+		"depth:10|g\ndepth:-3|g\n" +
+		"latency:20|ms|@0.25\n" +
+		"users:alice|s\nusers:bob|s\nusers:alice|s"))
+
+	report := m.Reset()
+
+	hits := appoptics.MetricWithTags("hits", map[string]interface{}{"route": "home", "region": "us-east"})
+	assert.Equal(t, int64(4), report.Counts[hits])
+
+	users := appoptics.MetricWithTags("users", map[string]interface{}{"region": "us-east"})
+	assert.Equal(t, int64(2), report.Counts[users])
+
+	depth := appoptics.MetricWithTags("depth", map[string]interface{}{"region": "us-east"})
+	assert.Equal(t, 2, int(report.Aggregators[depth].Count))
+	assert.Equal(t, 7.0, report.Aggregators[depth].Last)
+
+	latency := appoptics.MetricWithTags("latency", map[string]interface{}{"region": "us-east"})
+	assert.Equal(t, appoptics.Aggregator{Count: 4, Sum: 80, Min: 20, Max: 20, Last: 20}, report.Aggregators[latency])
+}
+
+func TestServerSetInterval(t *testing.T) {
+	m := appoptics.NewMeasurementSet()
+	s := NewServer(m, WithSetInterval(time.Nanosecond))
+
+	s.HandlePacket([]byte("users:alice|s"))
+	time.Sleep(time.Millisecond)
+	s.HandlePacket([]byte("users:alice|s"))
+
+	assert.Equal(t, int64(2), m.Reset().Counts["users"])
+}
+
+func TestServerGaugesExpire(t *testing.T) {
+	m := appoptics.NewMeasurementSet()
+	s := NewServer(m, WithSetInterval(time.Nanosecond), WithGaugeExpiry(time.Hour))
+	age := func(key string, by time.Duration) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		g := s.gauges[key]
+		g.updated = g.updated.Add(-by)
+		s.gauges[key] = g
+	}
+
+	// a signed value adjusts a gauge updated within the expiry, however short the set interval
+	s.HandlePacket([]byte("depth:10|g\nqueue:5|g"))
+	time.Sleep(time.Millisecond)
+	s.HandlePacket([]byte("depth:-3|g"))
+	assert.Equal(t, 7.0, m.Reset().Aggregators["depth"].Last)
+
+	// but not one which has expired
+	age("depth", time.Hour)
+	s.HandlePacket([]byte("depth:-3|g"))
+	assert.Equal(t, -3.0, m.Reset().Aggregators["depth"].Last)
+
+	// expired gauges are forgotten
+	age("queue", time.Hour)
+	s.mu.Lock()
+	s.gaugesExpiredAt = s.gaugesExpiredAt.Add(-time.Hour)
+	s.mu.Unlock()
+	s.HandlePacket([]byte("depth:1|g"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.Len(t, s.gauges, 1)
+}
+
+// failingConn is a PacketConn whose reads fail with err
+type failingConn struct {
+	net.PacketConn
+	err   error
+	reads int
+}
+
+func (c *failingConn) ReadFrom([]byte) (int, net.Addr, error) {
+	c.reads++
+	return 0, nil, c.err
+}
+
+func (c *failingConn) Close() error {
+	return nil
+}
+
+func TestServerStopsOnReadError(t *testing.T) {
+	s := NewServer(appoptics.NewMeasurementSet())
+	conn := &failingConn{err: errors.New("socket is broken")}
+	s.Serve(conn)
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Serve kept reading after a permanent error")
+	}
+	assert.Equal(t, 1, conn.reads)
+	assert.NoError(t, s.Close())
+}
+
+func TestServerListenAndServe(t *testing.T) {
+	for _, tc := range []struct {
+		network string
+		address string
+	}{
+		{"udp", "127.0.0.1:0"},
+		{"unixgram", filepath.Join(t.TempDir(), "statsd.sock")},
+	} {
+		t.Run(tc.network, func(t *testing.T) {
+			m := appoptics.NewMeasurementSet()
+			s := NewServer(m)
+
+			addr, err := s.ListenAndServe(tc.network, tc.address)
+			require.NoError(t, err)
+
+			conn, err := net.Dial(tc.network, addr.String())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, err = conn.Write([]byte("hits:1|c"))
+			require.NoError(t, err)
+
+			assert.Eventually(t, func() bool {
+				return m.Reset().Counts["hits"] == 1
+			}, time.Second, 5*time.Millisecond)
+
+			assert.NoError(t, s.Close())
+		})
+	}
+}
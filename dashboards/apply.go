@@ -0,0 +1,200 @@
+package dashboards
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/appoptics/appoptics-api-go/internal/linediff"
+)
+
+// Action is the change Apply makes to a Space or Chart
+type Action string
+
+const (
+	Create    Action = "create"
+	Update    Action = "update"
+	Delete    Action = "delete"
+	Unchanged Action = "unchanged"
+)
+
+// Change is a single planned change. Diff holds a line diff of the YAML form of the Chart, prefixed with "-"
+// for removed and "+" for added lines; it is empty for the Space and for unchanged Charts.
+type Change struct {
+	Action Action
+	// Chart is the name of the Chart, or empty for a change to the Space itself
+	Chart string
+	Diff  string
+}
+
+// Plan lists the changes needed to make a Space match a Document, in the order they are applied
+type Plan struct {
+	Space   string
+	SpaceID int
+	Changes []Change
+}
+
+// HasChanges reports whether applying the Plan modifies anything
+func (p *Plan) HasChanges() bool {
+	for _, change := range p.Changes {
+		if change.Action != Unchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the Plan for review, one line per change followed by any diff
+func (p *Plan) String() string {
+	var b strings.Builder
+	for _, change := range p.Changes {
+		if change.Chart == "" {
+			fmt.Fprintf(&b, "%s space %q\n", change.Action, p.Space)
+		} else {
+			fmt.Fprintf(&b, "%s chart %q\n", change.Action, change.Chart)
+		}
+		b.WriteString(linediff.Indent(change.Diff, "    "))
+	}
+	return b.String()
+}
+
+// ApplyOption configures Apply
+type ApplyOption func(*applyOptions)
+
+type applyOptions struct {
+	dryRun   bool
+	noDelete bool
+}
+
+// DryRun makes Apply compute the Plan without changing anything
+func DryRun() ApplyOption {
+	return func(o *applyOptions) {
+		o.dryRun = true
+	}
+}
+
+// NoDelete keeps Charts which exist in the Space but not in the Document
+func NoDelete() ApplyOption {
+	return func(o *applyOptions) {
+		o.noDelete = true
+	}
+}
+
+// Apply makes the Space named by the Document match it, creating the Space if needed. Charts are matched by
+// name: missing Charts are created, differing Charts are updated and Charts absent from the Document are
+// deleted, unless NoDelete is given. A Space in which several Charts share a name is left untouched, with an
+// error, as those Charts cannot be told apart. Applying the same Document twice makes no further changes. The returned
+// Plan describes the changes made, or with DryRun, the changes which would be made.
+func Apply(c appoptics.ServiceAccessor, doc *Document, opts ...ApplyOption) (*Plan, error) {
+	options := &applyOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Space: doc.Space}
+
+	space, err := FindSpace(c, doc.Space)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []*appoptics.Chart
+	if space == nil {
+		plan.Changes = append(plan.Changes, Change{Action: Create})
+		if !options.dryRun {
+			if space, err = c.SpacesService().Create(doc.Space); err != nil {
+				return plan, err
+			}
+		}
+	} else if existing, err = c.ChartsService().List(space.ID); err != nil {
+		return nil, err
+	}
+	if space != nil {
+		plan.SpaceID = space.ID
+	}
+
+	byName := map[string]*appoptics.Chart{}
+	for _, chart := range existing {
+		if _, ok := byName[chart.Name]; ok {
+			return nil, fmt.Errorf("several charts in space %q are named %q", doc.Space, chart.Name)
+		}
+		byName[chart.Name] = chart
+	}
+
+	apply := func(change Change, fn func() error) error {
+		plan.Changes = append(plan.Changes, change)
+		if options.dryRun || change.Action == Unchanged {
+			return nil
+		}
+		return fn()
+	}
+
+	for _, desired := range doc.Charts {
+		desired := desired
+		current, ok := byName[desired.Name]
+
+		if !ok {
+			err = apply(Change{Action: Create, Chart: desired.Name, Diff: diff(nil, &desired)}, func() error {
+				_, err := c.ChartsService().Create(desired.ToChart(), space.ID)
+				return err
+			})
+		} else {
+			currentDoc := FromChart(current)
+			action := Update
+			if currentDoc.Equal(desired) {
+				action = Unchanged
+			}
+			change := Change{Action: action, Chart: desired.Name}
+			if action == Update {
+				change.Diff = diff(&currentDoc, &desired)
+			}
+			err = apply(change, func() error {
+				chart := desired.ToChart()
+				chart.ID = current.ID
+				chart.RelatedSpace = current.RelatedSpace
+				_, err := c.ChartsService().Update(chart, space.ID)
+				return err
+			})
+		}
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	if options.noDelete {
+		return plan, nil
+	}
+
+	for _, chart := range existing {
+		if doc.hasChart(chart.Name) {
+			continue
+		}
+
+		chart := chart
+		currentDoc := FromChart(chart)
+		err = apply(Change{Action: Delete, Chart: chart.Name, Diff: diff(&currentDoc, nil)}, func() error {
+			return c.ChartsService().Delete(chart.ID, space.ID)
+		})
+		if err != nil {
+			return plan, err
+		}
+	}
+
+	return plan, nil
+}
+
+// diff returns a line diff between the YAML forms of two Charts, either of which may be nil
+func diff(from, to *Chart) string {
+	var a, b interface{}
+	if from != nil {
+		a = from
+	}
+	if to != nil {
+		b = to
+	}
+	return linediff.YAML(a, b)
+}
@@ -0,0 +1,166 @@
+package dashboards
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cpuChart() *appoptics.Chart {
+	return &appoptics.Chart{
+		Name: "CPU",
+		Type: "line",
+		Streams: []appoptics.Stream{{
+			ID:            99,
+			Metric:        "cpu.percent.used",
+			GroupFunction: "average",
+			Tags:          []appoptics.Tag{{Name: "env", Values: []string{"prod"}}},
+		}},
+		Thresholds: []appoptics.Threshold{{Operator: ">", Value: 90, Type: "red"}},
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	api, client := newFakeAPI(t)
+	spaceID := api.addSpace("Production", cpuChart(), &appoptics.Chart{Name: "Memory", Type: "stacked"})
+
+	doc, err := Export(client, spaceID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Production", doc.Space)
+	require.Len(t, doc.Charts, 2)
+	assert.Equal(t, "cpu.percent.used", doc.Charts[0].Streams[0].Metric)
+
+	for _, format := range []Format{YAML, JSON} {
+		var buf bytes.Buffer
+		require.NoError(t, doc.Encode(&buf, format))
+
+		decoded, err := Decode(&buf, format)
+		require.NoError(t, err, format)
+		assert.Equal(t, doc, decoded, format)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, doc.Encode(&buf, YAML))
+	assert.NotContains(t, buf.String(), "id:", "documents must not contain IDs")
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for name, input := range map[string]string{
+		"unknown field":   "version: 1\nspace: a\ncolour: red\n",
+		"no space":        "version: 1\n",
+		"bad version":     "version: 2\nspace: a\n",
+		"duplicate chart": "version: 1\nspace: a\ncharts:\n  - name: x\n  - name: x\n",
+		"unnamed chart":   "version: 1\nspace: a\ncharts:\n  - type: line\n",
+	} {
+		_, err := Decode(strings.NewReader(input), YAML)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestApply(t *testing.T) {
+	api, client := newFakeAPI(t)
+	spaceID := api.addSpace("Production",
+		cpuChart(),
+		&appoptics.Chart{Name: "Memory", Type: "stacked"},
+		&appoptics.Chart{Name: "Legacy", Type: "line"},
+	)
+
+	doc := &Document{
+		Version: DocumentVersion,
+		Space:   "Production",
+		Charts: []Chart{
+			FromChart(cpuChart()),
+			{Name: "Memory", Type: "line"},
+			{Name: "Disk", Type: "bignumber"},
+		},
+	}
+
+	plan, err := Apply(client, doc, DryRun())
+	require.NoError(t, err)
+	assert.Equal(t, 0, api.writes)
+	assert.True(t, plan.HasChanges())
+
+	actions := map[string]Action{}
+	for _, change := range plan.Changes {
+		if _, ok := actions[change.Chart]; !ok {
+			actions[change.Chart] = change.Action
+		}
+	}
+	assert.Equal(t, Unchanged, actions["CPU"])
+	assert.Equal(t, Update, actions["Memory"])
+	assert.Equal(t, Create, actions["Disk"])
+	assert.Equal(t, Delete, actions["Legacy"])
+	assert.Contains(t, plan.String(), "update chart \"Memory\"\n      name: Memory\n    - type: stacked\n    + type: line\n")
+	assert.Equal(t, 1, strings.Count(plan.String(), "delete chart"))
+
+	plan, err = Apply(client, doc)
+	require.NoError(t, err)
+	assert.Equal(t, 3, api.writes)
+	assert.Equal(t, []string{"CPU", "Memory", "Disk"}, api.chartNames(spaceID))
+
+	// applying again is a no-op
+	plan, err = Apply(client, doc)
+	require.NoError(t, err)
+	assert.False(t, plan.HasChanges())
+	assert.Equal(t, 3, api.writes)
+}
+
+func TestDuplicateChartNames(t *testing.T) {
+	api, client := newFakeAPI(t)
+	spaceID := api.addSpace("Production",
+		cpuChart(),
+		&appoptics.Chart{Name: "CPU", Type: "line"},
+		&appoptics.Chart{Name: "CPU (2)", Type: "bignumber"},
+		&appoptics.Chart{Name: "CPU", Type: "stacked"},
+	)
+
+	doc, err := Export(client, spaceID)
+	require.NoError(t, err)
+	var names []string
+	for _, chart := range doc.Charts {
+		names = append(names, chart.Name)
+	}
+	assert.Equal(t, []string{"CPU", "CPU (3)", "CPU (2)", "CPU (4)"}, names)
+	assert.Equal(t, "line", doc.Charts[1].Type)
+
+	_, err = Apply(client, doc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `several charts in space "Production" are named "CPU"`)
+	assert.Equal(t, 0, api.writes)
+	assert.Len(t, api.chartNames(spaceID), 4)
+}
+
+func TestApplyCreatesSpace(t *testing.T) {
+	api, client := newFakeAPI(t)
+	api.addSpace("Other", &appoptics.Chart{Name: "Kept"})
+
+	doc := &Document{Version: DocumentVersion, Space: "New", Charts: []Chart{{Name: "CPU", Type: "line"}}}
+
+	plan, err := Apply(client, doc, DryRun())
+	require.NoError(t, err)
+	assert.Equal(t, "create space \"New\"\ncreate chart \"CPU\"\n    + name: CPU\n    + type: line\n", plan.String())
+	assert.Equal(t, 0, api.writes)
+
+	plan, err = Apply(client, doc)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CPU"}, api.chartNames(plan.SpaceID))
+
+	exported, err := ExportByName(client, "New")
+	require.NoError(t, err)
+	assert.Equal(t, doc, exported)
+}
+
+func TestApplyNoDelete(t *testing.T) {
+	api, client := newFakeAPI(t)
+	spaceID := api.addSpace("Production", &appoptics.Chart{Name: "Legacy"})
+
+	doc := &Document{Version: DocumentVersion, Space: "Production", Charts: []Chart{{Name: "CPU"}}}
+	_, err := Apply(client, doc, NoDelete())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Legacy", "CPU"}, api.chartNames(spaceID))
+}
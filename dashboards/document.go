@@ -0,0 +1,266 @@
+// Package dashboards exports AppOptics Spaces and their Charts to portable YAML or JSON documents, and applies
+// such documents back to an organization idempotently, so that dashboards can be kept in version control and
+// moved between organizations.
+package dashboards
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/appoptics/appoptics-api-go"
+	"gopkg.in/yaml.v3"
+)
+
+// DocumentVersion is the version of the document format written by Export
+const DocumentVersion = 1
+
+// Format is a document encoding
+type Format string
+
+const (
+	YAML Format = "yaml"
+	JSON Format = "json"
+)
+
+// Document describes a Space and its Charts without any organization specific IDs. Charts are identified by
+// their names, which must be unique within the Document.
+type Document struct {
+	Version int     `json:"version" yaml:"version"`
+	Space   string  `json:"space" yaml:"space"`
+	Charts  []Chart `json:"charts,omitempty" yaml:"charts,omitempty"`
+}
+
+// Chart is the portable form of an appoptics.Chart
+type Chart struct {
+	Name       string      `json:"name" yaml:"name"`
+	Type       string      `json:"type,omitempty" yaml:"type,omitempty"`
+	Label      string      `json:"label,omitempty" yaml:"label,omitempty"`
+	Min        float64     `json:"min,omitempty" yaml:"min,omitempty"`
+	Max        float64     `json:"max,omitempty" yaml:"max,omitempty"`
+	Streams    []Stream    `json:"streams,omitempty" yaml:"streams,omitempty"`
+	Thresholds []Threshold `json:"thresholds,omitempty" yaml:"thresholds,omitempty"`
+}
+
+// Stream is the portable form of an appoptics.Stream
+type Stream struct {
+	Name               string `json:"name,omitempty" yaml:"name,omitempty"`
+	Metric             string `json:"metric,omitempty" yaml:"metric,omitempty"`
+	Composite          string `json:"composite,omitempty" yaml:"composite,omitempty"`
+	Type               string `json:"type,omitempty" yaml:"type,omitempty"`
+	Tags               []Tag  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	GroupFunction      string `json:"group_function,omitempty" yaml:"group_function,omitempty"`
+	GroupBy            string `json:"group_by,omitempty" yaml:"group_by,omitempty"`
+	SummaryFunction    string `json:"summary_function,omitempty" yaml:"summary_function,omitempty"`
+	DownsampleFunction string `json:"downsample_function,omitempty" yaml:"downsample_function,omitempty"`
+	TransformFunction  string `json:"transform_function,omitempty" yaml:"transform_function,omitempty"`
+	Color              string `json:"color,omitempty" yaml:"color,omitempty"`
+	UnitsShort         string `json:"units_short,omitempty" yaml:"units_short,omitempty"`
+	UnitsLong          string `json:"units_long,omitempty" yaml:"units_long,omitempty"`
+	Period             int    `json:"period,omitempty" yaml:"period,omitempty"`
+	Min                int    `json:"min,omitempty" yaml:"min,omitempty"`
+	Max                int    `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// Tag is the portable form of an appoptics.Tag
+type Tag struct {
+	Name    string   `json:"name" yaml:"name"`
+	Values  []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Grouped bool     `json:"grouped,omitempty" yaml:"grouped,omitempty"`
+	Dynamic bool     `json:"dynamic,omitempty" yaml:"dynamic,omitempty"`
+}
+
+// Threshold is the portable form of an appoptics.Threshold
+type Threshold struct {
+	Operator string  `json:"operator,omitempty" yaml:"operator,omitempty"`
+	Value    float64 `json:"value,omitempty" yaml:"value,omitempty"`
+	Type     string  `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// Validate checks that the Document names its Space and that Chart names are present and unique
+func (d *Document) Validate() error {
+	if d.Version != DocumentVersion {
+		return fmt.Errorf("unsupported document version %d", d.Version)
+	}
+	if d.Space == "" {
+		return fmt.Errorf("document has no space name")
+	}
+
+	seen := map[string]bool{}
+	for i, chart := range d.Charts {
+		if chart.Name == "" {
+			return fmt.Errorf("chart %d has no name", i+1)
+		}
+		if seen[chart.Name] {
+			return fmt.Errorf("chart name %q is not unique", chart.Name)
+		}
+		seen[chart.Name] = true
+	}
+	return nil
+}
+
+func (d *Document) hasChart(name string) bool {
+	for _, chart := range d.Charts {
+		if chart.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode writes the Document to w in the given Format
+func (d *Document) Encode(w io.Writer, format Format) error {
+	switch format {
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+		return enc.Close()
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// Decode reads and validates a Document in the given Format
+func Decode(r io.Reader, format Format) (*Document, error) {
+	d := &Document{}
+
+	var err error
+	switch format {
+	case YAML:
+		dec := yaml.NewDecoder(r)
+		dec.KnownFields(true)
+		err = dec.Decode(d)
+	case JSON:
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(d)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range d.Charts {
+		d.Charts[i].normalize()
+	}
+	return d, d.Validate()
+}
+
+// FromChart converts an appoptics.Chart to its portable form
+func FromChart(c *appoptics.Chart) Chart {
+	chart := Chart{
+		Name:  c.Name,
+		Type:  c.Type,
+		Label: c.Label,
+		Min:   c.Min,
+		Max:   c.Max,
+	}
+
+	for _, s := range c.Streams {
+		stream := Stream{
+			Name:               s.Name,
+			Metric:             s.Metric,
+			Composite:          s.Composite,
+			Type:               s.Type,
+			GroupFunction:      s.GroupFunction,
+			GroupBy:            s.GroupBy,
+			SummaryFunction:    s.SummaryFunction,
+			DownsampleFunction: s.DownsampleFunction,
+			TransformFunction:  s.TransformFunction,
+			Color:              s.Color,
+			UnitsShort:         s.UnitsShort,
+			UnitsLong:          s.UnitsLong,
+			Period:             s.Period,
+			Min:                s.Min,
+			Max:                s.Max,
+		}
+		for _, t := range s.Tags {
+			stream.Tags = append(stream.Tags, Tag{Name: t.Name, Values: t.Values, Grouped: t.Grouped, Dynamic: t.Dynamic})
+		}
+		chart.Streams = append(chart.Streams, stream)
+	}
+
+	for _, t := range c.Thresholds {
+		chart.Thresholds = append(chart.Thresholds, Threshold{Operator: t.Operator, Value: t.Value, Type: t.Type})
+	}
+
+	chart.normalize()
+	return chart
+}
+
+// ToChart converts the portable form back to an appoptics.Chart, without an ID
+func (c Chart) ToChart() *appoptics.Chart {
+	chart := &appoptics.Chart{
+		Name:  c.Name,
+		Type:  c.Type,
+		Label: c.Label,
+		Min:   c.Min,
+		Max:   c.Max,
+	}
+
+	for _, s := range c.Streams {
+		stream := appoptics.Stream{
+			Name:               s.Name,
+			Metric:             s.Metric,
+			Composite:          s.Composite,
+			Type:               s.Type,
+			GroupFunction:      s.GroupFunction,
+			GroupBy:            s.GroupBy,
+			SummaryFunction:    s.SummaryFunction,
+			DownsampleFunction: s.DownsampleFunction,
+			TransformFunction:  s.TransformFunction,
+			Color:              s.Color,
+			UnitsShort:         s.UnitsShort,
+			UnitsLong:          s.UnitsLong,
+			Period:             s.Period,
+			Min:                s.Min,
+			Max:                s.Max,
+		}
+		for _, t := range s.Tags {
+			stream.Tags = append(stream.Tags, appoptics.Tag{Name: t.Name, Values: t.Values, Grouped: t.Grouped, Dynamic: t.Dynamic})
+		}
+		chart.Streams = append(chart.Streams, stream)
+	}
+
+	for _, t := range c.Thresholds {
+		chart.Thresholds = append(chart.Thresholds, appoptics.Threshold{Operator: t.Operator, Value: t.Value, Type: t.Type})
+	}
+
+	return chart
+}
+
+// Equal reports whether two Charts are the same once empty lists are disregarded
+func (c Chart) Equal(other Chart) bool {
+	a, errA := json.Marshal(c)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// normalize replaces empty slices with nil so that documents compare equal regardless of how they were
+// decoded
+func (c *Chart) normalize() {
+	if len(c.Streams) == 0 {
+		c.Streams = nil
+	}
+	if len(c.Thresholds) == 0 {
+		c.Thresholds = nil
+	}
+	for i := range c.Streams {
+		if len(c.Streams[i].Tags) == 0 {
+			c.Streams[i].Tags = nil
+		}
+		for j := range c.Streams[i].Tags {
+			if len(c.Streams[i].Tags[j].Values) == 0 {
+				c.Streams[i].Tags[j].Values = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package dashboards
+
+import (
+	"fmt"
+
+	"github.com/appoptics/appoptics-api-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// spacesPageLength is the page size used when searching Spaces by name
+const spacesPageLength = 100
+
+// Export builds a Document from the Space with the given ID and all of its Charts. Documents match Charts by
+// name, so when several Charts share a name, all but the first are renamed with a numeric suffix, e.g. "CPU (2)",
+// and a warning is logged. Apply refuses to update such a Space until its Charts have been renamed.
+func Export(c appoptics.ServiceAccessor, spaceID int) (*Document, error) {
+	space, err := c.SpacesService().Retrieve(spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	charts, err := c.ChartsService().List(spaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Version: DocumentVersion, Space: space.Name}
+	names := map[string]bool{}
+	for _, chart := range charts {
+		names[chart.Name] = true
+	}
+	seen := map[string]bool{}
+	for _, chart := range charts {
+		exported := FromChart(chart)
+		if seen[chart.Name] {
+			exported.Name = uniqueName(chart.Name, names)
+			names[exported.Name] = true
+			log.Warnf("space %q has several charts named %q; exporting chart %d as %q", space.Name, chart.Name, chart.ID, exported.Name)
+		}
+		seen[chart.Name] = true
+		doc.Charts = append(doc.Charts, exported)
+	}
+
+	return doc, doc.Validate()
+}
+
+// uniqueName returns name with the lowest numeric suffix not in names
+func uniqueName(name string, names map[string]bool) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if !names[candidate] {
+			return candidate
+		}
+	}
+}
+
+// ExportByName is Export for the Space with the given name
+func ExportByName(c appoptics.ServiceAccessor, spaceName string) (*Document, error) {
+	space, err := FindSpace(c, spaceName)
+	if err != nil {
+		return nil, err
+	}
+	if space == nil {
+		return nil, fmt.Errorf("no space named %q", spaceName)
+	}
+	return Export(c, space.ID)
+}
+
+// FindSpace returns the Space with the given name, or nil if there is none
+func FindSpace(c appoptics.ServiceAccessor, name string) (*appoptics.Space, error) {
+	pagination := &appoptics.PaginationParameters{Length: spacesPageLength}
+	for {
+		spaces, err := c.SpacesService().List(pagination)
+		if err != nil {
+			return nil, err
+		}
+		for _, space := range spaces {
+			if space.Name == name {
+				return space, nil
+			}
+		}
+		if len(spaces) < spacesPageLength {
+			return nil, nil
+		}
+		pagination.Offset += len(spaces)
+	}
+}
@@ -0,0 +1,178 @@
+package dashboards
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/appoptics/appoptics-api-go"
+	"github.com/gorilla/mux"
+)
+
+// fakeAPI is an in-memory implementation of the Spaces and Charts endpoints
+type fakeAPI struct {
+	mu     sync.Mutex
+	nextID int
+	spaces []*appoptics.Space
+	charts map[int][]*appoptics.Chart
+	writes int
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *appoptics.Client) {
+	api := &fakeAPI{nextID: 1, charts: map[int][]*appoptics.Chart{}}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/spaces", api.listSpaces).Methods("GET")
+	router.HandleFunc("/v1/spaces", api.createSpace).Methods("POST")
+	router.HandleFunc("/v1/spaces/{id}", api.retrieveSpace).Methods("GET")
+	router.HandleFunc("/v1/spaces/{id}/charts", api.listCharts).Methods("GET")
+	router.HandleFunc("/v1/spaces/{id}/charts", api.createChart).Methods("POST")
+	router.HandleFunc("/v1/spaces/{id}/charts/{chartId}", api.updateChart).Methods("PUT")
+	router.HandleFunc("/v1/spaces/{id}/charts/{chartId}", api.deleteChart).Methods("DELETE")
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return api, appoptics.NewClient("deadbeef", appoptics.BaseURLClientOption(server.URL+"/v1/"))
+}
+
+func (api *fakeAPI) addSpace(name string, charts ...*appoptics.Chart) int {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	space := &appoptics.Space{ID: api.id(), Name: name}
+	api.spaces = append(api.spaces, space)
+	for _, chart := range charts {
+		chart.ID = api.id()
+		api.charts[space.ID] = append(api.charts[space.ID], chart)
+	}
+	return space.ID
+}
+
+func (api *fakeAPI) id() int {
+	id := api.nextID
+	api.nextID++
+	return id
+}
+
+func (api *fakeAPI) listSpaces(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	length, _ := strconv.Atoi(r.URL.Query().Get("length"))
+	spaces := api.spaces[min(offset, len(api.spaces)):]
+	if length > 0 && len(spaces) > length {
+		spaces = spaces[:length]
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"spaces": spaces})
+}
+
+func (api *fakeAPI) createSpace(w http.ResponseWriter, r *http.Request) {
+	space := &appoptics.Space{}
+	decodeBody(r, space)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.writes++
+	space.ID = api.id()
+	api.spaces = append(api.spaces, space)
+	json.NewEncoder(w).Encode(space)
+}
+
+func (api *fakeAPI) retrieveSpace(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	for _, space := range api.spaces {
+		if space.ID == id {
+			json.NewEncoder(w).Encode(space)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (api *fakeAPI) listCharts(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	charts := api.charts[id]
+	if charts == nil {
+		charts = []*appoptics.Chart{}
+	}
+	json.NewEncoder(w).Encode(charts)
+}
+
+func (api *fakeAPI) createChart(w http.ResponseWriter, r *http.Request) {
+	chart := &appoptics.Chart{}
+	decodeBody(r, chart)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.writes++
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	chart.ID = api.id()
+	api.charts[id] = append(api.charts[id], chart)
+	json.NewEncoder(w).Encode(chart)
+}
+
+func (api *fakeAPI) updateChart(w http.ResponseWriter, r *http.Request) {
+	chart := &appoptics.Chart{}
+	decodeBody(r, chart)
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.writes++
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	for i, existing := range api.charts[id] {
+		if strconv.Itoa(existing.ID) == mux.Vars(r)["chartId"] {
+			api.charts[id][i] = chart
+			json.NewEncoder(w).Encode(chart)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (api *fakeAPI) deleteChart(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.writes++
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	charts := api.charts[id]
+	for i, existing := range charts {
+		if strconv.Itoa(existing.ID) == mux.Vars(r)["chartId"] {
+			api.charts[id] = append(charts[:i:i], charts[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (api *fakeAPI) chartNames(spaceID int) []string {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	var names []string
+	for _, chart := range api.charts[spaceID] {
+		names = append(names, chart.Name)
+	}
+	return names
+}
+
+func decodeBody(r *http.Request, v interface{}) {
+	body, err := gzip.NewReader(r.Body)
+	if err != nil {
+		panic(fmt.Sprintf("request body is not gzipped: %s", err))
+	}
+	json.NewDecoder(body).Decode(v)
+}
@@ -0,0 +1,77 @@
+// Package linediff renders line based diffs of values in their YAML form, for reviewing planned changes
+package linediff
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML returns a diff between the YAML forms of from and to, either of which may be nil. Unchanged lines are
+// indented by two spaces, removed lines are prefixed with "- " and added lines with "+ ".
+func YAML(from, to interface{}) string {
+	return Lines(yamlLines(from), yamlLines(to))
+}
+
+// Lines returns a diff between two sequences of lines, based on their longest common subsequence
+func Lines(a, b []string) string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
+
+// Indent prefixes every line of a diff with indent, for nesting under a heading
+func Indent(diff, indent string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line != "" {
+			b.WriteString(indent + line)
+		}
+	}
+	return b.String()
+}
+
+func yamlLines(v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return []string{err.Error()}
+	}
+	enc.Close()
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
@@ -0,0 +1,26 @@
+package linediff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLines(t *testing.T) {
+	assert.Equal(t, "  a\n- b\n+ c\n  d\n+ e\n", Lines([]string{"a", "b", "d"}, []string{"a", "c", "d", "e"}))
+	assert.Equal(t, "", Lines(nil, nil))
+}
+
+func TestYAML(t *testing.T) {
+	type doc struct {
+		Name string `yaml:"name"`
+		Type string `yaml:"type,omitempty"`
+	}
+
+	assert.Equal(t, "+ name: a\n", YAML(nil, &doc{Name: "a"}))
+	assert.Equal(t, "  name: a\n- type: x\n+ type: z\n", YAML(&doc{Name: "a", Type: "x"}, &doc{Name: "a", Type: "z"}))
+}
+
+func TestIndent(t *testing.T) {
+	assert.Equal(t, "    + a\n    - b\n", Indent("+ a\n- b\n", "    "))
+}
@@ -1,6 +1,23 @@
 package appoptics
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// JobStateComplete is the State of a Job which finished successfully
+	JobStateComplete = "complete"
+	// JobStateFailed is the State of a Job which finished with errors
+	JobStateFailed = "failed"
+
+	defaultJobPollInterval    = 500 * time.Millisecond
+	defaultJobMaxPollInterval = 10 * time.Second
+)
 
 // Job is the representation of a task happening in the AppOptics cloud
 type Job struct {
@@ -11,8 +28,64 @@ type Job struct {
 	Errors   map[string][]string `json:"errors,omitempty"`
 }
 
+// Finished reports whether the Job has completed or failed
+func (j *Job) Finished() bool {
+	return j.State == JobStateComplete || j.State == JobStateFailed
+}
+
+// JobError is returned by WaitForJob when a Job fails. Errors holds the messages reported by the Job, keyed by
+// the field or resource they relate to.
+type JobError struct {
+	ID     int
+	Errors map[string][]string
+}
+
+func (e *JobError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("job %d failed", e.ID)
+	}
+
+	keys := make([]string, 0, len(e.Errors))
+	for k := range e.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	messages := make([]string, len(keys))
+	for i, k := range keys {
+		messages[i] = fmt.Sprintf("%s %s", k, strings.Join(e.Errors[k], ", "))
+	}
+	return fmt.Sprintf("job %d failed: %s", e.ID, strings.Join(messages, "; "))
+}
+
+// WaitForJobOption configures WaitForJob
+type WaitForJobOption func(*waitForJobConfig)
+
+type waitForJobConfig struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	progress    func(float64)
+}
+
+// JobPollInterval sets the initial delay between polls and the limit it backs off to, which must both be
+// positive. The defaults are 500ms and 10s.
+func JobPollInterval(initial, max time.Duration) WaitForJobOption {
+	return func(c *waitForJobConfig) {
+		c.interval = initial
+		c.maxInterval = max
+	}
+}
+
+// JobProgressCallback sets a function called with the Job's Progress each time it changes
+func JobProgressCallback(fn func(progress float64)) WaitForJobOption {
+	return func(c *waitForJobConfig) {
+		c.progress = fn
+	}
+}
+
 type JobsCommunicator interface {
 	Retrieve(int) (*Job, error)
+	RetrieveContext(context.Context, int) (*Job, error)
 }
 
 type JobsService struct {
@@ -25,6 +98,66 @@ func NewJobsService(c *Client) *JobsService {
 
 // Retrieve gets the Job identified by the provided ID
 func (js *JobsService) Retrieve(id int) (*Job, error) {
+	return js.RetrieveContext(context.Background(), id)
+}
+
+// WaitForJob polls the Job identified by the provided ID, backing off exponentially, until it has completed or
+// failed. A failed Job is returned along with a *JobError. If ctx is done first, the last retrieved Job is
+// returned with the context's error. An error is returned at once for non-positive poll intervals.
+func WaitForJob(ctx context.Context, jc JobsCommunicator, id int, opts ...WaitForJobOption) (*Job, error) {
+	config := &waitForJobConfig{
+		interval:    defaultJobPollInterval,
+		maxInterval: defaultJobMaxPollInterval,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.interval <= 0 || config.maxInterval <= 0 {
+		return nil, errors.New("job poll intervals must be positive")
+	}
+
+	var job *Job
+	lastProgress := -1.0
+	interval := config.interval
+
+	for {
+		current, err := jc.RetrieveContext(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return job, ctx.Err()
+			}
+			return job, err
+		}
+		job = current
+
+		if config.progress != nil && job.Progress != lastProgress {
+			lastProgress = job.Progress
+			config.progress(job.Progress)
+		}
+
+		switch job.State {
+		case JobStateComplete:
+			return job, nil
+		case JobStateFailed:
+			return job, &JobError{ID: job.ID, Errors: job.Errors}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return job, ctx.Err()
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > config.maxInterval {
+			interval = config.maxInterval
+		}
+	}
+}
+
+// RetrieveContext gets the Job identified by the provided ID, canceling the request if ctx is done first
+func (js *JobsService) RetrieveContext(ctx context.Context, id int) (*Job, error) {
 	path := fmt.Sprintf("jobs/%d", id)
 	req, err := js.client.NewRequest("GET", path, nil)
 	if err != nil {
@@ -33,7 +166,7 @@ func (js *JobsService) Retrieve(id int) (*Job, error) {
 
 	job := &Job{}
 
-	_, err = js.client.Do(req, job)
+	_, err = js.client.Do(req.WithContext(ctx), job)
 	if err != nil {
 		return nil, err
 	}
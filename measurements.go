@@ -1,9 +1,15 @@
 package appoptics
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -27,9 +33,70 @@ type Measurement struct {
 	Attributes map[string]interface{} `json:"attributes,omitempty"`
 }
 
+// RetrieveMeasurementsRequest holds the parameters of a Measurements query. Either Name or Composite must be set.
+// https://docs.appoptics.com/api/#retrieve-a-measurement
+type RetrieveMeasurementsRequest struct {
+	// Name is the Metric being queried
+	Name string
+	// Composite is a composite metric expression, queried instead of Name
+	Composite string
+	// StartTime is the beginning of the queried period. Either StartTime or Duration must be given.
+	StartTime time.Time
+	// EndTime is the end of the queried period, defaulting to now
+	EndTime time.Time
+	// Duration queries the most recent period of the given length instead of StartTime
+	Duration time.Duration
+	// Resolution is the resolution of the returned series, in seconds
+	Resolution int
+	// Tags restricts the returned series to those having all of the given tag values
+	Tags map[string]string
+	// TagsSearch restricts the returned series with a tag search expression, e.g. "region=us-* and !env=dev"
+	TagsSearch string
+	// GroupBy is a tag name, or "*", used to combine series
+	GroupBy string
+	// GroupByFunction is the function used to combine grouped series, e.g. "sum", "min", "max" or "mean"
+	GroupByFunction string
+	// SummaryFunction selects which summary field is used to produce each point, e.g. "mean", "sum" or "count"
+	SummaryFunction string
+}
+
+// MeasurementsResponse is the result of a Measurements query
+type MeasurementsResponse struct {
+	// Name is the queried Metric name; it is empty for composite queries
+	Name string `json:"name,omitempty"`
+	// Compose is the queried composite expression; it is empty for Metric queries
+	Compose string `json:"compose,omitempty"`
+	// Resolution is the resolution of the series, in seconds, which may be coarser than requested
+	Resolution int                   `json:"resolution"`
+	Series     []MeasurementSeries   `json:"series"`
+	Attributes *MetricAttributes     `json:"attributes,omitempty"`
+	Query      MeasurementsQueryInfo `json:"query"`
+}
+
+// MeasurementsQueryInfo holds pagination information for Measurements queries
+type MeasurementsQueryInfo struct {
+	// NextTime is set when the result was truncated, and should be used as the StartTime of the next request
+	NextTime int64 `json:"next_time,omitempty"`
+}
+
+// MeasurementSeries is the timeseries of points for a single set of tags
+type MeasurementSeries struct {
+	Tags         map[string]string  `json:"tags"`
+	Measurements []MeasurementPoint `json:"measurements"`
+	// Metric describes the Metric behind a series returned by a composite query
+	Metric *Metric `json:"metric,omitempty"`
+}
+
+// MeasurementPoint is a single point in a MeasurementSeries
+type MeasurementPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
 // MeasurementsCommunicator defines an interface for communicating with the Measurements portion of the AppOptics API
 type MeasurementsCommunicator interface {
 	Create(*MeasurementsBatch) (*http.Response, error)
+	Retrieve(*RetrieveMeasurementsRequest) (*MeasurementsResponse, error)
 }
 
 // MeasurementsService implements MeasurementsCommunicator
@@ -61,6 +128,138 @@ func (ms *MeasurementsService) Create(batch *MeasurementsBatch) (*http.Response,
 	return ms.client.Do(req, nil)
 }
 
+// Retrieve queries a single page of Measurements for a Metric or composite expression. If the result was
+// truncated, Query.NextTime holds the start time of the remaining data; RetrieveAll follows it automatically.
+func (ms *MeasurementsService) Retrieve(rmr *RetrieveMeasurementsRequest) (*MeasurementsResponse, error) {
+	path, err := rmr.path()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := ms.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	values := req.URL.Query()
+	rmr.addValues(values)
+	req.URL.RawQuery = values.Encode()
+
+	measurementsResponse := &MeasurementsResponse{}
+	_, err = ms.client.Do(req, measurementsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return measurementsResponse, nil
+}
+
+// RetrieveAll queries Measurements like Retrieve, following pagination until the full period has been fetched.
+// Points from each page are appended to the series with the same tags.
+func RetrieveAll(mc MeasurementsCommunicator, rmr *RetrieveMeasurementsRequest) (*MeasurementsResponse, error) {
+	page := *rmr
+	// a Duration query is made absolute so that later pages cover the same period
+	if page.Duration > 0 && page.StartTime.IsZero() {
+		if page.EndTime.IsZero() {
+			page.EndTime = time.Now()
+		}
+		page.StartTime = page.EndTime.Add(-page.Duration)
+		page.Duration = 0
+	}
+
+	var result *MeasurementsResponse
+	seriesIndex := map[string]int{}
+
+	for {
+		resp, err := mc.Retrieve(&page)
+		if err != nil {
+			return nil, err
+		}
+
+		pageSeries := resp.Series
+		if result == nil {
+			result = resp
+			result.Series = nil
+		}
+		for _, series := range pageSeries {
+			key := seriesKey(series.Tags)
+			if i, ok := seriesIndex[key]; ok {
+				result.Series[i].Measurements = append(result.Series[i].Measurements, series.Measurements...)
+				continue
+			}
+			seriesIndex[key] = len(result.Series)
+			result.Series = append(result.Series, series)
+		}
+
+		next := resp.Query.NextTime
+		if next == 0 || (!page.StartTime.IsZero() && next <= page.StartTime.Unix()) ||
+			(!page.EndTime.IsZero() && next >= page.EndTime.Unix()) {
+			result.Query = MeasurementsQueryInfo{}
+			return result, nil
+		}
+
+		page.StartTime = time.Unix(next, 0)
+	}
+}
+
+func (rmr *RetrieveMeasurementsRequest) path() (string, error) {
+	if rmr.Composite != "" {
+		return "measurements", nil
+	}
+	if rmr.Name == "" {
+		return "", errors.New("a Metric name or composite expression is required")
+	}
+	return "measurements/" + url.PathEscape(rmr.Name), nil
+}
+
+func (rmr *RetrieveMeasurementsRequest) addValues(values url.Values) {
+	if rmr.Composite != "" {
+		values.Set("compose", rmr.Composite)
+	}
+	if !rmr.StartTime.IsZero() {
+		values.Set("start_time", strconv.FormatInt(rmr.StartTime.Unix(), 10))
+	}
+	if !rmr.EndTime.IsZero() {
+		values.Set("end_time", strconv.FormatInt(rmr.EndTime.Unix(), 10))
+	}
+	if rmr.Duration > 0 {
+		values.Set("duration", strconv.FormatInt(int64(rmr.Duration/time.Second), 10))
+	}
+	if rmr.Resolution > 0 {
+		values.Set("resolution", strconv.Itoa(rmr.Resolution))
+	}
+	for k, v := range rmr.Tags {
+		values.Set(fmt.Sprintf("tags[%s]", k), v)
+	}
+	if rmr.TagsSearch != "" {
+		values.Set("tags_search", rmr.TagsSearch)
+	}
+	if rmr.GroupBy != "" {
+		values.Set("group_by", rmr.GroupBy)
+	}
+	if rmr.GroupByFunction != "" {
+		values.Set("group_by_function", rmr.GroupByFunction)
+	}
+	if rmr.SummaryFunction != "" {
+		values.Set("summary_function", rmr.SummaryFunction)
+	}
+}
+
+// seriesKey returns a stable identifier for a set of tags
+func seriesKey(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
+
+// Timestamp returns the point's timestamp as a time.Time
+func (mp MeasurementPoint) Timestamp() time.Time {
+	return time.Unix(mp.Time, 0)
+}
+
 // printMeasurements pretty-prints the supplied measurements to stdout
 func printMeasurements(data []Measurement) {
 	for _, measurement := range data {
@@ -1,6 +1,16 @@
 package appoptics
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+)
 
 // Metric is an AppOptics Metric
 type Metric struct {
@@ -32,21 +42,71 @@ type MetricsResponse struct {
 	Metrics []*Metric `json:"metrics,omitempty"`
 }
 
+// ListMetricsRequest holds the filters and pagination for listing Metrics
+type ListMetricsRequest struct {
+	// Name restricts the results to Metrics whose names contain the given string
+	Name string
+	// Tags restricts the results to Metrics reported with all of the given tag values
+	Tags map[string]string
+	// Pagination selects the page of results
+	Pagination *PaginationParameters
+}
+
+// ListTagsRequest holds the filters and pagination for listing tags
+type ListTagsRequest struct {
+	// Metric restricts the results to tags reported with the named Metric
+	Metric string
+	// Name restricts the results to tags whose names contain the given string
+	Name string
+	// Pagination selects the page of results
+	Pagination *PaginationParameters
+}
+
+// ListTagsResponse represents the returned data payload from the tags API
+type ListTagsResponse struct {
+	Query QueryInfo `json:"query,omitempty"`
+	Tags  []*Tag    `json:"tags"`
+}
+
 type MetricsService struct {
 	client *Client
 }
 
-// MetricUpdatePayload will apply the state represented by Attributes to the Metrics identified by Names
+// MetricUpdatePayload will apply the state represented by Attributes to the Metrics identified by Names, or to
+// all Metrics whose names match Pattern, e.g. "api.*"
 type MetricUpdatePayload struct {
-	Names      []string         `json:"names"`
+	Names      []string         `json:"names,omitempty"`
+	Pattern    string           `json:"pattern,omitempty"`
 	Attributes MetricAttributes `json:"attributes"`
 }
 
+// MetricUpdateFailure describes a Metric which a bulk update could not be applied to
+type MetricUpdateFailure struct {
+	Name   string
+	Errors []string
+}
+
+// MetricUpdateError is returned by UpdateMetricsAndWait when the bulk update Job reports failures
+type MetricUpdateError struct {
+	JobID    int
+	Failures []MetricUpdateFailure
+}
+
+func (e *MetricUpdateError) Error() string {
+	if len(e.Failures) == 0 {
+		return fmt.Sprintf("metrics update job %d failed", e.JobID)
+	}
+	return fmt.Sprintf("metrics update job %d failed for %d metrics", e.JobID, len(e.Failures))
+}
+
 type MetricsCommunicator interface {
-	List() (*MetricsResponse, error)
+	List(*ListMetricsRequest) (*MetricsResponse, error)
+	ListTags(*ListTagsRequest) (*ListTagsResponse, error)
+	RetrieveTag(string, *ListTagsRequest) (*Tag, error)
 	Retrieve(string) (*Metric, error)
 	Create(*Metric) (*Metric, error)
 	Update(string, *Metric) error
+	UpdateAll(*MetricUpdatePayload) (*Job, error)
 	Delete(string) error
 }
 
@@ -54,13 +114,26 @@ func NewMetricsService(c *Client) *MetricsService {
 	return &MetricsService{c}
 }
 
-// List lists the Metrics in the organization identified by the AppOptics token
-func (ms *MetricsService) List() (*MetricsResponse, error) {
+// List lists the Metrics in the organization identified by the AppOptics token. A nil ListMetricsRequest lists
+// the first page of all Metrics.
+func (ms *MetricsService) List(lmr *ListMetricsRequest) (*MetricsResponse, error) {
 	req, err := ms.client.NewRequest("GET", "metrics", nil)
 	if err != nil {
 		return nil, err
 	}
 
+	if lmr != nil {
+		lmr.Pagination.AddToRequest(req)
+		values := req.URL.Query()
+		if lmr.Name != "" {
+			values.Set("name", lmr.Name)
+		}
+		for k, v := range lmr.Tags {
+			values.Set(fmt.Sprintf("tags[%s]", k), v)
+		}
+		req.URL.RawQuery = values.Encode()
+	}
+
 	metricsResponse := &MetricsResponse{}
 
 	_, err = ms.client.Do(req, &metricsResponse)
@@ -72,10 +145,92 @@ func (ms *MetricsService) List() (*MetricsResponse, error) {
 	return metricsResponse, nil
 }
 
+// ListAllMetrics lists Metrics page by page until all Metrics matching the request have been retrieved
+func ListAllMetrics(mc MetricsCommunicator, lmr *ListMetricsRequest) ([]*Metric, error) {
+	page := ListMetricsRequest{}
+	if lmr != nil {
+		page = *lmr
+	}
+	pagination := PaginationParameters{}
+	if page.Pagination != nil {
+		pagination = *page.Pagination
+	}
+	page.Pagination = &pagination
+
+	var metrics []*Metric
+	for {
+		metricsResponse, err := mc.List(&page)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, metricsResponse.Metrics...)
+
+		pagination.Offset += len(metricsResponse.Metrics)
+		if len(metricsResponse.Metrics) == 0 || pagination.Offset >= metricsResponse.Query.Found {
+			return metrics, nil
+		}
+	}
+}
+
+// ListTags lists the tag names, and the values seen for each, across all Metrics or for the Metric named in
+// the request. Comparing the number of values for each tag is a cheap way to spot runaway cardinality.
+func (ms *MetricsService) ListTags(ltr *ListTagsRequest) (*ListTagsResponse, error) {
+	req, err := ms.client.NewRequest("GET", "tags", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ltr.addToRequest(req)
+
+	tagsResponse := &ListTagsResponse{}
+
+	_, err = ms.client.Do(req, tagsResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return tagsResponse, nil
+}
+
+// RetrieveTag fetches the values seen for the named tag, across all Metrics or for the Metric named in the
+// request
+func (ms *MetricsService) RetrieveTag(name string, ltr *ListTagsRequest) (*Tag, error) {
+	req, err := ms.client.NewRequest("GET", fmt.Sprintf("tags/%s", url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ltr.addToRequest(req)
+
+	tag := &Tag{}
+
+	_, err = ms.client.Do(req, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+func (ltr *ListTagsRequest) addToRequest(req *http.Request) {
+	if ltr == nil {
+		return
+	}
+	ltr.Pagination.AddToRequest(req)
+	values := req.URL.Query()
+	if ltr.Metric != "" {
+		values.Set("metric", ltr.Metric)
+	}
+	if ltr.Name != "" {
+		values.Set("name", ltr.Name)
+	}
+	req.URL.RawQuery = values.Encode()
+}
+
 // Retrieve fetches the Metric identified by the given name
 func (ms *MetricsService) Retrieve(name string) (*Metric, error) {
 	metric := &Metric{}
-	path := fmt.Sprintf("metrics/%s", name)
+	path := fmt.Sprintf("metrics/%s", url.PathEscape(name))
 	req, err := ms.client.NewRequest("GET", path, nil)
 
 	if err != nil {
@@ -92,7 +247,7 @@ func (ms *MetricsService) Retrieve(name string) (*Metric, error) {
 
 // Create creates the Metric in the organization identified by the AppOptics token
 func (ms *MetricsService) Create(m *Metric) (*Metric, error) {
-	path := fmt.Sprintf("metrics/%s", m.Name)
+	path := fmt.Sprintf("metrics/%s", url.PathEscape(m.Name))
 	req, err := ms.client.NewRequest("PUT", path, m)
 	if err != nil {
 		return nil, err
@@ -110,7 +265,7 @@ func (ms *MetricsService) Create(m *Metric) (*Metric, error) {
 
 // Update updates the Metric with the given name, setting it to match the Metric pointer argument
 func (ms *MetricsService) Update(originalName string, m *Metric) error {
-	path := fmt.Sprintf("metrics/%s", originalName)
+	path := fmt.Sprintf("metrics/%s", url.PathEscape(originalName))
 	req, err := ms.client.NewRequest("PUT", path, m)
 
 	if err != nil {
@@ -126,9 +281,68 @@ func (ms *MetricsService) Update(originalName string, m *Metric) error {
 	return nil
 }
 
+// UpdateAll applies the attributes in the payload to all of the Metrics it identifies. The update happens
+// asynchronously; the returned Job can be followed with the JobsService or UpdateMetricsAndWait.
+func (ms *MetricsService) UpdateAll(payload *MetricUpdatePayload) (*Job, error) {
+	if len(payload.Names) == 0 && payload.Pattern == "" {
+		return nil, errors.New("a list of Metric names or a pattern is required")
+	}
+
+	req, err := ms.client.NewRequest("PUT", "metrics", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{}
+
+	resp, err := ms.client.Do(req, job)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	// the Job may only be identified by the Location header of the 202 response
+	if job.ID == 0 && resp != nil {
+		if id, err := strconv.Atoi(path.Base(resp.Header.Get("Location"))); err == nil {
+			job.ID = id
+		}
+	}
+	if job.ID == 0 {
+		return nil, errors.New("bulk Metric update response did not identify a Job")
+	}
+
+	return job, nil
+}
+
+// UpdateMetricsAndWait performs a bulk update with UpdateAll and waits for the resulting Job with WaitForJob.
+// If the Job fails, a *MetricUpdateError listing the Metrics which could not be updated is returned.
+func UpdateMetricsAndWait(ctx context.Context, c ServiceAccessor, payload *MetricUpdatePayload, opts ...WaitForJobOption) (*Job, error) {
+	job, err := c.MetricsService().UpdateAll(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err = WaitForJob(ctx, c.JobsService(), job.ID, opts...)
+	if jobErr, ok := err.(*JobError); ok {
+		return job, &MetricUpdateError{JobID: jobErr.ID, Failures: metricUpdateFailures(job)}
+	}
+	return job, err
+}
+
+// metricUpdateFailures converts the errors of a Job, keyed by Metric name, into a sorted slice
+func metricUpdateFailures(job *Job) []MetricUpdateFailure {
+	failures := make([]MetricUpdateFailure, 0, len(job.Errors))
+	for name, errs := range job.Errors {
+		failures = append(failures, MetricUpdateFailure{Name: name, Errors: errs})
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Name < failures[j].Name
+	})
+	return failures
+}
+
 // Delete deletes the Metric matching the name argument
 func (ms *MetricsService) Delete(name string) error {
-	path := fmt.Sprintf("metrics/%s", name)
+	path := fmt.Sprintf("metrics/%s", url.PathEscape(name))
 	req, err := ms.client.NewRequest("DELETE", path, nil)
 	if err != nil {
 		return err
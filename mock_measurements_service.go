@@ -3,9 +3,14 @@ package appoptics
 import "net/http"
 
 type MockMeasurementsService struct {
-	OnCreate func(batch *MeasurementsBatch) (*http.Response, error)
+	OnCreate   func(batch *MeasurementsBatch) (*http.Response, error)
+	OnRetrieve func(rmr *RetrieveMeasurementsRequest) (*MeasurementsResponse, error)
 }
 
 func (m *MockMeasurementsService) Create(batch *MeasurementsBatch) (*http.Response, error) {
 	return m.OnCreate(batch)
 }
+
+func (m *MockMeasurementsService) Retrieve(rmr *RetrieveMeasurementsRequest) (*MeasurementsResponse, error) {
+	return m.OnRetrieve(rmr)
+}
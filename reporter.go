@@ -15,6 +15,9 @@ const (
 	maxMeasurementsPerBatch           = 1000
 )
 
+// ReportInterval is the interval at which a Reporter flushes its MeasurementSet to AppOptics
+const ReportInterval = outputMeasurementsInterval
+
 // Reporter provides a way to persist data from a set collection of Aggregators and Counters at a regular interval
 type Reporter struct {
 	measurementSet   *MeasurementSet
@@ -77,7 +80,7 @@ func (r *Reporter) postMeasurementBatches() {
 }
 
 func (r *Reporter) flushReport(report *MeasurementSetReport) {
-	batchTimeUnixSecs := (time.Now().Unix() / outputMeasurementsIntervalSeconds) * outputMeasurementsIntervalSeconds
+	batchTimeUnixSecs := ReportBatchTime(time.Now())
 
 	var batch *MeasurementsBatch
 	resetBatch := func() {
@@ -140,6 +143,12 @@ func (r *Reporter) flushReport(report *MeasurementSetReport) {
 	}
 }
 
+// ReportBatchTime returns the Unix time of t rounded down to a multiple of ReportInterval, which a Reporter uses
+// to align the measurements it flushes
+func ReportBatchTime(t time.Time) int64 {
+	return (t.Unix() / outputMeasurementsIntervalSeconds) * outputMeasurementsIntervalSeconds
+}
+
 func (r *Reporter) flushReportsForever() {
 	// Sleep for a random duration between 0 and outputMeasurementsInterval in order to randomize the counters output cycle.
 	time.Sleep(time.Duration(rand.Int63n(int64(outputMeasurementsInterval))))
@@ -0,0 +1,210 @@
+package appoptics
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// Service types supported by the typed ServiceSettings
+const (
+	ServiceTypeEmail     = "mail"
+	ServiceTypeSlack     = "slack"
+	ServiceTypePagerDuty = "pagerduty"
+	ServiceTypeWebhook   = "webhook"
+	ServiceTypeOpsGenie  = "opsgenie"
+	ServiceTypeVictorOps = "victorops"
+)
+
+// ErrServiceNotFound is returned by FindServiceByTitle when no Service matches
+var ErrServiceNotFound = errors.New("service not found")
+
+// ServiceSettings is implemented by the typed settings of each supported Service type
+type ServiceSettings interface {
+	// ServiceType returns the Service.Type the settings belong to
+	ServiceType() string
+	// Validate reports missing or malformed settings
+	Validate() error
+	// ToMap converts the settings to the form held in Service.Settings
+	ToMap() map[string]string
+}
+
+// EmailSettings sends notifications to one or more email addresses
+type EmailSettings struct {
+	Addresses []string
+}
+
+// SlackSettings posts notifications to a Slack incoming webhook
+type SlackSettings struct {
+	URL string
+}
+
+// PagerDutySettings opens PagerDuty incidents through an integration key
+type PagerDutySettings struct {
+	ServiceKey string
+	// EventType is the PagerDuty event type, defaulting to "trigger"
+	EventType   string
+	Description string
+}
+
+// WebhookSettings POSTs notifications as JSON to a URL
+type WebhookSettings struct {
+	URL string
+}
+
+// OpsGenieSettings creates OpsGenie alerts
+type OpsGenieSettings struct {
+	APIKey     string
+	Recipients []string
+	Tags       []string
+}
+
+// VictorOpsSettings creates VictorOps incidents
+type VictorOpsSettings struct {
+	APIKey     string
+	RoutingKey string
+}
+
+// NewService returns a Service of the type of the given settings, after validating them
+func NewService(title string, settings ServiceSettings) (*Service, error) {
+	if title == "" {
+		return nil, errors.New("service title is required")
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return &Service{Type: settings.ServiceType(), Title: title, Settings: settings.ToMap()}, nil
+}
+
+// TypedSettings converts the Settings of the Service to the typed settings of its Type. Unrecognized keys are
+// ignored, and the result is not validated.
+func (s *Service) TypedSettings() (ServiceSettings, error) {
+	m := s.Settings
+	switch s.Type {
+	case ServiceTypeEmail:
+		return &EmailSettings{Addresses: splitList(m["addresses"])}, nil
+	case ServiceTypeSlack:
+		return &SlackSettings{URL: m["url"]}, nil
+	case ServiceTypePagerDuty:
+		return &PagerDutySettings{ServiceKey: m["service_key"], EventType: m["event_type"], Description: m["description"]}, nil
+	case ServiceTypeWebhook:
+		return &WebhookSettings{URL: m["url"]}, nil
+	case ServiceTypeOpsGenie:
+		return &OpsGenieSettings{APIKey: m["api_key"], Recipients: splitList(m["recipients"]), Tags: splitList(m["tags"])}, nil
+	case ServiceTypeVictorOps:
+		return &VictorOpsSettings{APIKey: m["api_key"], RoutingKey: m["routing_key"]}, nil
+	}
+	return nil, fmt.Errorf("unsupported service type %q", s.Type)
+}
+
+func (s *EmailSettings) ServiceType() string { return ServiceTypeEmail }
+
+func (s *EmailSettings) Validate() error {
+	if len(s.Addresses) == 0 {
+		return errors.New("mail service needs at least one address")
+	}
+	for _, address := range s.Addresses {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("mail service address %q is invalid: %s", address, err)
+		}
+	}
+	return nil
+}
+
+func (s *EmailSettings) ToMap() map[string]string {
+	return map[string]string{"addresses": strings.Join(s.Addresses, ",")}
+}
+
+func (s *SlackSettings) ServiceType() string { return ServiceTypeSlack }
+
+func (s *SlackSettings) Validate() error {
+	return validateServiceURL(ServiceTypeSlack, s.URL)
+}
+
+func (s *SlackSettings) ToMap() map[string]string {
+	return map[string]string{"url": s.URL}
+}
+
+func (s *PagerDutySettings) ServiceType() string { return ServiceTypePagerDuty }
+
+func (s *PagerDutySettings) Validate() error {
+	if s.ServiceKey == "" {
+		return errors.New("pagerduty service needs a service key")
+	}
+	return nil
+}
+
+func (s *PagerDutySettings) ToMap() map[string]string {
+	m := map[string]string{"service_key": s.ServiceKey, "event_type": s.EventType}
+	if m["event_type"] == "" {
+		m["event_type"] = "trigger"
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	return m
+}
+
+func (s *WebhookSettings) ServiceType() string { return ServiceTypeWebhook }
+
+func (s *WebhookSettings) Validate() error {
+	return validateServiceURL(ServiceTypeWebhook, s.URL)
+}
+
+func (s *WebhookSettings) ToMap() map[string]string {
+	return map[string]string{"url": s.URL}
+}
+
+func (s *OpsGenieSettings) ServiceType() string { return ServiceTypeOpsGenie }
+
+func (s *OpsGenieSettings) Validate() error {
+	if s.APIKey == "" {
+		return errors.New("opsgenie service needs an API key")
+	}
+	return nil
+}
+
+func (s *OpsGenieSettings) ToMap() map[string]string {
+	m := map[string]string{"api_key": s.APIKey}
+	if len(s.Recipients) > 0 {
+		m["recipients"] = strings.Join(s.Recipients, ",")
+	}
+	if len(s.Tags) > 0 {
+		m["tags"] = strings.Join(s.Tags, ",")
+	}
+	return m
+}
+
+func (s *VictorOpsSettings) ServiceType() string { return ServiceTypeVictorOps }
+
+func (s *VictorOpsSettings) Validate() error {
+	if s.APIKey == "" || s.RoutingKey == "" {
+		return errors.New("victorops service needs an API key and a routing key")
+	}
+	return nil
+}
+
+func (s *VictorOpsSettings) ToMap() map[string]string {
+	return map[string]string{"api_key": s.APIKey, "routing_key": s.RoutingKey}
+}
+
+func validateServiceURL(serviceType, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s service needs an http or https URL, not %q", serviceType, rawURL)
+	}
+	return nil
+}
+
+// splitList splits a comma separated setting, trimming spaces and dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
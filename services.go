@@ -10,7 +10,7 @@ type Service struct {
 }
 
 type ServicesCommunicator interface {
-	List() (*ListServicesResponse, error)
+	List(*PaginationParameters) (*ListServicesResponse, error)
 	Retrieve(int) (*Service, error)
 	Create(*Service) (*Service, error)
 	Update(*Service) error
@@ -30,13 +30,15 @@ func NewServiceService(c *Client) *ServicesService {
 	return &ServicesService{c}
 }
 
-// List retrieves all Services
-func (ss *ServicesService) List() (*ListServicesResponse, error) {
+// List retrieves a page of Services. A nil PaginationParameters retrieves the first page.
+func (ss *ServicesService) List(rp *PaginationParameters) (*ListServicesResponse, error) {
 	req, err := ss.client.NewRequest("GET", "services", nil)
 	if err != nil {
 		return nil, err
 	}
 
+	rp.AddToRequest(req)
+
 	servicesResponse := &ListServicesResponse{}
 
 	_, err = ss.client.Do(req, &servicesResponse)
@@ -48,6 +50,24 @@ func (ss *ServicesService) List() (*ListServicesResponse, error) {
 	return servicesResponse, nil
 }
 
+// ListAllServices retrieves every Service, requesting further pages until all have been fetched
+func ListAllServices(sc ServicesCommunicator) ([]*Service, error) {
+	var services []*Service
+	rp := &PaginationParameters{}
+	for {
+		servicesResponse, err := sc.List(rp)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, servicesResponse.Services...)
+
+		rp.Offset += len(servicesResponse.Services)
+		if len(servicesResponse.Services) == 0 || rp.Offset >= servicesResponse.Query.Found {
+			return services, nil
+		}
+	}
+}
+
 // Retrieve returns the Service identified by the parameter
 func (ss *ServicesService) Retrieve(id int) (*Service, error) {
 	service := &Service{}
@@ -82,6 +102,56 @@ func (ss *ServicesService) Create(s *Service) (*Service, error) {
 	return createdService, nil
 }
 
+// CreateServiceWithSettings validates the typed settings and creates a Service of their type with the given title
+func CreateServiceWithSettings(sc ServicesCommunicator, title string, settings ServiceSettings) (*Service, error) {
+	s, err := NewService(title, settings)
+	if err != nil {
+		return nil, err
+	}
+	return sc.Create(s)
+}
+
+// FindServicesByType retrieves every Service of the given type, such as ServiceTypeSlack, from those returned by
+// ListAllServices
+func FindServicesByType(sc ServicesCommunicator, serviceType string) ([]*Service, error) {
+	services, err := ListAllServices(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*Service
+	for _, s := range services {
+		if s.Type == serviceType {
+			matched = append(matched, s)
+		}
+	}
+	return matched, nil
+}
+
+// FindServiceByTitle retrieves the Service with the given type and title. ErrServiceNotFound is returned if there
+// is none, and an error if several Services match.
+func FindServiceByTitle(sc ServicesCommunicator, serviceType, title string) (*Service, error) {
+	services, err := FindServicesByType(sc, serviceType)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Service
+	for _, s := range services {
+		if s.Title != title {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("several %s services are titled %q", serviceType, title)
+		}
+		found = s
+	}
+	if found == nil {
+		return nil, ErrServiceNotFound
+	}
+	return found, nil
+}
+
 // Update updates the Service
 func (ss *ServicesService) Update(s *Service) error {
 	path := fmt.Sprintf("services/%d", s.ID)
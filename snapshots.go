@@ -1,7 +1,13 @@
 package appoptics
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
 	"time"
 )
 
@@ -24,9 +30,22 @@ type SnapshotChart struct {
 	Type    string   `json:"type"`
 }
 
+// NewChartSnapshot returns a Snapshot of the Chart with the given ID and type, e.g. "line" or "stacked", covering
+// the duration up to now
+func NewChartSnapshot(chartID int, chartType string, duration time.Duration) *Snapshot {
+	return &Snapshot{
+		Duration: int(duration / time.Second),
+		EndTime:  time.Now().UTC(),
+		Subject: map[string]SnapshotChart{
+			"chart": {ID: chartID, Sources: []string{"*"}, Type: chartType},
+		},
+	}
+}
+
 type SnapshotsCommunicator interface {
 	Create(*Snapshot) (*Snapshot, error)
 	Retrieve(int) (*Snapshot, error)
+	DownloadImage(context.Context, string, io.Writer) error
 }
 
 type SnapshotsService struct {
@@ -73,3 +92,69 @@ func (ss *SnapshotsService) Retrieve(id int) (*Snapshot, error) {
 
 	return snapshot, nil
 }
+
+// CreateAndDownloadSnapshot creates a Snapshot, waits for the Job rendering it to complete and writes the
+// resulting PNG image to w. The returned Snapshot has its ImageHref populated.
+func CreateAndDownloadSnapshot(ctx context.Context, c ServiceAccessor, s *Snapshot, w io.Writer, opts ...WaitForJobOption) (*Snapshot, error) {
+	snapshot, err := c.SnapshotsService().Create(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.JobHref != "" {
+		jobID, err := hrefID(snapshot.JobHref)
+		if err != nil {
+			return snapshot, err
+		}
+		if _, err := WaitForJob(ctx, c.JobsService(), jobID, opts...); err != nil {
+			return snapshot, err
+		}
+	}
+
+	if snapshot.ImageHref == "" {
+		snapshotID, err := hrefID(snapshot.Href)
+		if err != nil {
+			return snapshot, err
+		}
+		if snapshot, err = c.SnapshotsService().Retrieve(snapshotID); err != nil {
+			return nil, err
+		}
+		if snapshot.ImageHref == "" {
+			return snapshot, errors.New("snapshot has no image after its job completed")
+		}
+	}
+
+	return snapshot, c.SnapshotsService().DownloadImage(ctx, snapshot.ImageHref, w)
+}
+
+// DownloadImage writes the image at href, the ImageHref of a rendered Snapshot, to w. The image is served outside
+// the API, so the request carries no credentials.
+func (ss *SnapshotsService) DownloadImage(ctx context.Context, href string, w io.Writer) error {
+	req, err := http.NewRequest("GET", href, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", ss.client.completeUserAgentString())
+
+	resp, err := ss.client.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error downloading snapshot image: %s", resp.Status)
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// hrefID returns the numeric ID at the end of an API resource URL, e.g. https://api.appoptics.com/v1/jobs/123
+func hrefID(href string) (int, error) {
+	id, err := strconv.Atoi(path.Base(href))
+	if err != nil {
+		return 0, fmt.Errorf("no resource ID in %q", href)
+	}
+	return id, nil
+}